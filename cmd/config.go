@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"broadcaster/storages"
+	"broadcaster/utils/info"
+	"broadcaster/utils/logging"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Bootstrap config tools",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [uri]",
+	Short: "Validates bootstrap config and reports all problems",
+	Long: `Validates bootstrap config with included files and reports all problems with their positions.
+Config is loaded from the uri or file path argument, BCTR_BOOTSTRAP_FILE is used if omitted.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = godotenv.Load()
+
+		uri := os.Getenv(strings.ToUpper(info.EnvPrefix) + "_BOOTSTRAP_FILE")
+		if len(args) > 0 {
+			uri = args[0]
+		}
+		if uri == "" {
+			exitWithError(errors.New("Config uri is required"))
+		}
+		uri, err := configUri(uri)
+		if err != nil {
+			exitWithError(err)
+		}
+
+		logger := logging.NewLogger("error", logging.FormatPretty)
+
+		cfg, err := storages.GetBootstrapConfig(context.Background(), uri, logger)
+		if err != nil {
+			var problems storages.ConfigProblems
+			if !errors.As(err, &problems) {
+				exitWithError(err)
+			}
+			for _, p := range problems {
+				fmt.Fprintln(os.Stderr, p.String())
+			}
+			fmt.Fprintf(os.Stderr, "Config is invalid: %d problem(s)\n", len(problems))
+			os.Exit(1)
+		}
+
+		fmt.Printf("Config is valid: %d feed(s), %d notifier(s)\n", len(cfg.RssFeeds()), len(cfg.Notifiers))
+	},
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Prints bootstrap config JSON Schema",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Print(string(storages.BootstrapSchema))
+	},
+}
+
+// Returns the file uri for plain file paths.
+func configUri(value string) (string, error) {
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" {
+		return value, nil
+	}
+	path, err := filepath.Abs(value)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get config file path: %w", err)
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String(), nil
+}
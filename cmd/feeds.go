@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"broadcaster/storages"
+	"broadcaster/storages/memory"
+	"broadcaster/utils/info"
+	"broadcaster/utils/logging"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	feedsImportCmd.Flags().String("opml", "", "OPML file path")
+	feedsImportCmd.Flags().String("api", "", "Server URL to import feeds into its storage (eg http://localhost:8080)")
+	feedsImportCmd.Flags().StringSlice("profile", nil, "Config profiles of imported feeds, the config defaults are used if omitted")
+	_ = feedsImportCmd.MarkFlagRequired("opml")
+
+	feedsExportCmd.Flags().String("config", "", "Bootstrap config uri or file path, BCTR_BOOTSTRAP_FILE is used if empty")
+	feedsExportCmd.Flags().String("api", "", "Server URL to export feeds from its storage (eg http://localhost:8080)")
+
+	feedsCmd.AddCommand(feedsImportCmd)
+	feedsCmd.AddCommand(feedsExportCmd)
+	rootCmd.AddCommand(feedsCmd)
+}
+
+var feedsCmd = &cobra.Command{
+	Use:   "feeds",
+	Short: "Feeds import and export",
+}
+
+var feedsImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Imports feeds from OPML",
+	Long: `Converts OPML outlines to bootstrap config feeds and prints them as YAML.
+Folders are used as feeds categories. With --api feeds are added to the running server storage instead.
+Imported feeds get notifications of the profiles or the config defaults, the server rejects feeds without notifications.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = godotenv.Load()
+
+		path, _ := cmd.Flags().GetString("opml")
+		api, _ := cmd.Flags().GetString("api")
+		profiles, _ := cmd.Flags().GetStringSlice("profile")
+
+		f, err := os.Open(path)
+		if err != nil {
+			exitWithError(fmt.Errorf("Failed to open OPML file: %w", err))
+		}
+		defer f.Close()
+
+		if api != "" {
+			endpoint := "/api/v1/feeds.opml"
+			if len(profiles) > 0 {
+				endpoint += "?" + url.Values{"profile": profiles}.Encode()
+			}
+			resp, err := apiRequest(http.MethodPost, api, endpoint, f)
+			if err != nil {
+				exitWithError(err)
+			}
+			var result struct {
+				Added   []string `json:"added"`
+				Skipped []string `json:"skipped"`
+			}
+			if err := json.Unmarshal(resp, &result); err != nil {
+				exitWithError(fmt.Errorf("Failed to parse response: %w", err))
+			}
+			fmt.Printf("Imported %d feed(s), %d already existing skipped\n", len(result.Added), len(result.Skipped))
+			return
+		}
+
+		feeds, err := storages.ParseOPML(f)
+		if err != nil {
+			exitWithError(err)
+		}
+		for i := range feeds {
+			feeds[i].Profiles = profiles
+		}
+
+		out := struct {
+			Feeds []storages.FeedConfig `yaml:"feeds"`
+		}{Feeds: feeds}
+
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(out); err != nil {
+			exitWithError(fmt.Errorf("Failed to encode feeds: %w", err))
+		}
+	},
+}
+
+var feedsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports feeds as OPML",
+	Long:  `Prints feeds from the storage bootstrapped with the config as OPML. With --api feeds are exported from the running server.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = godotenv.Load()
+
+		api, _ := cmd.Flags().GetString("api")
+		if api != "" {
+			resp, err := apiRequest(http.MethodGet, api, "/api/v1/feeds.opml", nil)
+			if err != nil {
+				exitWithError(err)
+			}
+			fmt.Print(string(resp))
+			return
+		}
+
+		uri, _ := cmd.Flags().GetString("config")
+		if uri == "" {
+			uri = os.Getenv(strings.ToUpper(info.EnvPrefix) + "_BOOTSTRAP_FILE")
+		}
+		if uri == "" {
+			exitWithError(errors.New("Config uri is required"))
+		}
+		uri, err := configUri(uri)
+		if err != nil {
+			exitWithError(err)
+		}
+
+		ctx := context.Background()
+		logger := logging.NewLogger("error", logging.FormatPretty)
+
+		st := memory.NewStorage(memory.WithLogger(logger))
+		if err := st.BootstrapFromConfigFile(ctx, uri); err != nil {
+			exitWithError(err)
+		}
+		feeds, err := st.Feeds().List(ctx)
+		if err != nil {
+			exitWithError(err)
+		}
+		if err := storages.WriteOPML(os.Stdout, "Broadcaster feeds", feeds); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+// Sends request to the server API and returns the response body.
+// The API token is sent if BCTR_API_TOKEN is set.
+func apiRequest(method, server, path string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequest(method, strings.TrimRight(server, "/")+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request: %w", err)
+	}
+	if token := os.Getenv(strings.ToUpper(info.EnvPrefix) + "_API_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	cl := &http.Client{Timeout: 30 * time.Second}
+	resp, err := cl.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Bad response code (%d): %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+func exitWithError(err error) {
+	fmt.Fprintln(os.Stderr, err.Error())
+	os.Exit(1)
+}
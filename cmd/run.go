@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"broadcaster/services/housekeeper"
+	"broadcaster/services/processer"
+	"broadcaster/storages"
+	"broadcaster/storages/memory"
+	"broadcaster/utils/info"
+	"broadcaster/utils/logging"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	runCmd.Flags().Bool("once", false, "Process feeds once and exit")
+	runCmd.Flags().StringSlice("feed", nil, "Feeds ids to process, all feeds if empty. Can be repeated or comma separated")
+	runCmd.Flags().Bool("dry-run", false, "Print rendered notifications to stdout instead of sending them, logs are written to stderr")
+	runCmd.Flags().Int("backfill", 0, "Hours before the first run to notify about published items, overrides BCTR_BACKFILL_HOURS")
+
+	rootCmd.AddCommand(runCmd)
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Processes feeds without the server",
+	Long: `Processes feeds every BCTR_CHECK_INTERVAL seconds without the REST API and WebSub callbacks, or once with --once.
+Feeds are loaded from the bootstrap config into the storage the same way as by the server.
+With --dry-run notifications are rendered by the configured notifiers and printed instead of being sent, items aren't stored.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = godotenv.Load()
+
+		once, _ := cmd.Flags().GetBool("once")
+		feedIds, _ := cmd.Flags().GetStringSlice("feed")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		backfill, _ := cmd.Flags().GetInt("backfill")
+
+		var cfg serverConfig
+		if err := envconfig.Process(info.EnvPrefix, &cfg); err != nil {
+			exitWithError(fmt.Errorf("Can't load env: %w", err))
+		}
+
+		// Keeping stdout for rendered notifications
+		output := "stdout"
+		if dryRun {
+			output = "stderr"
+		}
+		logger := newAppLogger(cfg, output)
+
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		ctx = logging.ContextWithLogger(ctx, logger)
+		defer cancel()
+
+		/* Storage */
+
+		st := memory.NewStorage(
+			memory.WithLogger(logger.Named("storage")),
+		)
+
+		bootstrap, err := storages.GetBootstrapConfig(ctx, cfg.BootstrapFile, logger.Named("storage"))
+		if err != nil {
+			logger.Fatalf("Bootstrap failed: %s", err.Error())
+		}
+		if err := st.BootstrapFromConfig(ctx, bootstrap); err != nil {
+			logger.Fatalf("Bootstrap failed: %s", err.Error())
+		}
+
+		/* Services */
+
+		opts := []processer.Option{
+			processer.WithLogger(logger.Named("processer")),
+			processer.WithNotifiers(bootstrap.Notifiers),
+			processer.WithoutWebSub(),
+		}
+		if backfill > 0 {
+			opts = append(opts, processer.WithBackfillHours(backfill))
+		}
+		if dryRun {
+			opts = append(opts, processer.WithDryRun(os.Stdout))
+		}
+
+		pcr, err := processer.NewService(st, opts...)
+		if err != nil {
+			logger.Fatalf("Can't create processer service: %v", err.Error())
+		}
+
+		/* Processing */
+
+		// Unknown feeds ids are reported by the first run
+		if err := pcr.ProcessFeeds(ctx, feedIds...); err != nil {
+			exitWithError(fmt.Errorf("Failed to process feeds: %w", err))
+		}
+
+		if once {
+			if n := pcr.DropQueued(); n > 0 {
+				logger.Warnf("Dropping '%d' notifications queued during quiet hours", n)
+			}
+			return
+		}
+
+		hkr, err := housekeeper.NewService(
+			st,
+			housekeeper.WithLogger(logger.Named("housekeeper")),
+		)
+		if err != nil {
+			logger.Fatalf("Can't create housekeeper service: %v", err)
+		}
+
+		ticker := time.NewTicker(time.Duration(cfg.CheckInterval) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := pcr.ProcessFeeds(ctx, feedIds...); err != nil {
+					logger.Error("Failed to process feeds: ", err.Error())
+				}
+				if err := hkr.CleanupFeedItems(ctx, time.Duration(cfg.StateTTL)*time.Second); err != nil {
+					logger.Error("Failed to cleanup feed items: ", err.Error())
+				}
+			case <-ctx.Done():
+				logger.Info("Stopping application")
+				return
+			}
+		}
+	},
+}
@@ -4,13 +4,16 @@ import (
 	"broadcaster/controllers/restapi"
 	"broadcaster/services/housekeeper"
 	"broadcaster/services/processer"
+	"broadcaster/storages"
 	"broadcaster/storages/memory"
+	"broadcaster/structs"
 	"broadcaster/utils/info"
 	"broadcaster/utils/logging"
 	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -31,6 +34,8 @@ type serverConfig struct {
 	BootstrapFile string         `envconfig:"BOOTSTRAP_FILE"`
 	StateTTL      int            `envconfig:"STATE_TTL" default:"86400"`
 	CheckInterval int            `envconfig:"CHECK_INTERVAL" default:"300"`
+	// Bootstrap file reload interval in seconds, reload by SIGHUP only if 0
+	BootstrapReloadInterval int `envconfig:"BOOTSTRAP_RELOAD_INTERVAL"`
 }
 
 var serverCmd = &cobra.Command{
@@ -49,18 +54,7 @@ var serverCmd = &cobra.Command{
 
 		/* Logger */
 
-		logger := logging.NewLogger(cfg.LogLevel, cfg.LogFormat)
-		logger.WithOptions(zap.AddStacktrace(zap.ErrorLevel))
-
-		// Adding env fields for non-local environments
-		if cfg.Env != "local" {
-			logger = logger.With(
-				"app", info.Namespace,
-				"env", cfg.Env,
-				"release", info.Release,
-				"hash", info.CommitHash,
-			)
-		}
+		logger := newAppLogger(cfg, "stdout")
 
 		/* Root context */
 
@@ -95,20 +89,79 @@ var serverCmd = &cobra.Command{
 
 		logger.Debugf("Bootstraping from config file: '%s'", cfg.BootstrapFile)
 
-		if err := st.BootstrapFromConfigFile(ctx, cfg.BootstrapFile); err != nil {
+		bootstrap, err := storages.GetBootstrapConfig(ctx, cfg.BootstrapFile, logger.Named("storage"))
+		if err != nil {
+			logger.Fatalf("Bootstrap failed: %s", err.Error())
+		}
+		if err := st.BootstrapFromConfig(ctx, bootstrap); err != nil {
 			logger.Fatalf("Bootstrap failed: %s", err.Error())
 		}
 
+		// Current config, its defaults and profiles are applied to feeds imported with the API
+		var current atomic.Pointer[storages.BootstrapConfig]
+		current.Store(bootstrap)
+
 		/* Services */
 
 		pcr, err := processer.NewService(
 			st,
 			processer.WithLogger(logger.Named("processer")),
+			processer.WithNotifiers(bootstrap.Notifiers),
 		)
 		if err != nil {
 			logger.Fatalf("Can't create processer service: %v", err.Error())
 		}
 
+		/* Bootstrap config reload */
+
+		reload := func() {
+			logger.Info("Reloading bootstrap config")
+
+			bootstrap, err := storages.GetBootstrapConfig(ctx, cfg.BootstrapFile, logger.Named("storage"))
+			if err != nil {
+				logger.Errorf("Failed to reload bootstrap config, keeping the current one: %s", err.Error())
+				return
+			}
+			if err := pcr.Reload(bootstrap.Notifiers, bootstrap.RssFeeds()); err != nil {
+				logger.Errorf("Invalid bootstrap config, keeping the current one: %s", err.Error())
+				return
+			}
+			diff, err := st.ReloadFromConfig(ctx, bootstrap)
+			if err != nil {
+				logger.Errorf("Failed to apply bootstrap config: %s", err.Error())
+				return
+			}
+			current.Store(bootstrap)
+
+			logger.With(
+				"added", feedsIds(diff.Added),
+				"updated", feedsIds(diff.Updated),
+				"removed", diff.Removed,
+			).Infof("Bootstrap config reloaded: %s", diff)
+		}
+
+		reloadCh := make(chan os.Signal, 1)
+		signal.Notify(reloadCh, syscall.SIGHUP)
+		go func() {
+			var tick <-chan time.Time
+			if cfg.BootstrapReloadInterval > 0 {
+				ticker := time.NewTicker(time.Duration(cfg.BootstrapReloadInterval) * time.Second)
+				defer ticker.Stop()
+				tick = ticker.C
+			}
+			for {
+				select {
+				case <-reloadCh:
+					reload()
+				case <-tick:
+					reload()
+				case <-ctx.Done():
+					signal.Stop(reloadCh)
+					return
+				}
+			}
+		}()
+
 		hkr, err := housekeeper.NewService(
 			st,
 			housekeeper.WithLogger(logger.Named("housekeeper")),
@@ -119,6 +172,25 @@ var serverCmd = &cobra.Command{
 
 		/* Starting service */
 
+		api, err := restapi.New(
+			restapi.WithLogger(logger.Named("restapi")),
+			restapi.WithStorage(st),
+			restapi.WithBootstrapConfig(current.Load),
+			restapi.WithWebSub(pcr),
+		)
+		if err != nil {
+			logger.Fatalf("Can't create restapi: %w", err)
+		}
+
+		// Serving before the first processing, so WebSub hubs can verify subscriptions intents right away
+		if err := api.Listen(); err != nil {
+			logger.Fatal(err)
+		}
+		served := make(chan error, 1)
+		go func() {
+			served <- api.Serve(ctx)
+		}()
+
 		// Processing data for the first time and start regular processing
 		if err := pcr.Process(ctx); err != nil {
 			logger.Error("Failed to process data: ", err.Error())
@@ -146,17 +218,35 @@ var serverCmd = &cobra.Command{
 			}
 		}()
 
-		api, err := restapi.New(
-			restapi.WithLogger(logger.Named("restapi")),
-		)
-		if err != nil {
-			logger.Fatalf("Can't create restapi: %w", err)
-		}
-
-		err = api.Serve(ctx)
+		err = <-served
 		cancel()
 		if err != nil {
 			logger.Fatal(err)
 		}
 	},
 }
+
+// Creates the application logger writing to the output, with env fields for non-local environments.
+func newAppLogger(cfg serverConfig, output string) *zap.SugaredLogger {
+	logger := logging.NewLoggerWithOutput(cfg.LogLevel, cfg.LogFormat, output)
+	logger.WithOptions(zap.AddStacktrace(zap.ErrorLevel))
+
+	if cfg.Env != "local" {
+		logger = logger.With(
+			"app", info.Namespace,
+			"env", cfg.Env,
+			"release", info.Release,
+			"hash", info.CommitHash,
+		)
+	}
+	return logger
+}
+
+// Returns feeds identifiers.
+func feedsIds(feeds []structs.RssFeed) []string {
+	result := make([]string, 0, len(feeds))
+	for _, feed := range feeds {
+		result = append(result, feed.Id)
+	}
+	return result
+}
@@ -0,0 +1,19 @@
+package restapi
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Requires the API token as the bearer token of the request.
+func (s *Service) requireToken(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.APIToken)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse{Error: "Invalid API token"})
+		return
+	}
+	c.Next()
+}
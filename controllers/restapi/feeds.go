@@ -0,0 +1,110 @@
+package restapi
+
+import (
+	"broadcaster/storages"
+	"broadcaster/structs"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+type feedResponse struct {
+	Id            string `json:"id" example:"HelsinginSanomat.City"`
+	Source        string `json:"source" example:"Helsingin Sanomat"`
+	Category      string `json:"category,omitempty" example:"City"`
+	URL           string `json:"url" example:"https://www.hs.fi/rss/kaupunki.xml"`
+	Language      string `json:"language,omitempty" example:"fi"`
+	Status        string `json:"status" example:"active"`
+	StatusMessage string `json:"status_message,omitempty"`
+}
+
+func newFeedResponse(feed structs.RssFeed) feedResponse {
+	return feedResponse{
+		Id:            feed.Id,
+		Source:        feed.Source,
+		Category:      feed.Category,
+		URL:           feed.URL,
+		Language:      feed.Language,
+		Status:        string(feed.Status),
+		StatusMessage: feed.StatusMessage,
+	}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Service) listFeeds(c *gin.Context) {
+	feeds, err := s.storage.Feeds().List(c.Request.Context())
+	if err != nil {
+		s.logger.With("err", err.Error()).Error("Failed to list feeds")
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "Failed to list feeds"})
+		return
+	}
+
+	resp := make([]feedResponse, 0, len(feeds))
+	for _, feed := range feeds {
+		resp = append(resp, newFeedResponse(feed))
+	}
+	sort.Slice(resp, func(i, j int) bool { return resp[i].Id < resp[j].Id })
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Service) getFeed(c *gin.Context) {
+	feed, err := s.storage.Feeds().Find(c.Request.Context(), storages.FeedsStorageFindRequest{Id: c.Param("id")})
+	if err != nil {
+		c.JSON(http.StatusNotFound, errorResponse{Error: "Feed not found"})
+		return
+	}
+	c.JSON(http.StatusOK, newFeedResponse(*feed))
+}
+
+// Pauses or resumes the feed. Resuming erroring feeds retries them on the next run.
+// Disabled feeds are managed with the config only.
+func (s *Service) setFeedStatus(c *gin.Context) {
+	var req struct {
+		Status structs.FeedStatus `json:"status" example:"paused"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "Invalid request body"})
+		return
+	}
+	if req.Status != structs.FeedStatusActive && req.Status != structs.FeedStatusPaused {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "Status should be 'active' or 'paused'"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	feed, err := s.storage.Feeds().Find(ctx, storages.FeedsStorageFindRequest{Id: id})
+	if err != nil {
+		c.JSON(http.StatusNotFound, errorResponse{Error: "Feed not found"})
+		return
+	}
+	if feed.Status == structs.FeedStatusDisabled {
+		c.JSON(http.StatusConflict, errorResponse{Error: "Feed is disabled in the config"})
+		return
+	}
+
+	// Feed can be disabled by the config reload meanwhile
+	updated, err := s.storage.Feeds().Update(ctx, storages.FeedsStorageUpdateRequest{
+		Id:       id,
+		Status:   req.Status,
+		IfStatus: []structs.FeedStatus{structs.FeedStatusActive, structs.FeedStatusPaused, structs.FeedStatusErroring},
+	})
+	if err != nil {
+		s.logger.With("feed_id", id, "err", err.Error()).Error("Failed to update feed status")
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "Failed to update feed status"})
+		return
+	}
+	if updated.Status == structs.FeedStatusDisabled {
+		c.JSON(http.StatusConflict, errorResponse{Error: "Feed is disabled in the config"})
+		return
+	}
+	s.logger.With("feed_id", id).Infof("Feed status is changed to '%s'", req.Status)
+
+	c.JSON(http.StatusOK, newFeedResponse(*updated))
+}
@@ -0,0 +1,108 @@
+package restapi
+
+import (
+	"broadcaster/storages"
+	"broadcaster/storages/memory"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_feedsRoutes(t *testing.T) {
+	st := memory.NewStorage()
+	require.NoError(t, st.BootstrapFromConfig(context.Background(), &storages.BootstrapConfig{
+		Feeds: []storages.FeedConfig{
+			{Source: "One", URL: "https://one.example.com/rss"},
+			{Source: "Two", URL: "https://two.example.com/rss", Disabled: true},
+		},
+	}))
+
+	s := &Service{cfg: &Config{APIToken: "secret"}, logger: zap.NewNop().Sugar(), storage: st}
+	router := s.routes()
+
+	do := func(method, path, body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		var resp map[string]interface{}
+		if strings.HasPrefix(rec.Body.String(), "{") {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		}
+		return rec.Code, resp
+	}
+
+	t.Run("List", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/feeds", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var feeds []feedResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &feeds))
+		require.Len(t, feeds, 2)
+		require.Equal(t, "One", feeds[0].Id)
+		require.Equal(t, "active", feeds[0].Status)
+		require.Equal(t, "disabled", feeds[1].Status)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		code, resp := do(http.MethodGet, "/api/v1/feeds/One", "")
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, "https://one.example.com/rss", resp["url"])
+
+		code, _ = do(http.MethodGet, "/api/v1/feeds/Missing", "")
+		require.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("SetStatus", func(t *testing.T) {
+		code, resp := do(http.MethodPut, "/api/v1/feeds/One/status", `{"status":"paused"}`)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, "paused", resp["status"])
+
+		code, resp = do(http.MethodPut, "/api/v1/feeds/One/status", `{"status":"active"}`)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, "active", resp["status"])
+
+		code, _ = do(http.MethodPut, "/api/v1/feeds/One/status", `{"status":"erroring"}`)
+		require.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = do(http.MethodPut, "/api/v1/feeds/One/status", `not json`)
+		require.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = do(http.MethodPut, "/api/v1/feeds/Two/status", `{"status":"active"}`)
+		require.Equal(t, http.StatusConflict, code)
+
+		code, _ = do(http.MethodPut, "/api/v1/feeds/Missing/status", `{"status":"active"}`)
+		require.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		for _, auth := range []string{"", "Bearer wrong", "secret"} {
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/feeds/One/status", strings.NewReader(`{"status":"paused"}`))
+			req.Header.Set("Authorization", auth)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusUnauthorized, rec.Code, auth)
+		}
+
+		feed, err := st.Feeds().Find(context.Background(), storages.FeedsStorageFindRequest{Id: "One"})
+		require.NoError(t, err)
+		require.Equal(t, "active", string(feed.Status))
+	})
+
+	t.Run("NoToken", func(t *testing.T) {
+		s := &Service{cfg: &Config{}, logger: zap.NewNop().Sugar(), storage: st}
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/feeds/One/status", strings.NewReader(`{"status":"paused"}`))
+		rec := httptest.NewRecorder()
+		s.routes().ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNotFound, rec.Code, "Changing endpoints are disabled without the token")
+	})
+}
@@ -0,0 +1,100 @@
+package restapi
+
+import (
+	"broadcaster/storages"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Maximum size of imported OPML documents.
+const opmlMaxSize = 5 << 20
+
+// Exports feeds as OPML.
+func (s *Service) exportOPML(c *gin.Context) {
+	feeds, err := s.storage.Feeds().List(c.Request.Context())
+	if err != nil {
+		s.logger.With("err", err.Error()).Error("Failed to list feeds")
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "Failed to list feeds"})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := storages.WriteOPML(&buf, "Broadcaster feeds", feeds); err != nil {
+		s.logger.With("err", err.Error()).Error("Failed to write OPML")
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "Failed to export feeds"})
+		return
+	}
+	c.Data(http.StatusOK, "text/x-opml; charset=utf-8", buf.Bytes())
+}
+
+type importResponse struct {
+	Added   []string `json:"added"`
+	Skipped []string `json:"skipped"` // Already existing feeds
+}
+
+// Adds feeds from the OPML request body. Existing feeds are skipped.
+// Feeds get notifications of the 'profile' query parameters profiles or the config defaults,
+// the import is rejected if feeds have no notifications, as such feeds are never processed.
+func (s *Service) importOPML(c *gin.Context) {
+	feeds, err := storages.ParseOPML(http.MaxBytesReader(c.Writer, c.Request.Body, opmlMaxSize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, errorResponse{Error: fmt.Sprintf("OPML exceeds %d bytes", maxErr.Limit)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	config := &storages.BootstrapConfig{}
+	if s.bootstrap != nil {
+		config = s.bootstrap()
+	}
+	feeds, err = config.ExpandImportedFeeds(feeds, c.QueryArray("profile")...)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	for _, fc := range feeds {
+		if len(fc.Notifications) == 0 {
+			c.JSON(http.StatusBadRequest, errorResponse{
+				Error: fmt.Sprintf("Feed '%s' has no notifications, import feeds with a profile or set the config defaults notifications", fc.ToRssFeed().Id),
+			})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	resp := importResponse{Added: []string{}, Skipped: []string{}}
+
+	for _, fc := range feeds {
+		feed := fc.ToRssFeed()
+		_, err := s.storage.Feeds().Create(ctx, storages.FeedsStorageCreateRequest{
+			Id:         feed.Id,
+			Source:     feed.Source,
+			Category:   feed.Category,
+			URL:        feed.URL,
+			Language:   feed.Language,
+			ItemsLimit: feed.ItemsLimit,
+			Notify:     feed.Notifications,
+		})
+		switch {
+		case errors.Is(err, storages.ItemExistsError):
+			resp.Skipped = append(resp.Skipped, feed.Id)
+		case err != nil:
+			s.logger.With("feed_id", feed.Id, "err", err.Error()).Error("Failed to create feed")
+			c.JSON(http.StatusInternalServerError, errorResponse{Error: "Failed to create feed"})
+			return
+		default:
+			resp.Added = append(resp.Added, feed.Id)
+		}
+	}
+	s.logger.Infof("Imported %d feeds from OPML, %d skipped", len(resp.Added), len(resp.Skipped))
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,119 @@
+package restapi
+
+import (
+	"broadcaster/storages"
+	"broadcaster/storages/memory"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_opmlRoutes(t *testing.T) {
+	st := memory.NewStorage()
+	require.NoError(t, st.BootstrapFromConfig(context.Background(), &storages.BootstrapConfig{
+		Feeds: []storages.FeedConfig{
+			{Source: "One", Category: "News", URL: "https://one.example.com/rss"},
+		},
+	}))
+
+	config := &storages.BootstrapConfig{
+		Profiles: map[string]storages.FeedTemplateConfig{
+			"news": {ItemsLimit: 5, Notifications: []storages.FeedNotificationsConfig{{Type: "slack", To: []string{"#news"}}}},
+		},
+	}
+	s := &Service{
+		cfg:       &Config{APIToken: "secret"},
+		logger:    zap.NewNop().Sugar(),
+		storage:   st,
+		bootstrap: func() *storages.BootstrapConfig { return config },
+	}
+	router := s.routes()
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Import", func(t *testing.T) {
+		body := `<opml version="2.0"><body><outline text="News">
+			<outline text="One" xmlUrl="https://one.example.com/rss"/>
+			<outline text="Two" xmlUrl="https://two.example.com/rss" language="fi"/>
+		</outline></body></opml>`
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/feeds.opml", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec = post("/api/v1/feeds.opml", body)
+		require.Equal(t, http.StatusBadRequest, rec.Code, "Feeds without notifications aren't imported")
+		require.Contains(t, rec.Body.String(), "no notifications")
+
+		rec = post("/api/v1/feeds.opml?profile=missing", body)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = post("/api/v1/feeds.opml?profile=news", body)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp importResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, []string{"Two.News"}, resp.Added)
+		require.Equal(t, []string{"One.News"}, resp.Skipped)
+
+		feed, err := st.Feeds().Find(context.Background(), storages.FeedsStorageFindRequest{Id: "Two.News"})
+		require.NoError(t, err)
+		require.Equal(t, "fi", feed.Language)
+		require.Equal(t, 5, feed.ItemsLimit)
+		require.Equal(t, []string{"#news"}, feed.Notifications[0].To)
+		require.Equal(t, "fi", feed.Notifications[0].Translate.From)
+
+		// Default notifications are used without profiles
+		config.Defaults = &storages.FeedTemplateConfig{Notifications: []storages.FeedNotificationsConfig{{Type: "slack", To: []string{"#all"}}}}
+		rec = post("/api/v1/feeds.opml", `<opml version="2.0"><body><outline text="Four" xmlUrl="https://four.example.com/rss"/></body></opml>`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		feed, err = st.Feeds().Find(context.Background(), storages.FeedsStorageFindRequest{Id: "Four"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"#all"}, feed.Notifications[0].To)
+
+		rec = post("/api/v1/feeds.opml", "not opml")
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = post("/api/v1/feeds.opml", "<opml>"+strings.Repeat(" ", opmlMaxSize))
+		require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	t.Run("Reload", func(t *testing.T) {
+		diff, err := st.ReloadFromConfig(context.Background(), &storages.BootstrapConfig{
+			Feeds: []storages.FeedConfig{
+				{Source: "Three", Category: "News", URL: "https://three.example.com/rss"},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"One.News"}, diff.Removed, "Imported feeds aren't removed by config reloads")
+
+		feed, err := st.Feeds().Find(context.Background(), storages.FeedsStorageFindRequest{Id: "Two.News"})
+		require.NoError(t, err)
+		require.True(t, feed.Managed)
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/feeds.opml", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Contains(t, rec.Header().Get("Content-Type"), "text/x-opml")
+
+		feeds, err := storages.ParseOPML(rec.Body)
+		require.NoError(t, err)
+		require.Len(t, feeds, 3)
+	})
+}
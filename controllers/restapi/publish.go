@@ -0,0 +1,463 @@
+package restapi
+
+import (
+	"broadcaster/storages"
+	"broadcaster/structs"
+	"broadcaster/utils/info"
+	"broadcaster/utils/strutil"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// Published feeds formats by file extension.
+const (
+	formatRSS  = "xml"
+	formatAtom = "atom"
+	formatJSON = "json"
+)
+
+// File name of published feeds with untranslated items.
+const originalFeedName = "feed"
+
+// Feed published by the service.
+type publishedFeed struct {
+	Title    string
+	Link     string // Source feed URL
+	SelfURL  string
+	Language string
+	Items    []structs.RssFeedItem
+}
+
+// Returns the newest item publication date or the current time.
+func (f publishedFeed) updated() time.Time {
+	var result time.Time
+	for _, item := range f.Items {
+		if item.PubDate.After(result) {
+			result = item.PubDate
+		}
+	}
+	if result.IsZero() {
+		result = time.Now()
+	}
+	return result.UTC()
+}
+
+// Parses published feed file name '<lang>.<format>'. Language is empty for the original feed.
+func parsePublishedFile(name string) (lang, format string, ok bool) {
+	base, format, found := strings.Cut(name, ".")
+	if !found {
+		return "", "", false
+	}
+	switch format {
+	case formatRSS, formatAtom, formatJSON:
+	default:
+		return "", "", false
+	}
+	if base == originalFeedName {
+		return "", format, true
+	}
+	if _, err := language.Parse(base); err != nil {
+		return "", "", false
+	}
+	return base, format, true
+}
+
+// Publishes the feed items: '/feeds/:id/:file'.
+func (s *Service) publishFeed(c *gin.Context) {
+	lang, format, ok := parsePublishedFile(c.Param("file"))
+	if !ok {
+		c.JSON(http.StatusNotFound, errorResponse{Error: "Unknown feed format or language"})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	feed, err := s.storage.Feeds().Find(ctx, storages.FeedsStorageFindRequest{Id: c.Param("id")})
+	if err != nil {
+		c.JSON(http.StatusNotFound, errorResponse{Error: "Feed not found"})
+		return
+	}
+	if lang != "" && !slices.Contains(feed.GetTranslatonsLang(), lang) {
+		c.JSON(http.StatusNotFound, errorResponse{Error: "Feed isn't translated into the language"})
+		return
+	}
+
+	title := feed.Source
+	if feed.Category != "" {
+		title += " - " + feed.Category
+	}
+	pf := publishedFeed{
+		Title:    title,
+		Link:     feed.URL,
+		Language: strutil.Coalesce(lang, feed.Language),
+	}
+	s.publish(c, pf, []string{feed.Id}, lang, format)
+}
+
+// Publishes items of all feeds of the category: '/categories/:category/:file'.
+func (s *Service) publishCategory(c *gin.Context) {
+	lang, format, ok := parsePublishedFile(c.Param("file"))
+	if !ok {
+		c.JSON(http.StatusNotFound, errorResponse{Error: "Unknown feed format or language"})
+		return
+	}
+
+	category := c.Param("category")
+
+	feeds, err := s.storage.Feeds().List(c.Request.Context())
+	if err != nil {
+		s.logger.With("err", err.Error()).Error("Failed to list feeds")
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "Failed to list feeds"})
+		return
+	}
+	var ids []string
+	translated := lang == ""
+	for _, feed := range feeds {
+		if feed.Category == category {
+			ids = append(ids, feed.Id)
+			translated = translated || slices.Contains(feed.GetTranslatonsLang(), lang)
+		}
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusNotFound, errorResponse{Error: "Category not found"})
+		return
+	}
+	if !translated {
+		c.JSON(http.StatusNotFound, errorResponse{Error: "Category feeds aren't translated into the language"})
+		return
+	}
+
+	pf := publishedFeed{
+		Title:    category,
+		Language: lang,
+	}
+	s.publish(c, pf, ids, lang, format)
+}
+
+// Loads feeds items, replaces them with translations if the language is set and writes the feed in the format.
+func (s *Service) publish(c *gin.Context, pf publishedFeed, feedIds []string, lang, format string) {
+	ctx := c.Request.Context()
+
+	items, err := s.storage.FeedItems().List(ctx, storages.FeedItemsListRequest{
+		FeedIds: feedIds,
+		Limit:   s.cfg.PublishItemsLimit,
+	})
+	if err != nil {
+		s.logger.With("err", err.Error()).Error("Failed to list feed items")
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "Failed to list feed items"})
+		return
+	}
+	if lang != "" {
+		items = translatedItems(items, lang)
+	}
+
+	pf.Items = items
+	pf.SelfURL = s.publicURL(c)
+
+	switch format {
+	case formatRSS:
+		writeXML(c, "application/rss+xml; charset=utf-8", newRssDocument(pf))
+	case formatAtom:
+		writeXML(c, "application/atom+xml; charset=utf-8", newAtomFeed(pf))
+	case formatJSON:
+		data, err := json.MarshalIndent(newJSONFeed(pf), "", "  ")
+		if err != nil {
+			s.logger.With("err", err.Error()).Error("Failed to encode JSON feed")
+			c.JSON(http.StatusInternalServerError, errorResponse{Error: "Failed to encode feed"})
+			return
+		}
+		c.Data(http.StatusOK, "application/feed+json; charset=utf-8", data)
+	}
+}
+
+// Replaces items with their stored translations into the language made by feeds processing.
+// Items aren't translated by requests, the ones without translations are kept as is.
+func translatedItems(items []structs.RssFeedItem, lang string) []structs.RssFeedItem {
+	result := make([]structs.RssFeedItem, 0, len(items))
+	for _, item := range items {
+		translated, _ := item.Translated(lang)
+		result = append(result, translated)
+	}
+	return result
+}
+
+// Returns the request URL with PUBLIC_URL as the base if set.
+// Otherwise the request host is used, with the 'X-Forwarded-Proto' scheme if it's http or https.
+func (s *Service) publicURL(c *gin.Context) string {
+	base := strings.TrimRight(s.cfg.PublicURL, "/")
+	if base == "" {
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		switch proto := strings.ToLower(c.GetHeader("X-Forwarded-Proto")); proto {
+		case "http", "https":
+			scheme = proto
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	return base + c.Request.URL.EscapedPath()
+}
+
+func writeXML(c *gin.Context, contentType string, v interface{}) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "Failed to encode feed"})
+		return
+	}
+	c.Data(http.StatusOK, contentType, append([]byte(xml.Header), data...))
+}
+
+// ------------------------------------------------------------------------------------------------
+// RSS 2.0
+
+type rssDocument struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	ContentNS string     `xml:"xmlns:content,attr"`
+	AtomNS    string     `xml:"xmlns:atom,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string      `xml:"title"`
+	Link          string      `xml:"link"`
+	Description   string      `xml:"description"`
+	Language      string      `xml:"language,omitempty"`
+	LastBuildDate string      `xml:"lastBuildDate"`
+	Generator     string      `xml:"generator"`
+	AtomLink      rssAtomLink `xml:"atom:link"`
+	Items         []rssItem   `xml:"item"`
+}
+
+type rssAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link,omitempty"`
+	Description string        `xml:"description"`
+	Content     string        `xml:"content:encoded,omitempty"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	Categories  []string      `xml:"category"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+func newRssDocument(pf publishedFeed) rssDocument {
+	doc := rssDocument{
+		Version:   "2.0",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         pf.Title,
+			Link:          strutil.Coalesce(pf.Link, pf.SelfURL),
+			Description:   pf.Title,
+			Language:      pf.Language,
+			LastBuildDate: pf.updated().Format(time.RFC1123Z),
+			Generator:     info.AppName,
+			AtomLink:      rssAtomLink{Href: pf.SelfURL, Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+	for _, item := range pf.Items {
+		ri := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+			Content:     item.Content,
+			GUID:        rssGUID{Value: item.Id},
+			Categories:  item.Categories,
+		}
+		if !item.PubDate.IsZero() {
+			ri.PubDate = item.PubDate.UTC().Format(time.RFC1123Z)
+		}
+		if len(item.Enclosures) > 0 {
+			e := item.Enclosures[0]
+			ri.Enclosure = &rssEnclosure{URL: e.URL, Length: e.Length, Type: e.Type}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, ri)
+	}
+	return doc
+}
+
+// ------------------------------------------------------------------------------------------------
+// Atom
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Lang      string      `xml:"xml:lang,attr,omitempty"`
+	Title     string      `xml:"title"`
+	ID        string      `xml:"id"`
+	Updated   string      `xml:"updated"`
+	Generator string      `xml:"generator"`
+	Links     []atomLink  `xml:"link"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Summary    *atomText      `xml:"summary"`
+	Content    *atomText      `xml:"content"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+func newAtomFeed(pf publishedFeed) atomFeed {
+	feed := atomFeed{
+		Lang:      pf.Language,
+		Title:     pf.Title,
+		ID:        pf.SelfURL,
+		Updated:   pf.updated().Format(time.RFC3339),
+		Generator: info.AppName,
+		Links:     []atomLink{{Href: pf.SelfURL, Rel: "self", Type: "application/atom+xml"}},
+	}
+	if pf.Link != "" {
+		feed.Links = append(feed.Links, atomLink{Href: pf.Link, Rel: "via"})
+	}
+
+	for _, item := range pf.Items {
+		updated := item.PubDate
+		if updated.IsZero() {
+			updated = item.Processed
+		}
+		entry := atomEntry{
+			Title:   item.Title,
+			ID:      itemURN(item, pf.Language),
+			Updated: updated.UTC().Format(time.RFC3339),
+		}
+		if !item.PubDate.IsZero() {
+			entry.Published = item.PubDate.UTC().Format(time.RFC3339)
+		}
+		if item.Link != "" {
+			entry.Links = append(entry.Links, atomLink{Href: item.Link, Rel: "alternate"})
+		}
+		for _, e := range item.Enclosures {
+			entry.Links = append(entry.Links, atomLink{Href: e.URL, Rel: "enclosure", Type: e.Type, Length: e.Length})
+		}
+		if item.Description != "" {
+			entry.Summary = &atomText{Type: "html", Value: item.Description}
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Value: item.Content}
+		}
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
+
+// Returns the item URN, translations of the item have own identifiers.
+func itemURN(item structs.RssFeedItem, lang string) string {
+	urn := "urn:" + strings.ToLower(info.AppName) + ":item:" + url.PathEscape(item.Id)
+	if lang != "" {
+		urn += ":" + lang
+	}
+	return urn
+}
+
+// ------------------------------------------------------------------------------------------------
+// JSON Feed 1.1
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url"`
+	Language    string         `json:"language,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url,omitempty"`
+	Title         string               `json:"title"`
+	ContentHTML   string               `json:"content_html"`
+	Summary       string               `json:"summary,omitempty"`
+	Image         string               `json:"image,omitempty"`
+	DatePublished string               `json:"date_published,omitempty"`
+	Tags          []string             `json:"tags,omitempty"`
+	Language      string               `json:"language,omitempty"`
+	Attachments   []jsonFeedAttachment `json:"attachments,omitempty"`
+}
+
+type jsonFeedAttachment struct {
+	URL         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	SizeInBytes int64  `json:"size_in_bytes,omitempty"`
+}
+
+func newJSONFeed(pf publishedFeed) jsonFeed {
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       pf.Title,
+		HomePageURL: pf.Link,
+		FeedURL:     pf.SelfURL,
+		Language:    pf.Language,
+		Items:       []jsonFeedItem{},
+	}
+	for _, item := range pf.Items {
+		fi := jsonFeedItem{
+			ID:          itemURN(item, pf.Language),
+			URL:         item.Link,
+			Title:       item.Title,
+			ContentHTML: strutil.Coalesce(item.Content, item.Description),
+			Image:       item.Image,
+			Tags:        item.Categories,
+			Language:    item.Language,
+		}
+		if item.Content != "" {
+			fi.Summary = item.Description
+		}
+		if !item.PubDate.IsZero() {
+			fi.DatePublished = item.PubDate.UTC().Format(time.RFC3339)
+		}
+		for _, e := range item.Enclosures {
+			fi.Attachments = append(fi.Attachments, jsonFeedAttachment{URL: e.URL, MimeType: e.Type, SizeInBytes: e.Length})
+		}
+		feed.Items = append(feed.Items, fi)
+	}
+	return feed
+}
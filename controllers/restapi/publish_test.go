@@ -0,0 +1,137 @@
+package restapi
+
+import (
+	"broadcaster/storages"
+	"broadcaster/storages/memory"
+	"broadcaster/structs"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_publishRoutes(t *testing.T) {
+	ctx := context.Background()
+
+	translated := []storages.FeedNotificationsConfig{{Type: "slack", To: []string{"#news"}, Translate: storages.FeedTranslationsConfig{To: "en"}}}
+
+	st := memory.NewStorage()
+	require.NoError(t, st.BootstrapFromConfig(ctx, &storages.BootstrapConfig{
+		Feeds: []storages.FeedConfig{
+			{Source: "One", Category: "News", URL: "https://one.example.com/rss", Language: "fi", Notifications: translated},
+			{Source: "Two", Category: "News", URL: "https://two.example.com/rss", Language: "fi"},
+			{Source: "Three", Category: "Sports", URL: "https://three.example.com/rss"},
+		},
+	}))
+
+	pubDate := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	items := []storages.FeedItemsCreateRequest{
+		{Id: "one-old", FeedId: "One.News", Title: "Vanha", Link: "https://one.example.com/1", PubDate: pubDate, Language: "fi",
+			Translations: map[string]structs.RssFeedItemTranslation{"en": {Title: "en: Vanha", Link: "https://one.example.com/1"}}},
+		{Id: "one-new", FeedId: "One.News", Title: "Uusi", Description: "<p>Kuvaus</p>", Link: "https://one.example.com/2", PubDate: pubDate.Add(time.Hour), Language: "fi",
+			Categories: []string{"Helsinki"}, Enclosures: []structs.RssFeedEnclosure{{URL: "https://one.example.com/2.mp3", Type: "audio/mpeg", Length: 100}},
+			Translations: map[string]structs.RssFeedItemTranslation{"en": {Title: "en: Uusi", Description: "<p>Description</p>", Link: "https://one.example.com/2"}}},
+		{Id: "untranslated", FeedId: "Two.News", Title: "Toinen", Link: "https://two.example.com/1", PubDate: pubDate.Add(30 * time.Minute), Language: "fi"},
+		{Id: "three", FeedId: "Three.Sports", Title: "Sport", Link: "https://three.example.com/1", PubDate: pubDate},
+	}
+	for _, item := range items {
+		_, err := st.FeedItems().Create(ctx, item)
+		require.NoError(t, err)
+	}
+
+	s := &Service{
+		cfg:     &Config{PublishItemsLimit: 10, PublicURL: "https://news.example.com/"},
+		logger:  zap.NewNop().Sugar(),
+		storage: st,
+	}
+	router := s.routes()
+
+	get := func(path string) (*httptest.ResponseRecorder, *gofeed.Feed) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			return rec, nil
+		}
+		feed, err := gofeed.NewParser().ParseString(rec.Body.String())
+		require.NoError(t, err, rec.Body.String())
+		return rec, feed
+	}
+
+	titles := func(feed *gofeed.Feed) []string {
+		var result []string
+		for _, item := range feed.Items {
+			result = append(result, item.Title)
+		}
+		return result
+	}
+
+	t.Run("Original", func(t *testing.T) {
+		for _, file := range []string{"feed.xml", "feed.atom", "feed.json"} {
+			rec, feed := get("/feeds/One.News/" + file)
+			require.Equal(t, http.StatusOK, rec.Code, file)
+			require.Equal(t, "One - News", feed.Title, file)
+			require.Equal(t, []string{"Uusi", "Vanha"}, titles(feed), file)
+			require.Equal(t, "https://news.example.com/feeds/One.News/"+file, feed.FeedLink, file)
+
+			item := feed.Items[0]
+			require.Equal(t, "https://one.example.com/2", item.Link, file)
+			require.Equal(t, []string{"Helsinki"}, item.Categories, file)
+			require.Len(t, item.Enclosures, 1, file)
+			require.Equal(t, pubDate.Add(time.Hour), item.PublishedParsed.UTC(), file)
+		}
+
+		rec, _ := get("/feeds/One.News/feed.xml")
+		require.Contains(t, rec.Header().Get("Content-Type"), "application/rss+xml")
+		rec, _ = get("/feeds/One.News/feed.atom")
+		require.Contains(t, rec.Header().Get("Content-Type"), "application/atom+xml")
+		rec, _ = get("/feeds/One.News/feed.json")
+		require.Contains(t, rec.Header().Get("Content-Type"), "application/feed+json")
+	})
+
+	t.Run("Translated", func(t *testing.T) {
+		rec, feed := get("/feeds/One.News/en.xml")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "en", feed.Language)
+		require.Equal(t, []string{"en: Uusi", "en: Vanha"}, titles(feed))
+	})
+
+	t.Run("Category", func(t *testing.T) {
+		_, feed := get("/categories/News/en.json")
+		require.Equal(t, "News", feed.Title)
+		require.Equal(t, []string{"en: Uusi", "Toinen", "en: Vanha"}, titles(feed), "Untranslated items are kept")
+
+		rec, _ := get("/categories/Missing/feed.xml")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		rec, _ = get("/categories/Sports/en.xml")
+		require.Equal(t, http.StatusNotFound, rec.Code, "Category feeds aren't translated")
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		for _, path := range []string{"/feeds/Missing/feed.xml", "/feeds/One.News/feed.html", "/feeds/One.News/english.xml", "/feeds/One.News/feed", "/feeds/One.News/de.xml", "/feeds/Two.News/en.xml"} {
+			rec, _ := get(path)
+			require.Equal(t, http.StatusNotFound, rec.Code, path)
+		}
+	})
+
+	t.Run("SelfURL", func(t *testing.T) {
+		s.cfg.PublicURL = ""
+		defer func() { s.cfg.PublicURL = "https://news.example.com/" }()
+
+		for proto, expected := range map[string]string{"": "http", "https": "https", "javascript": "http"} {
+			req := httptest.NewRequest(http.MethodGet, "http://news.example.com/feeds/One.News/feed.json", nil)
+			req.Header.Set("X-Forwarded-Proto", proto)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			feed, err := gofeed.NewParser().ParseString(rec.Body.String())
+			require.NoError(t, err)
+			require.Equal(t, expected+"://news.example.com/feeds/One.News/feed.json", feed.FeedLink, proto)
+		}
+	})
+}
@@ -1,9 +1,11 @@
 package restapi
 
 import (
+	"broadcaster/storages"
 	"broadcaster/utils/info"
 	"context"
 	"fmt"
+	"net/url"
 
 	"github.com/dlampsi/gsrv"
 	"github.com/kelseyhightower/envconfig"
@@ -12,11 +14,32 @@ import (
 
 type Config struct {
 	Address string `envconfig:"ADDRESS" default:"0.0.0.0:8080"`
+	// Base URL of the service for links in published feeds, the request host is used if empty
+	PublicURL         string `envconfig:"PUBLIC_URL"`
+	PublishItemsLimit int    `envconfig:"PUBLISH_ITEMS_LIMIT" default:"50"`
+	// Bearer token of feeds changing endpoints, the endpoints are disabled if empty
+	APIToken string `envconfig:"API_TOKEN"`
+}
+
+type Storage interface {
+	Feeds() storages.FeedsStorage
+	FeedItems() storages.FeedItemsStorage
+}
+
+// Handles WebSub hubs callbacks.
+type WebSub interface {
+	VerifySubscription(feedId string, query url.Values) (string, error)
+	ReceivePush(ctx context.Context, feedId string, body []byte, signature string) error
 }
 
 type Service struct {
-	cfg    *Config
-	logger *zap.SugaredLogger
+	cfg     *Config
+	logger  *zap.SugaredLogger
+	storage Storage
+	websub  WebSub
+	srv     *gsrv.Server
+	// Returns the current bootstrap config, its defaults and profiles are applied to imported feeds
+	bootstrap func() *storages.BootstrapConfig
 }
 
 type Option func(*Service)
@@ -25,6 +48,21 @@ func WithLogger(l *zap.SugaredLogger) Option {
 	return func(s *Service) { s.logger = l }
 }
 
+// Enables feeds API endpoints.
+func WithStorage(st Storage) Option {
+	return func(s *Service) { s.storage = st }
+}
+
+// Sets the current bootstrap config getter. Imported feeds get notifications of its defaults and profiles.
+func WithBootstrapConfig(get func() *storages.BootstrapConfig) Option {
+	return func(s *Service) { s.bootstrap = get }
+}
+
+// Enables WebSub callbacks endpoints.
+func WithWebSub(ws WebSub) Option {
+	return func(s *Service) { s.websub = ws }
+}
+
 func New(opts ...Option) (*Service, error) {
 	var cfg Config
 	if err := envconfig.Process(info.EnvPrefix, &cfg); err != nil {
@@ -42,10 +80,22 @@ func New(opts ...Option) (*Service, error) {
 	return s, nil
 }
 
-func (s *Service) Serve(ctx context.Context) error {
+// Creates the server listening on the address. Connections are queued until Serve is called.
+func (s *Service) Listen() error {
+	if s.srv != nil {
+		return nil
+	}
 	srv, err := gsrv.New(s.cfg.Address, gsrv.WithLogger(s.logger))
 	if err != nil {
 		return fmt.Errorf("Can't init new server: %w", err)
 	}
-	return srv.ServeHTTP(ctx, s.routes())
+	s.srv = srv
+	return nil
+}
+
+func (s *Service) Serve(ctx context.Context) error {
+	if err := s.Listen(); err != nil {
+		return err
+	}
+	return s.srv.ServeHTTP(ctx, s.routes())
 }
@@ -2,6 +2,7 @@ package restapi
 
 import (
 	"broadcaster/utils/info"
+	"expvar"
 	"net/http"
 
 	"github.com/gin-contrib/pprof"
@@ -16,6 +17,7 @@ func (s *Service) routes() *gin.Engine {
 	r.Use(ginzap.RecoveryWithZap(s.logger.Desugar(), true))
 
 	pprof.Register(r, "debug/pprof")
+	r.GET("/debug/vars", gin.WrapH(expvar.Handler()))
 
 	r.GET("/health", func(c *gin.Context) {
 		var resp = struct {
@@ -43,5 +45,27 @@ func (s *Service) routes() *gin.Engine {
 		c.JSON(http.StatusOK, reps)
 	})
 
+	if s.storage != nil {
+		v1 := r.Group("/api/v1")
+		v1.GET("/feeds", s.listFeeds)
+		v1.GET("/feeds.opml", s.exportOPML)
+		v1.GET("/feeds/:id", s.getFeed)
+
+		if s.cfg.APIToken != "" {
+			authorized := v1.Group("", s.requireToken)
+			authorized.POST("/feeds.opml", s.importOPML)
+			authorized.PUT("/feeds/:id/status", s.setFeedStatus)
+		}
+
+		// Published feeds
+		r.GET("/feeds/:id/:file", s.publishFeed)
+		r.GET("/categories/:category/:file", s.publishCategory)
+	}
+
+	if s.websub != nil {
+		r.GET("/websub/:id", s.verifyWebSub)
+		r.POST("/websub/:id", s.receiveWebSub)
+	}
+
 	return r
 }
@@ -0,0 +1,53 @@
+package restapi
+
+import (
+	"broadcaster/services/processer/websub"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Maximum size of the content pushed by WebSub hubs.
+const maxPushSize = 10 << 20
+
+// Responds to the WebSub hub intent verification with the challenge: 'GET /websub/:id'.
+func (s *Service) verifyWebSub(c *gin.Context) {
+	challenge, err := s.websub.VerifySubscription(c.Param("id"), c.Request.URL.Query())
+	if err != nil {
+		s.logger.With("feed_id", c.Param("id"), "mode", c.Query("hub.mode"), "err", err.Error()).
+			Warn("WebSub intent isn't confirmed")
+		c.String(http.StatusNotFound, "Not found")
+		return
+	}
+	c.String(http.StatusOK, challenge)
+}
+
+// Receives the feed content pushed by the WebSub hub: 'POST /websub/:id'.
+func (s *Service) receiveWebSub(c *gin.Context) {
+	feedId := c.Param("id")
+	logger := s.logger.With("feed_id", feedId)
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPushSize))
+	if err != nil {
+		c.String(http.StatusRequestEntityTooLarge, "Content is too large")
+		return
+	}
+
+	err = s.websub.ReceivePush(c.Request.Context(), feedId, body, c.GetHeader("X-Hub-Signature"))
+	switch {
+	case errors.Is(err, websub.UnknownSubscriptionError):
+		c.String(http.StatusNotFound, "Not found")
+	case errors.Is(err, websub.InvalidSignatureError):
+		// Hubs shouldn't retry such requests, content is ignored
+		logger.Warn("Ignoring WebSub content with invalid signature")
+		c.Status(http.StatusAccepted)
+	case err != nil:
+		logger.With("err", err.Error()).Warn("Failed to receive WebSub content")
+		c.String(http.StatusBadRequest, err.Error())
+	default:
+		logger.Debug("Received WebSub content")
+		c.Status(http.StatusAccepted)
+	}
+}
@@ -0,0 +1,82 @@
+package restapi
+
+import (
+	"broadcaster/services/processer/websub"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// WebSub callbacks handler accepting the 'valid' signature of the 'known' feed.
+type fakeWebSub struct {
+	pushed []string
+}
+
+func (w *fakeWebSub) VerifySubscription(feedId string, query url.Values) (string, error) {
+	if feedId != "known" {
+		return "", websub.UnknownSubscriptionError
+	}
+	return query.Get("hub.challenge"), nil
+}
+
+func (w *fakeWebSub) ReceivePush(ctx context.Context, feedId string, body []byte, signature string) error {
+	if feedId != "known" {
+		return websub.UnknownSubscriptionError
+	}
+	if signature != "valid" {
+		return websub.InvalidSignatureError
+	}
+	w.pushed = append(w.pushed, string(body))
+	return nil
+}
+
+func Test_websubRoutes(t *testing.T) {
+	ws := &fakeWebSub{}
+	s := &Service{logger: zap.NewNop().Sugar(), websub: ws}
+	router := s.routes()
+
+	do := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Verify", func(t *testing.T) {
+		rec := do(httptest.NewRequest(http.MethodGet, "/websub/known?hub.mode=subscribe&hub.challenge=abc", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "abc", rec.Body.String())
+
+		rec = do(httptest.NewRequest(http.MethodGet, "/websub/unknown?hub.mode=subscribe&hub.challenge=abc", nil))
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		require.NotContains(t, rec.Body.String(), "abc")
+	})
+
+	t.Run("Push", func(t *testing.T) {
+		push := func(feedId, signature string) int {
+			req := httptest.NewRequest(http.MethodPost, "/websub/"+feedId, strings.NewReader("<feed/>"))
+			req.Header.Set("X-Hub-Signature", signature)
+			return do(req).Code
+		}
+
+		require.Equal(t, http.StatusAccepted, push("known", "valid"))
+		require.Equal(t, []string{"<feed/>"}, ws.pushed)
+
+		require.Equal(t, http.StatusAccepted, push("known", "invalid"), "Invalid content is acknowledged")
+		require.Len(t, ws.pushed, 1)
+
+		require.Equal(t, http.StatusNotFound, push("unknown", "valid"))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		s := &Service{logger: zap.NewNop().Sugar()}
+		rec := httptest.NewRecorder()
+		s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/websub/known?hub.challenge=abc", nil))
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
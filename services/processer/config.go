@@ -1,6 +1,8 @@
 package processer
 
-import "errors"
+import (
+	"errors"
+)
 
 type TranslationType string
 
@@ -13,11 +15,24 @@ const (
 type Config struct {
 	TranslatorType       TranslationType `envconfig:"TRANSLATOR_TYPE" default:"google_api"`
 	GoogleCloudProjectId string          `envconfig:"GOOGLE_CLOUD_PROJECT_ID"`
-	TelegramBotToken     string          `envconfig:"TELEGRAM_BOT_TOKEN"`
-	SlackApiToken        string          `envconfig:"SLACK_API_TOKEN"`
 	BackfillHours        int             `envconfig:"BACKFILL_HOURS"`
 	MuteNotifications    bool            `envconfig:"MUTE_NOTIFICATIONS"`
 	GoogleCloudCreds     string          `envconfig:"GOOGLE_CLOUD_CREDS"`
+	// Full articles content fetching
+	ContentTimeout int   `envconfig:"CONTENT_TIMEOUT" default:"15"`
+	ContentMaxSize int64 `envconfig:"CONTENT_MAX_SIZE" default:"2097152"`
+	// Cross-feed deduplication of stories sent to the same destination
+	Dedup              bool `envconfig:"DEDUP"`
+	DedupWindowHours   int  `envconfig:"DEDUP_WINDOW_HOURS" default:"24"`
+	DedupTitleDistance int  `envconfig:"DEDUP_TITLE_DISTANCE" default:"10"`
+	// Consecutive fetch failures after which the feed is erroring and retried with backoff
+	FeedMaxFailures int `envconfig:"FEED_MAX_FAILURES" default:"5"`
+	FeedBackoff     int `envconfig:"FEED_BACKOFF" default:"600"`       // Initial backoff in seconds
+	FeedMaxBackoff  int `envconfig:"FEED_MAX_BACKOFF" default:"21600"` // Maximum backoff in seconds
+	// WebSub subscriptions to hubs discovered in feeds, callbacks are served at PUBLIC_URL
+	WebSub      bool   `envconfig:"WEBSUB"`
+	WebSubLease int    `envconfig:"WEBSUB_LEASE" default:"86400"` // Requested lease in seconds
+	PublicURL   string `envconfig:"PUBLIC_URL"`
 }
 
 func (c *Config) Validate() error {
@@ -27,8 +42,17 @@ func (c *Config) Validate() error {
 	if c.TranslatorType == TranslationTypeGC && c.GoogleCloudProjectId == "" {
 		return errors.New("Google Cloud Project ID is required")
 	}
-	if !c.MuteNotifications && c.TelegramBotToken == "" {
-		return errors.New("Telegram Bot Token is required")
+	if c.Dedup && c.DedupWindowHours <= 0 {
+		return errors.New("Deduplication window should be positive")
+	}
+	if c.FeedMaxFailures <= 0 {
+		return errors.New("Feed max failures should be positive")
+	}
+	if c.FeedBackoff <= 0 || c.FeedMaxBackoff < c.FeedBackoff {
+		return errors.New("Feed backoff should be positive and not exceed the max backoff")
+	}
+	if c.WebSub && c.PublicURL == "" {
+		return errors.New("Public URL is required for WebSub callbacks")
 	}
 	return nil
 }
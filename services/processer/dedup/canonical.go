@@ -0,0 +1,126 @@
+package dedup
+
+import (
+	"hash/fnv"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// Query parameters that are used for tracking only and don't change the linked content.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"yclid":   true,
+	"msclkid": true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"igshid":  true,
+	"ocid":    true,
+	"cmpid":   true,
+	"ref":     true,
+	"ref_src": true,
+}
+
+// Tracking parameters prefixes (eg utm_source, utm_medium).
+var trackingPrefixes = []string{"utm_", "at_", "pk_", "mtm_"}
+
+// Returns canonical form of the link: lowercased host without "www." prefix and path
+// without fragment, tracking query params and trailing slash. Scheme is dropped, so
+// http and https links are the same.
+// Returns an empty string for invalid or empty links.
+func CanonicalLink(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	query := u.Query()
+	for key := range query {
+		if isTrackingParam(key) {
+			query.Del(key)
+		}
+	}
+
+	result := host + strings.TrimRight(u.EscapedPath(), "/")
+	if len(query) > 0 {
+		result += "?" + query.Encode() // Encode sorts keys
+	}
+
+	return result
+}
+
+func isTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+	if trackingParams[key] {
+		return true
+	}
+	for _, p := range trackingPrefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Size of characters shingles used for titles simhash.
+const shingleSize = 3
+
+// Calculates simhash of the normalized text based on characters shingles.
+// Returns the hash and the number of words in the text.
+func Simhash(text string) (uint64, int) {
+	terms := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(terms) == 0 {
+		return 0, 0
+	}
+
+	normalized := []rune(strings.Join(terms, " "))
+	var features []string
+	for i := 0; i+shingleSize <= len(normalized); i++ {
+		features = append(features, string(normalized[i:i+shingleSize]))
+	}
+	if len(features) == 0 {
+		features = append(features, string(normalized))
+	}
+
+	var weights [64]int
+	for _, f := range features {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(f))
+		sum := mix(h.Sum64())
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+
+	var result uint64
+	for i := 0; i < 64; i++ {
+		if weights[i] > 0 {
+			result |= 1 << uint(i)
+		}
+	}
+
+	return result, len(terms)
+}
+
+// Finalizes the hash to spread short strings FNV hashes over all bits (splitmix64 finalizer).
+func mix(h uint64) uint64 {
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
@@ -0,0 +1,114 @@
+package dedup
+
+import (
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// Deduplicator remembers stories delivered to destinations and detects the same story
+// coming from different feeds (or with different GUIDs) within a time window.
+type Deduplicator struct {
+	window   time.Duration
+	distance int
+	mu       *sync.Mutex
+	// destination -> stories delivered to it
+	seen map[string][]story
+}
+
+type story struct {
+	link  string
+	title uint64
+	terms int
+	at    time.Time
+}
+
+// Minimal number of title terms required to consider near-duplicate titles.
+// Short titles (eg "Weather") produce too many false positives.
+const minTitleTerms = 4
+
+// Creates new deduplicator.
+// Window defines how long stories are remembered, distance is a maximum Hamming distance
+// between titles simhashes to consider titles near-duplicates. Negative distance disables titles comparison.
+func New(window time.Duration, distance int) *Deduplicator {
+	return &Deduplicator{
+		window:   window,
+		distance: distance,
+		mu:       &sync.Mutex{},
+		seen:     make(map[string][]story),
+	}
+}
+
+// Checks whether the story (identified by link and title) was already delivered to the destination
+// within the window. If not, remembers it and returns true.
+func (d *Deduplicator) Claim(destination, link, title string, now time.Time) bool {
+	s := story{
+		link: CanonicalLink(link),
+		at:   now,
+	}
+	s.title, s.terms = Simhash(title)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var actual []story
+	for _, prev := range d.seen[destination] {
+		if now.Sub(prev.at) > d.window {
+			continue
+		}
+		actual = append(actual, prev)
+	}
+	d.seen[destination] = actual
+
+	for _, prev := range actual {
+		if s.link != "" && s.link == prev.link {
+			return false
+		}
+		if d.distance >= 0 && s.terms >= minTitleTerms && prev.terms >= minTitleTerms &&
+			bits.OnesCount64(s.title^prev.title) <= d.distance {
+			return false
+		}
+	}
+
+	d.seen[destination] = append(d.seen[destination], s)
+
+	return true
+}
+
+// Forgets the story claimed for the destination, eg when its delivery failed,
+// so the story can be delivered to the destination again.
+func (d *Deduplicator) Release(destination, link, title string) {
+	link = CanonicalLink(link)
+	hash, _ := Simhash(title)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stories := d.seen[destination]
+	for i := len(stories) - 1; i >= 0; i-- {
+		if stories[i].link == link && stories[i].title == hash {
+			d.seen[destination] = append(stories[:i:i], stories[i+1:]...)
+			return
+		}
+	}
+}
+
+// Removes all stories older than the window.
+func (d *Deduplicator) Cleanup(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for dest, stories := range d.seen {
+		var actual []story
+		for _, s := range stories {
+			if now.Sub(s.at) <= d.window {
+				actual = append(actual, s)
+			}
+		}
+		if len(actual) == 0 {
+			delete(d.seen, dest)
+			continue
+		}
+		d.seen[dest] = actual
+	}
+}
@@ -0,0 +1,79 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CanonicalLink(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"empty", "", ""},
+		{"invalid", "not a link", ""},
+		{"plain", "https://example.com/news/1", "example.com/news/1"},
+		{"schemeAndWww", "http://WWW.Example.com/news/1/", "example.com/news/1"},
+		{"fragment", "https://example.com/news/1#comments", "example.com/news/1"},
+		{"tracking", "https://example.com/news/1?utm_source=rss&utm_medium=feed&fbclid=abc", "example.com/news/1"},
+		{"keepsParams", "https://example.com/article?id=2&utm_campaign=x&a=1", "example.com/article?a=1&id=2"},
+		{"defaultPort", "https://example.com:443/news", "example.com/news"},
+		{"customPort", "https://example.com:8443/news", "example.com:8443/news"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, CanonicalLink(tt.in))
+		})
+	}
+}
+
+func Test_Deduplicator_Claim(t *testing.T) {
+	now := time.Now().UTC()
+
+	t.Run("sameLink", func(t *testing.T) {
+		d := New(time.Hour, 10)
+		require.True(t, d.Claim("slack:#news", "https://example.com/a?utm_source=feed1", "First title", now))
+		require.False(t, d.Claim("slack:#news", "http://www.example.com/a", "Another title", now))
+		require.True(t, d.Claim("slack:#other", "https://example.com/a", "First title", now), "Other destinations are independent")
+	})
+	t.Run("nearDuplicateTitle", func(t *testing.T) {
+		d := New(time.Hour, 10)
+		require.True(t, d.Claim("dest", "https://a.com/1", "Finland joins NATO as 31st member of the alliance", now))
+		require.False(t, d.Claim("dest", "https://b.com/2", "Finland officially joins NATO as 31st member of the alliance", now))
+		require.True(t, d.Claim("dest", "https://c.com/3", "Stock markets fall sharply after central bank decision", now))
+	})
+	t.Run("shortTitles", func(t *testing.T) {
+		d := New(time.Hour, 64)
+		require.True(t, d.Claim("dest", "https://a.com/1", "Weather", now))
+		require.True(t, d.Claim("dest", "https://b.com/2", "Weather", now), "Short titles should not be compared")
+	})
+	t.Run("titlesDisabled", func(t *testing.T) {
+		d := New(time.Hour, -1)
+		title := "Finland joins NATO as 31st member of the alliance"
+		require.True(t, d.Claim("dest", "https://a.com/1", title, now))
+		require.True(t, d.Claim("dest", "https://b.com/2", title, now))
+	})
+	t.Run("window", func(t *testing.T) {
+		d := New(time.Hour, 10)
+		require.True(t, d.Claim("dest", "https://a.com/1", "", now.Add(-2*time.Hour)))
+		require.True(t, d.Claim("dest", "https://a.com/1", "", now), "Stories outside of the window should be forgotten")
+
+		d.Cleanup(now.Add(2 * time.Hour))
+		require.Empty(t, d.seen)
+	})
+	t.Run("release", func(t *testing.T) {
+		d := New(time.Hour, 10)
+		title := "Finland joins NATO as 31st member of the alliance"
+		require.True(t, d.Claim("dest", "https://a.com/1", title, now))
+		require.True(t, d.Claim("dest", "https://c.com/3", "Stock markets fall sharply after central bank decision", now))
+
+		d.Release("dest", "https://a.com/1?utm_source=feed", title)
+		require.True(t, d.Claim("dest", "https://b.com/2", title, now), "Released stories can be delivered again")
+		require.False(t, d.Claim("dest", "https://c.com/3", "", now), "Other stories are kept")
+
+		d.Release("other", "https://a.com/1", title)
+	})
+}
@@ -0,0 +1,49 @@
+package processer
+
+import (
+	"broadcaster/services/processer/notifier"
+	"broadcaster/structs"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Prints notifications rendered by notifiers instead of sending them.
+func WithDryRun(w io.Writer) Option {
+	return func(s *Service) {
+		s.dryRun = &dryRunPrinter{w: w}
+	}
+}
+
+// Writes rendered notifications, one per destination. Notifications of concurrently processed feeds aren't interleaved.
+type dryRunPrinter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (p *dryRunPrinter) print(typ string, nfn structs.RssFeedNotification, item structs.RssFeedItem, req notifier.NotificationRequest) {
+	to := req.To
+	if len(to) == 0 {
+		to = []string{"(default destination)"}
+	}
+
+	name := notifierName(nfn)
+	if name != typ {
+		name += " (" + typ + ")"
+	}
+
+	var b strings.Builder
+	for _, dest := range to {
+		fmt.Fprintf(&b, "=== %s -> %s\n", name, dest)
+		fmt.Fprintf(&b, "Feed: %s\nItem: %s\n", item.FeedId, item.Id)
+		if req.Image != "" {
+			fmt.Fprintf(&b, "Image: %s\n", req.Image)
+		}
+		fmt.Fprintf(&b, "\n%s\n\n", strings.TrimSpace(req.Message))
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, _ = io.WriteString(p.w, b.String())
+}
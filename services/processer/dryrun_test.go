@@ -0,0 +1,98 @@
+package processer
+
+import (
+	"broadcaster/services/processer/notifier"
+	"broadcaster/storages"
+	"broadcaster/storages/memory"
+	"broadcaster/structs"
+	"bytes"
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/require"
+)
+
+// Renders items titles and links, counts sent notifications.
+type messageNotifier struct {
+	sent atomic.Int32
+}
+
+func (n *messageNotifier) Notify(ctx context.Context, r notifier.NotificationRequest) error {
+	n.sent.Add(1)
+	return nil
+}
+
+func (n *messageNotifier) NewRequest(fn structs.RssFeedNotification, item *structs.RssFeedItem) notifier.NotificationRequest {
+	return notifier.NotificationRequest{
+		To:      fn.To,
+		Message: item.Title + "\n" + item.Link,
+		Image:   item.Image,
+	}
+}
+
+func Test_Service_ProcessFeeds_dryRun(t *testing.T) {
+	ctx := context.Background()
+
+	st := memory.NewStorage()
+	for _, id := range []string{"news", "blog"} {
+		_, err := st.Feeds().Create(ctx, storages.FeedsStorageCreateRequest{Id: id, Source: id, URL: "https://example.com/" + id})
+		require.NoError(t, err)
+		_, err = st.Feeds().Update(ctx, storages.FeedsStorageUpdateRequest{
+			Id:     id,
+			Notify: []structs.RssFeedNotification{{Notifier: "team", To: []string{"#general", "#" + id}}},
+		})
+		require.NoError(t, err)
+	}
+
+	src := FeedSourceFunc(func(ctx context.Context, feed structs.RssFeed) (*gofeed.Feed, error) {
+		published := time.Now().Add(-10 * time.Minute)
+		return &gofeed.Feed{Items: []*gofeed.Item{{
+			GUID:            "1",
+			Title:           "Hello from " + feed.Id,
+			Link:            "https://example.com/" + feed.Id + "/1",
+			PublishedParsed: &published,
+		}}}, nil
+	})
+
+	var out bytes.Buffer
+	nfr := &messageNotifier{}
+	s := &Service{
+		cfg:          &Config{BackfillHours: 1, FeedMaxFailures: 5},
+		logger:       tservice.logger,
+		storage:      st,
+		translator:   tservice.translator,
+		notifiers:    map[string]notifierInstance{"team": {Type: "slack", Notifier: nfr}},
+		mu:           &sync.RWMutex{},
+		translations: make(map[string]map[string]structs.RssFeedItem),
+		failures:     make(map[string]*feedFailures),
+	}
+	WithFeedSource(structs.FeedTypeRSS, src)(s)
+	WithDryRun(&out)(s)
+
+	require.NoError(t, s.ProcessFeeds(ctx, "news"))
+	require.Equal(t, `=== team (slack) -> #general
+Feed: news
+Item: news/1
+
+Hello from news
+https://example.com/news/1
+
+=== team (slack) -> #news
+Feed: news
+Item: news/1
+
+Hello from news
+https://example.com/news/1
+
+`, out.String())
+	require.Zero(t, nfr.sent.Load(), "Notifications aren't sent")
+
+	_, err := st.FeedItems().Find(ctx, storages.FeedItemsStorageFindRequest{Id: "news/1"})
+	require.ErrorIs(t, err, storages.ItemNotFoundError, "Items aren't stored")
+
+	require.ErrorContains(t, s.ProcessFeeds(ctx, "news", "missing", "other"), "Unknown feeds: missing, other")
+}
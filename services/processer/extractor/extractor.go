@@ -0,0 +1,274 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+var (
+	DisallowedByRobotsError = errors.New("Disallowed by robots.txt")
+	TooLargeError           = errors.New("Page size exceeds the limit")
+	NotHtmlError            = errors.New("Page is not an HTML document")
+)
+
+type Config struct {
+	Timeout   time.Duration // Timeout of the page download
+	MaxSize   int64         // Maximum page size in bytes
+	UserAgent string        // User agent used for requests and robots.txt rules
+}
+
+// Downloads articles pages and extracts the main text and the lead image.
+type Extractor struct {
+	cfg    *Config
+	httpCl *http.Client
+	mu     *sync.Mutex
+	// robots.txt rules cache. scheme://host -> rules
+	robots map[string]*robotsRules
+}
+
+// Extracted article.
+type Article struct {
+	Content string // Main article text, paragraphs are separated by empty lines
+	Image   string // Lead image URL
+}
+
+func New(cfg *Config) *Extractor {
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "broadcaster"
+	}
+	httpCl := &http.Client{
+		Transport: &http.Transport{
+			Dial: (&net.Dialer{
+				Timeout: 10 * time.Second,
+			}).Dial,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		Timeout: cfg.Timeout,
+	}
+	return &Extractor{
+		cfg:    cfg,
+		httpCl: httpCl,
+		mu:     &sync.Mutex{},
+		robots: make(map[string]*robotsRules),
+	}
+}
+
+// Downloads the page and extracts the article from it.
+func (e *Extractor) Extract(ctx context.Context, link string) (*Article, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse link: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("Unsupported link scheme '%s'", u.Scheme)
+	}
+
+	allowed, err := e.allowed(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to check robots.txt: %w", err)
+	}
+	if !allowed {
+		return nil, DisallowedByRobotsError
+	}
+
+	body, err := e.get(ctx, u.String(), e.cfg.MaxSize, true)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse page: %w", err)
+	}
+
+	return extract(doc, u), nil
+}
+
+// Downloads the document respecting the size limit. Zero max size means no limit.
+func (e *Extractor) get(ctx context.Context, uri string, maxSize int64, htmlOnly bool) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new request: %w", err)
+	}
+	req.Header.Set("User-Agent", e.cfg.UserAgent)
+	if htmlOnly {
+		req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	}
+
+	resp, err := e.httpCl.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{Code: resp.StatusCode}
+	}
+
+	if htmlOnly {
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+			return nil, NotHtmlError
+		}
+	}
+
+	if maxSize > 0 && resp.ContentLength > maxSize {
+		return nil, TooLargeError
+	}
+
+	reader := io.Reader(resp.Body)
+	if maxSize > 0 {
+		reader = io.LimitReader(resp.Body, maxSize+1)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read response: %w", err)
+	}
+	if maxSize > 0 && int64(len(body)) > maxSize {
+		return nil, TooLargeError
+	}
+
+	return body, nil
+}
+
+type statusError struct {
+	Code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("Bad response code (%d)", e.Code)
+}
+
+// Elements that never contain the article text.
+const noiseSelector = "script, style, noscript, template, nav, header, footer, aside, form, iframe, svg, button"
+
+var (
+	positiveRe = regexp.MustCompile(`(?i)article|content|body|main|post|entry|story|text`)
+	negativeRe = regexp.MustCompile(`(?i)comment|sidebar|footer|related|share|social|promo|advert|banner|menu|subscribe`)
+)
+
+// Minimal paragraph length to be considered as an article text.
+const minParagraphLen = 25
+
+// Extracts the article from the page document.
+// Picks the element with the most paragraphs text, similar to readability algorithms.
+func extract(doc *goquery.Document, base *url.URL) *Article {
+	result := &Article{
+		Image: leadImage(doc, base),
+	}
+
+	doc.Find(noiseSelector).Remove()
+
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+
+	doc.Find("p").Each(func(_ int, p *goquery.Selection) {
+		text := strings.TrimSpace(p.Text())
+		if len(text) < minParagraphLen {
+			return
+		}
+		parent := p.Parent()
+		if parent.Length() == 0 {
+			return
+		}
+		node := parent.Get(0)
+		if _, exists := scores[node]; !exists {
+			candidates = append(candidates, node)
+			scores[node] = classWeight(parent)
+		}
+		scores[node] += 1 + float64(len(text))/100
+	})
+
+	var best *goquery.Selection
+	var bestScore float64
+	for _, node := range candidates {
+		if best == nil || scores[node] > bestScore {
+			best = doc.FindNodes(node)
+			bestScore = scores[node]
+		}
+	}
+
+	if best == nil {
+		return result
+	}
+
+	var paragraphs []string
+	best.Find("p, h2, h3, li, blockquote").Each(func(_ int, s *goquery.Selection) {
+		// Nested elements are covered by their parents
+		if s.ParentsFiltered("p, li, blockquote").Length() > 0 {
+			return
+		}
+		text := strings.Join(strings.Fields(s.Text()), " ")
+		if text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+	})
+	result.Content = strings.Join(paragraphs, "\n\n")
+
+	if result.Image == "" {
+		if src, ok := best.Find("img[src]").First().Attr("src"); ok {
+			result.Image = resolve(base, src)
+		}
+	}
+
+	return result
+}
+
+// Scores the element by its class and id attributes.
+func classWeight(s *goquery.Selection) float64 {
+	var weight float64
+	for _, attr := range []string{"class", "id"} {
+		value, ok := s.Attr(attr)
+		if !ok || value == "" {
+			continue
+		}
+		if negativeRe.MatchString(value) {
+			weight -= 25
+		}
+		if positiveRe.MatchString(value) {
+			weight += 25
+		}
+	}
+	if goquery.NodeName(s) == "article" {
+		weight += 25
+	}
+	return weight
+}
+
+// Returns the page lead image from the page metadata.
+func leadImage(doc *goquery.Document, base *url.URL) string {
+	selectors := []string{
+		`meta[property="og:image"]`,
+		`meta[property="og:image:url"]`,
+		`meta[name="twitter:image"]`,
+	}
+	for _, sel := range selectors {
+		if content, ok := doc.Find(sel).First().Attr("content"); ok && strings.TrimSpace(content) != "" {
+			return resolve(base, strings.TrimSpace(content))
+		}
+	}
+	return ""
+}
+
+// Resolves the reference relative to the base URL.
+func resolve(base *url.URL, ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(u).String()
+}
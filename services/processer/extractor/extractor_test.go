@@ -0,0 +1,183 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testArticle = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Page title</title>
+  <meta property="og:image" content="/images/lead.jpg">
+  <script>var tracking = "This script text should never be extracted as article";</script>
+</head>
+<body>
+  <nav><p>Navigation paragraph with enough text to be a candidate</p></nav>
+  <div class="sidebar">
+    <p>Sidebar promo text which is long enough to be scored as a paragraph.</p>
+  </div>
+  <article class="article-body">
+    <h2>Subtitle</h2>
+    <p>First paragraph of the article with the <b>main</b> story text.</p>
+    <p>Second paragraph continues the story and adds more details.</p>
+    <p>Short</p>
+  </article>
+  <div class="comments">
+    <p>Comment from a reader that is long enough to be a paragraph.</p>
+  </div>
+</body>
+</html>`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private/\n\nUser-agent: otherbot\nDisallow: /\n")
+	})
+	mux.HandleFunc("/news/article", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, testArticle)
+	})
+	mux.HandleFunc("/private/article", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, testArticle)
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, strings.Repeat("<p>large</p>", 1000))
+	})
+	mux.HandleFunc("/file.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		fmt.Fprint(w, "%PDF")
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func Test_Extractor_Extract(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+
+	ex := New(&Config{Timeout: 5 * time.Second, MaxSize: 4096})
+
+	t.Run("article", func(t *testing.T) {
+		article, err := ex.Extract(ctx, srv.URL+"/news/article")
+		require.NoError(t, err)
+		require.Equal(t, srv.URL+"/images/lead.jpg", article.Image)
+		require.Equal(
+			t,
+			"Subtitle\n\nFirst paragraph of the article with the main story text.\n\n"+
+				"Second paragraph continues the story and adds more details.\n\nShort",
+			article.Content,
+		)
+	})
+	t.Run("disallowedByRobots", func(t *testing.T) {
+		_, err := ex.Extract(ctx, srv.URL+"/private/article")
+		require.ErrorIs(t, err, DisallowedByRobotsError)
+	})
+	t.Run("tooLarge", func(t *testing.T) {
+		_, err := ex.Extract(ctx, srv.URL+"/large")
+		require.ErrorIs(t, err, TooLargeError)
+	})
+	t.Run("notHtml", func(t *testing.T) {
+		_, err := ex.Extract(ctx, srv.URL+"/file.pdf")
+		require.ErrorIs(t, err, NotHtmlError)
+	})
+	t.Run("notFound", func(t *testing.T) {
+		_, err := ex.Extract(ctx, srv.URL+"/missing")
+		require.Error(t, err)
+	})
+	t.Run("badScheme", func(t *testing.T) {
+		_, err := ex.Extract(ctx, "ftp://example.com/file")
+		require.Error(t, err)
+	})
+	t.Run("specificAgent", func(t *testing.T) {
+		other := New(&Config{Timeout: 5 * time.Second, UserAgent: "OtherBot/1.0"})
+		_, err := other.Extract(ctx, srv.URL+"/news/article")
+		require.ErrorIs(t, err, DisallowedByRobotsError)
+	})
+}
+
+func Test_robotsRules_allowed(t *testing.T) {
+	data := []byte(`# Comment
+User-agent: *
+Disallow: /search
+Allow: /search/about
+Disallow: /*.pdf$
+Disallow: /tmp*/cache
+
+User-agent: broadcaster
+User-agent: otherbot
+Disallow: /private
+`)
+
+	common := parseRobots(data, "somebot")
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/news/1", true},
+		{"/search", false},
+		{"/search?q=1", false},
+		{"/search/about", true},
+		{"/files/doc.pdf", false},
+		{"/files/doc.pdf.html", true},
+		{"/tmp1/cache/x", false},
+		{"/private", true},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, common.allowed(tt.path), tt.path)
+	}
+
+	specific := parseRobots(data, "broadcaster/1.0")
+	require.False(t, specific.allowed("/private/page"))
+	require.True(t, specific.allowed("/search"), "Only specific group rules should be applied")
+
+	require.True(t, parseRobots(nil, "broadcaster").allowed("/anything"))
+
+	short := parseRobots([]byte("User-agent: b\nDisallow: /\n\nUser-agent: BROADCASTER\nDisallow: /private\n"), "Broadcaster/1.0")
+	require.True(t, short.allowed("/news"), "Groups names are matched as whole product tokens")
+	require.False(t, short.allowed("/private/page"), "Product tokens are case-insensitive")
+}
+
+func Test_Extractor_allowed_transientErrors(t *testing.T) {
+	ctx := context.Background()
+
+	var failing atomic.Bool
+	failing.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private/\n")
+	}))
+	defer srv.Close()
+
+	ex := New(&Config{Timeout: 5 * time.Second})
+	u, _ := url.Parse(srv.URL + "/news")
+
+	_, err := ex.allowed(ctx, u)
+	require.Error(t, err)
+
+	failing.Store(false)
+	allowed, err := ex.allowed(ctx, u)
+	require.NoError(t, err, "Errors aren't cached")
+	require.True(t, allowed)
+
+	down, _ := url.Parse("http://127.0.0.1:1/news")
+	_, err = ex.allowed(ctx, down)
+	require.Error(t, err)
+	require.NotContains(t, ex.robots, "http://127.0.0.1:1")
+}
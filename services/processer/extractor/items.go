@@ -0,0 +1,199 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/mmcdole/gofeed"
+)
+
+// CSS selectors of the page items. Selectors ending with '@attr' use the attribute value instead of the text.
+type Selectors struct {
+	Item        string // Items containers, other selectors are relative to them
+	Title       string
+	Link        string // The item itself or its first link if empty
+	Date        string
+	DateFormat  string // Go time layout of dates, common layouts are tried if empty
+	Description string
+}
+
+// Layouts of dates tried if the date format isn't set.
+var dateLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"02.01.2006 15:04",
+	"02.01.2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+}
+
+// Checks the selectors are valid CSS selectors with the required item and title ones.
+func (s Selectors) Validate() error {
+	if s.Item == "" || s.Title == "" {
+		return errors.New("Item and title selectors are required")
+	}
+	for _, spec := range []string{s.Item, s.Title, s.Link, s.Date, s.Description} {
+		sel, _ := splitSelector(spec)
+		if sel == "" {
+			continue
+		}
+		if _, err := cascadia.Compile(sel); err != nil {
+			return fmt.Errorf("Invalid selector '%s': %w", sel, err)
+		}
+	}
+	return nil
+}
+
+// Downloads the page and extracts items from it by the selectors.
+func (e *Extractor) ExtractItems(ctx context.Context, pageURL string, sel Selectors) ([]*gofeed.Item, error) {
+	if err := sel.Validate(); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse page URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("Unsupported page URL scheme '%s'", u.Scheme)
+	}
+
+	allowed, err := e.allowed(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to check robots.txt: %w", err)
+	}
+	if !allowed {
+		return nil, DisallowedByRobotsError
+	}
+
+	body, err := e.get(ctx, u.String(), e.cfg.MaxSize, true)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse page: %w", err)
+	}
+
+	return extractItems(doc, u, sel), nil
+}
+
+// Extracts items from the page document. Items without titles are skipped.
+// Links are resolved against the page URL (or its base element) and dates are left empty if can't be parsed.
+func extractItems(doc *goquery.Document, base *url.URL, sel Selectors) []*gofeed.Item {
+	if href, exists := doc.Find("base[href]").First().Attr("href"); exists {
+		if u, err := base.Parse(href); err == nil {
+			base = u
+		}
+	}
+
+	var result []*gofeed.Item
+
+	doc.Find(sel.Item).Each(func(_ int, s *goquery.Selection) {
+		item := &gofeed.Item{
+			Title:       selectValue(s, sel.Title, ""),
+			Description: selectValue(s, sel.Description, ""),
+		}
+		if item.Title == "" {
+			return
+		}
+
+		if link := itemLink(s, sel.Link); link != "" {
+			if u, err := base.Parse(link); err == nil {
+				item.Link = u.String()
+			}
+		}
+
+		if sel.Date != "" {
+			item.Published = selectValue(s, sel.Date, "datetime")
+			item.PublishedParsed = parseDate(item.Published, sel.DateFormat)
+		}
+
+		result = append(result, item)
+	})
+
+	return result
+}
+
+// Returns the link of the item: the selected element href, the item href or its first link href.
+func itemLink(s *goquery.Selection, spec string) string {
+	if spec != "" {
+		return selectValue(s, spec, "href")
+	}
+	if href, exists := s.Attr("href"); exists {
+		return strings.TrimSpace(href)
+	}
+	href, _ := s.Find("a[href]").First().Attr("href")
+	return strings.TrimSpace(href)
+}
+
+// Returns the text or the attribute value of the first element matching the selector within the item.
+// Default attribute is used instead of the text if the element has it.
+func selectValue(s *goquery.Selection, spec, defaultAttr string) string {
+	if spec == "" {
+		return ""
+	}
+
+	sel, attr := splitSelector(spec)
+	el := s
+	if sel != "" {
+		el = s.Find(sel).First()
+	}
+	if el.Length() == 0 {
+		return ""
+	}
+
+	if attr == "" && defaultAttr != "" {
+		if _, exists := el.Attr(defaultAttr); exists {
+			attr = defaultAttr
+		}
+	}
+	if attr != "" {
+		value, _ := el.Attr(attr)
+		return strings.TrimSpace(value)
+	}
+	return strings.Join(strings.Fields(el.Text()), " ")
+}
+
+// Splits the selector spec into the CSS selector and the attribute name: 'a.title@href'.
+func splitSelector(spec string) (string, string) {
+	i := strings.LastIndex(spec, "@")
+	if i < 0 {
+		return strings.TrimSpace(spec), ""
+	}
+	return strings.TrimSpace(spec[:i]), strings.TrimSpace(spec[i+1:])
+}
+
+// Parses the date with the layout or common layouts if the layout is empty.
+func parseDate(value, layout string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	layouts := dateLayouts
+	if layout != "" {
+		layouts = []string{layout}
+	}
+	for _, l := range layouts {
+		if t, err := time.Parse(l, value); err == nil {
+			t = t.UTC()
+			return &t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package extractor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/require"
+)
+
+func loadTestDocument(t *testing.T, name string) *goquery.Document {
+	f, err := os.Open("testdata/" + name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	doc, err := goquery.NewDocumentFromReader(f)
+	require.NoError(t, err)
+	return doc
+}
+
+func Test_extractItems(t *testing.T) {
+	t.Run("News", func(t *testing.T) {
+		base, _ := url.Parse("https://example.com/city/")
+		items := extractItems(loadTestDocument(t, "news.html"), base, Selectors{
+			Item:        "article.news",
+			Title:       "h2",
+			Date:        "time",
+			Description: ".summary",
+		})
+		require.Len(t, items, 3, "Items without titles are skipped")
+
+		require.Equal(t, "New tram line opens in the city centre", items[0].Title)
+		require.Equal(t, "https://example.com/news/1-tram-line", items[0].Link)
+		require.Equal(t, "The line connects the railway station with the harbour.", items[0].Description)
+		require.NotNil(t, items[0].PublishedParsed)
+		require.Equal(t, time.Date(2024, 5, 1, 6, 30, 0, 0, time.UTC), *items[0].PublishedParsed)
+
+		require.Equal(t, "https://other.example.com/story?id=2", items[1].Link)
+		require.Equal(t, time.Date(2024, 4, 30, 18, 0, 0, 0, time.UTC), *items[1].PublishedParsed)
+
+		require.Equal(t, "Market square renovation", items[2].Title)
+		require.Nil(t, items[2].PublishedParsed)
+		require.Empty(t, items[2].Description)
+	})
+
+	t.Run("Attributes", func(t *testing.T) {
+		base, _ := url.Parse("https://example.com/blog")
+		items := extractItems(loadTestDocument(t, "blog.html"), base, Selectors{
+			Item:       "ul.posts li",
+			Title:      "a.post",
+			Link:       "a.post@href",
+			Date:       "a.post@data-published",
+			DateFormat: "02.01.2006",
+		})
+		require.Len(t, items, 3)
+
+		require.Equal(t, "Release 2.0", items[0].Title)
+		require.Equal(t, "https://example.com/blog/posts/release-2-0", items[0].Link, "Links are resolved against the base element")
+		require.Equal(t, "05.03.2024", items[0].Published)
+		require.Equal(t, time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), *items[0].PublishedParsed)
+
+		require.Equal(t, "soon", items[2].Published)
+		require.Nil(t, items[2].PublishedParsed, "Invalid dates are left empty")
+	})
+}
+
+func Test_Selectors_Validate(t *testing.T) {
+	require.NoError(t, Selectors{Item: "article", Title: "h2 > a", Link: "@href", Date: "time@datetime"}.Validate())
+	require.Error(t, Selectors{Item: "article"}.Validate())
+	require.Error(t, Selectors{Item: "article", Title: "h2["}.Validate())
+}
+
+func Test_Extractor_ExtractItems(t *testing.T) {
+	ctx := context.Background()
+
+	page, err := os.ReadFile("testdata/news.html")
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	e := New(&Config{Timeout: 5 * time.Second, MaxSize: 1 << 20})
+	sel := Selectors{Item: "article.news", Title: "h2"}
+
+	items, err := e.ExtractItems(ctx, srv.URL+"/city", sel)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	require.Equal(t, srv.URL+"/news/1-tram-line", items[0].Link)
+
+	_, err = e.ExtractItems(ctx, srv.URL+"/private/city", sel)
+	require.ErrorIs(t, err, DisallowedByRobotsError)
+
+	_, err = e.ExtractItems(ctx, srv.URL+"/city", Selectors{Item: "article"})
+	require.Error(t, err)
+}
@@ -0,0 +1,268 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// JSONPath expressions of the JSON document items and their fields.
+// Items path is relative to the document root, fields paths are relative to items.
+// Supported expressions subset: '$', '.key', "['key']", '[0]', '[*]' and '.*'.
+type JSONMapping struct {
+	Items       string
+	Id          string
+	Title       string
+	Link        string
+	Date        string
+	DateFormat  string // Go time layout of dates, common layouts and unix timestamps are detected if empty
+	Description string
+	Image       string
+}
+
+// Checks the mapping paths are valid with the required items and title ones.
+func (m JSONMapping) Validate() error {
+	if m.Items == "" || m.Title == "" {
+		return errors.New("Items and title paths are required")
+	}
+	for _, path := range []string{m.Items, m.Id, m.Title, m.Link, m.Date, m.Description, m.Image} {
+		if _, err := parseJSONPath(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Downloads the JSON document and extracts items from it by the mapping.
+func (e *Extractor) ExtractJSONItems(ctx context.Context, docURL string, m JSONMapping) ([]*gofeed.Item, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(docURL)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse document URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("Unsupported document URL scheme '%s'", u.Scheme)
+	}
+
+	body, err := e.get(ctx, u.String(), e.cfg.MaxSize, false)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("Failed to parse JSON document: %w", err)
+	}
+
+	return extractJSONItems(doc, u, m)
+}
+
+// Extracts items from the JSON document. Items without titles are skipped.
+func extractJSONItems(doc interface{}, base *url.URL, m JSONMapping) ([]*gofeed.Item, error) {
+	itemsPath, err := parseJSONPath(m.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	values := itemsPath.eval(doc)
+	// Path to the items array itself
+	if len(values) == 1 && !itemsPath.wildcard() {
+		if arr, ok := values[0].([]interface{}); ok {
+			values = arr
+		}
+	}
+
+	field := func(value interface{}, path string) string {
+		if path == "" {
+			return ""
+		}
+		p, _ := parseJSONPath(path)
+		matches := p.eval(value)
+		if len(matches) == 0 {
+			return ""
+		}
+		return jsonString(matches[0])
+	}
+
+	var result []*gofeed.Item
+	for _, value := range values {
+		item := &gofeed.Item{
+			GUID:        field(value, m.Id),
+			Title:       field(value, m.Title),
+			Description: field(value, m.Description),
+		}
+		if item.Title == "" {
+			continue
+		}
+		if link := field(value, m.Link); link != "" {
+			if u, err := base.Parse(link); err == nil {
+				item.Link = u.String()
+			}
+		}
+		if image := field(value, m.Image); image != "" {
+			if u, err := base.Parse(image); err == nil {
+				item.Image = &gofeed.Image{URL: u.String()}
+			}
+		}
+		if m.Date != "" {
+			item.Published = field(value, m.Date)
+			item.PublishedParsed = parseJSONDate(item.Published, m.DateFormat)
+		}
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+// Returns the scalar JSON value as a string. Objects and arrays are empty.
+func jsonString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
+
+// Parses the date string or the unix timestamp in seconds or milliseconds.
+func parseJSONDate(value, layout string) *time.Time {
+	if layout == "" {
+		if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+			// Larger timestamps are milliseconds, in seconds they would be after the year 5138
+			var t time.Time
+			if ts > 1e11 {
+				t = time.UnixMilli(ts).UTC()
+			} else {
+				t = time.Unix(ts, 0).UTC()
+			}
+			return &t
+		}
+	}
+	return parseDate(value, layout)
+}
+
+// Step of the JSONPath expression.
+type jsonPathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+type jsonPath []jsonPathStep
+
+// Parses the JSONPath expressions subset: '$.data.items[*]', "$['key'][0].title", 'title'.
+func parseJSONPath(expr string) (jsonPath, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("Invalid JSONPath '%s': %s", expr, reason)
+	}
+
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "$")
+
+	var result jsonPath
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '.':
+			i++
+			end := i
+			for end < len(s) && s[end] != '.' && s[end] != '[' {
+				end++
+			}
+			name := s[i:end]
+			if name == "" {
+				return nil, invalid("empty key")
+			}
+			if name == "*" {
+				result = append(result, jsonPathStep{wildcard: true})
+			} else {
+				result = append(result, jsonPathStep{key: name})
+			}
+			i = end
+		case s[i] == '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, invalid("unclosed bracket")
+			}
+			inner := strings.TrimSpace(s[i+1 : i+end])
+			i += end + 1
+
+			switch {
+			case inner == "*":
+				result = append(result, jsonPathStep{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				result = append(result, jsonPathStep{key: inner[1 : len(inner)-1]})
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil || n < 0 {
+					return nil, invalid(fmt.Sprintf("unsupported selector '[%s]'", inner))
+				}
+				result = append(result, jsonPathStep{index: n, isIndex: true})
+			}
+		case len(result) == 0 && i == 0:
+			// Relative path without the leading dot: 'title', 'author.name'
+			s = "." + s
+		default:
+			return nil, invalid(fmt.Sprintf("unexpected character '%c'", s[i]))
+		}
+	}
+	return result, nil
+}
+
+// Checks whether the path selects several values.
+func (p jsonPath) wildcard() bool {
+	for _, step := range p {
+		if step.wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns values selected by the path.
+func (p jsonPath) eval(value interface{}) []interface{} {
+	values := []interface{}{value}
+	for _, step := range p {
+		var next []interface{}
+		for _, v := range values {
+			switch node := v.(type) {
+			case map[string]interface{}:
+				if step.wildcard {
+					for _, child := range node {
+						next = append(next, child)
+					}
+				} else if child, exists := node[step.key]; exists && !step.isIndex {
+					next = append(next, child)
+				}
+			case []interface{}:
+				switch {
+				case step.wildcard:
+					next = append(next, node...)
+				case step.isIndex && step.index < len(node):
+					next = append(next, node[step.index])
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
@@ -0,0 +1,144 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func loadTestJSON(t *testing.T, name string) interface{} {
+	data, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	require.NoError(t, dec.Decode(&doc))
+	return doc
+}
+
+func Test_extractJSONItems(t *testing.T) {
+	doc := loadTestJSON(t, "news.json")
+	base, _ := url.Parse("https://example.com/api/news")
+
+	mapping := JSONMapping{
+		Items:       "$.data.articles",
+		Id:          "id",
+		Title:       "headline",
+		Link:        "url",
+		Date:        "published_at",
+		Description: "summary",
+		Image:       "media.images[0].src",
+	}
+
+	items, err := extractJSONItems(doc, base, mapping)
+	require.NoError(t, err)
+	require.Len(t, items, 3, "Items without titles are skipped")
+
+	require.Equal(t, "101", items[0].GUID)
+	require.Equal(t, "New tram line opens in the city centre", items[0].Title)
+	require.Equal(t, "https://example.com/news/101-tram-line", items[0].Link)
+	require.Equal(t, "The line connects the railway station with the harbour.", items[0].Description)
+	require.Equal(t, "https://cdn.example.com/tram.jpg", items[0].Image.URL)
+	require.Equal(t, time.Date(2024, 5, 1, 6, 30, 0, 0, time.UTC), *items[0].PublishedParsed)
+
+	require.Equal(t, "https://other.example.com/story?id=102", items[1].Link)
+	require.Equal(t, time.Date(2024, 4, 30, 18, 0, 0, 0, time.UTC), *items[1].PublishedParsed, "Unix timestamps are parsed")
+	require.Nil(t, items[1].Image)
+
+	require.Equal(t, "Market square renovation", items[2].Title)
+	require.Nil(t, items[2].PublishedParsed, "Invalid dates are left empty")
+
+	t.Run("Wildcard", func(t *testing.T) {
+		mapping.Items = "$['data']['articles'][*]"
+		wildcard, err := extractJSONItems(doc, base, mapping)
+		require.NoError(t, err)
+		require.Equal(t, items, wildcard)
+	})
+}
+
+func Test_parseJSONPath(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    jsonPath
+		wantErr bool
+	}{
+		{expr: "$", want: nil},
+		{expr: "title", want: jsonPath{{key: "title"}}},
+		{expr: "$.data.items[*]", want: jsonPath{{key: "data"}, {key: "items"}, {wildcard: true}}},
+		{expr: "$['the key'][2].*", want: jsonPath{{key: "the key"}, {index: 2, isIndex: true}, {wildcard: true}}},
+		{expr: "author.name", want: jsonPath{{key: "author"}, {key: "name"}}},
+		{expr: "$.data[", wantErr: true},
+		{expr: "$..items", wantErr: true},
+		{expr: "$.items[?(@.id)]", wantErr: true},
+		{expr: "$.items[-1]", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := parseJSONPath(tt.expr)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_parseJSONDate(t *testing.T) {
+	require.Equal(t, time.Unix(1714500000, 0).UTC(), *parseJSONDate("1714500000", ""))
+	require.Equal(t, time.UnixMilli(1714500000123).UTC(), *parseJSONDate("1714500000123", ""))
+	require.Equal(t, time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), *parseJSONDate("05/03/2024", "02/01/2006"))
+	require.Nil(t, parseJSONDate("1714500000", "2006-01-02"))
+	require.Nil(t, parseJSONDate("", ""))
+}
+
+func Test_JSONMapping_Validate(t *testing.T) {
+	require.NoError(t, JSONMapping{Items: "$.items", Title: "title", Link: "links[0].href"}.Validate())
+	require.Error(t, JSONMapping{Items: "$.items"}.Validate())
+	require.Error(t, JSONMapping{Items: "$.items", Title: "title", Date: "$..date"}.Validate())
+}
+
+func Test_Extractor_ExtractJSONItems(t *testing.T) {
+	ctx := context.Background()
+
+	data, err := os.ReadFile("testdata/news.json")
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/news" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	e := New(&Config{Timeout: 5 * time.Second, MaxSize: 1 << 20})
+	mapping := JSONMapping{Items: "$.data.articles", Title: "headline", Link: "url"}
+
+	items, err := e.ExtractJSONItems(ctx, srv.URL+"/api/news", mapping)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	require.Equal(t, srv.URL+"/news/101-tram-line", items[0].Link)
+
+	_, err = e.ExtractJSONItems(ctx, srv.URL+"/missing", mapping)
+	require.Error(t, err)
+
+	_, err = e.ExtractJSONItems(ctx, "ftp://example.com/news.json", mapping)
+	require.Error(t, err)
+
+	small := New(&Config{Timeout: 5 * time.Second, MaxSize: 100})
+	_, err = small.ExtractJSONItems(ctx, srv.URL+"/api/news", mapping)
+	require.ErrorIs(t, err, TooLargeError)
+}
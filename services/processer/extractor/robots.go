@@ -0,0 +1,174 @@
+package extractor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// How long robots.txt rules are cached.
+const robotsTTL = time.Hour
+
+// Maximum robots.txt size (see RFC 9309).
+const robotsMaxSize = 500 * 1024
+
+type robotsRules struct {
+	fetched time.Time
+	rules   []robotsRule
+}
+
+type robotsRule struct {
+	allow bool
+	path  string
+}
+
+// Checks whether the link is allowed to be fetched by robots.txt rules of the site.
+func (e *Extractor) allowed(ctx context.Context, u *url.URL) (bool, error) {
+	key := u.Scheme + "://" + u.Host
+
+	e.mu.Lock()
+	rules, exists := e.robots[key]
+	e.mu.Unlock()
+
+	if !exists || time.Since(rules.fetched) > robotsTTL {
+		var err error
+		rules, err = e.fetchRobots(ctx, key)
+		if err != nil {
+			return false, err
+		}
+		e.mu.Lock()
+		e.robots[key] = rules
+		e.mu.Unlock()
+	}
+
+	return rules.allowed(u.RequestURI()), nil
+}
+
+func (e *Extractor) fetchRobots(ctx context.Context, site string) (*robotsRules, error) {
+	body, err := e.get(ctx, site+"/robots.txt", robotsMaxSize, false)
+	if err != nil {
+		var statusErr *statusError
+		switch {
+		case errors.Is(err, TooLargeError):
+			// Too large files can't be parsed correctly, treat as absent
+			return &robotsRules{fetched: time.Now()}, nil
+		case errors.As(err, &statusErr) && statusErr.Code >= 400 && statusErr.Code < 500:
+			// No robots.txt, everything is allowed
+			return &robotsRules{fetched: time.Now()}, nil
+		}
+		// Server and network errors are transient, nothing is fetched until robots.txt is available
+		return nil, err
+	}
+
+	rules := parseRobots(body, e.cfg.UserAgent)
+	rules.fetched = time.Now()
+	return rules, nil
+}
+
+// Parses robots.txt rules applied to the user agent.
+// Rules of the group matching the user agent are used, otherwise rules of the '*' group.
+func parseRobots(data []byte, userAgent string) *robotsRules {
+	token := productToken(userAgent)
+
+	var (
+		specific, common []robotsRule
+		hasSpecific      bool
+		agents           []string
+		inRules          bool
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// New group starts after the rules of the previous one
+			if inRules {
+				agents = nil
+				inRules = false
+			}
+			agents = append(agents, productToken(value))
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue
+			}
+			rule := robotsRule{allow: key == "allow", path: value}
+			for _, agent := range agents {
+				switch {
+				case agent == "*":
+					common = append(common, rule)
+				case token != "" && agent == token:
+					hasSpecific = true
+					specific = append(specific, rule)
+				}
+			}
+		}
+	}
+
+	if hasSpecific {
+		return &robotsRules{rules: specific}
+	}
+	return &robotsRules{rules: common}
+}
+
+// Returns the lowercased product token of the user agent: 'broadcaster' of 'Broadcaster/1.0'.
+func productToken(userAgent string) string {
+	return strings.ToLower(strings.TrimSpace(strings.SplitN(userAgent, "/", 2)[0]))
+}
+
+// Returns whether the path is allowed. The longest matching rule wins, allow wins on equal length.
+func (r *robotsRules) allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+
+	allowed := true
+	matched := -1
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.path, path) {
+			continue
+		}
+		if len(rule.path) > matched || (len(rule.path) == matched && rule.allow) {
+			matched = len(rule.path)
+			allowed = rule.allow
+		}
+	}
+	return allowed
+}
+
+// Matches the path against robots.txt pattern supporting '*' wildcards and '$' end anchor.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	if !anchored && !strings.Contains(pattern, "*") {
+		return strings.HasPrefix(path, pattern)
+	}
+
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	matched, err := regexp.MatchString(expr, path)
+	return err == nil && matched
+}
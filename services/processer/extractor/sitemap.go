@@ -0,0 +1,145 @@
+package extractor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// Returned for sitemaps indexes, feeds should point to the news sitemap itself.
+var SitemapIndexError = errors.New("Sitemap index isn't supported, use one of its sitemaps")
+
+// Sitemap document with the Google News and image extensions.
+type sitemapDocument struct {
+	XMLName xml.Name
+	URLs    []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+		News    struct {
+			Title           string `xml:"title"`
+			PublicationDate string `xml:"publication_date"`
+			Keywords        string `xml:"keywords"`
+		} `xml:"news"`
+		Images []struct {
+			Loc string `xml:"loc"`
+		} `xml:"image"`
+	} `xml:"url"`
+}
+
+// Downloads the (optionally gzipped) sitemap and extracts items from its URLs, newest first.
+func (e *Extractor) ExtractSitemapItems(ctx context.Context, sitemapURL string) ([]*gofeed.Item, error) {
+	u, err := url.Parse(sitemapURL)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse sitemap URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("Unsupported sitemap URL scheme '%s'", u.Scheme)
+	}
+
+	body, err := e.get(ctx, u.String(), e.cfg.MaxSize, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+		if body, err = e.gunzip(body); err != nil {
+			return nil, err
+		}
+	}
+
+	return extractSitemapItems(body, u)
+}
+
+// Decompresses the gzipped document respecting the size limit.
+func (e *Extractor) gunzip(body []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decompress sitemap: %w", err)
+	}
+	defer zr.Close()
+
+	reader := io.Reader(zr)
+	if e.cfg.MaxSize > 0 {
+		reader = io.LimitReader(zr, e.cfg.MaxSize+1)
+	}
+	result, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decompress sitemap: %w", err)
+	}
+	if e.cfg.MaxSize > 0 && int64(len(result)) > e.cfg.MaxSize {
+		return nil, TooLargeError
+	}
+	return result, nil
+}
+
+// Extracts items from the sitemap document. Titles are taken from news extensions or fall back to the links.
+func extractSitemapItems(body []byte, base *url.URL) ([]*gofeed.Item, error) {
+	var doc sitemapDocument
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("Failed to parse sitemap: %w", err)
+	}
+	switch doc.XMLName.Local {
+	case "urlset":
+	case "sitemapindex":
+		return nil, SitemapIndexError
+	default:
+		return nil, fmt.Errorf("Failed to parse sitemap: unexpected root element '%s'", doc.XMLName.Local)
+	}
+
+	var result []*gofeed.Item
+	for _, entry := range doc.URLs {
+		loc, err := base.Parse(strings.TrimSpace(entry.Loc))
+		if err != nil || entry.Loc == "" {
+			continue
+		}
+		link := loc.String()
+
+		item := &gofeed.Item{
+			GUID:  link,
+			Link:  link,
+			Title: strings.Join(strings.Fields(entry.News.Title), " "),
+		}
+		if item.Title == "" {
+			item.Title = link
+		}
+
+		item.Published = strings.TrimSpace(entry.News.PublicationDate)
+		if item.Published == "" {
+			item.Published = strings.TrimSpace(entry.LastMod)
+		}
+		item.PublishedParsed = parseDate(item.Published, "")
+
+		for _, keyword := range strings.Split(entry.News.Keywords, ",") {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				item.Categories = append(item.Categories, keyword)
+			}
+		}
+		if len(entry.Images) > 0 && entry.Images[0].Loc != "" {
+			if u, err := base.Parse(strings.TrimSpace(entry.Images[0].Loc)); err == nil {
+				item.Image = &gofeed.Image{URL: u.String()}
+			}
+		}
+
+		result = append(result, item)
+	}
+
+	// Sitemaps aren't ordered, items without dates go last
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i].PublishedParsed, result[j].PublishedParsed
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.After(*b)
+	})
+
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package extractor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_extractSitemapItems(t *testing.T) {
+	data, err := os.ReadFile("testdata/news-sitemap.xml")
+	require.NoError(t, err)
+	base, _ := url.Parse("https://example.com/news-sitemap.xml")
+
+	items, err := extractSitemapItems(data, base)
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+
+	require.Equal(t, "https://example.com/news/1-tram-line", items[0].GUID)
+	require.Equal(t, "https://example.com/news/1-tram-line", items[0].Link)
+	require.Equal(t, "New tram line opens in the city centre", items[0].Title)
+	require.Equal(t, time.Date(2024, 5, 1, 6, 30, 0, 0, time.UTC), *items[0].PublishedParsed, "News publication date is preferred")
+	require.Equal(t, "https://cdn.example.com/tram.jpg", items[0].Image.URL)
+
+	require.Equal(t, "City council approves the budget", items[1].Title)
+	require.Equal(t, []string{"council", "budget"}, items[1].Categories)
+
+	require.Equal(t, "https://example.com/news/3-market", items[2].Title, "Title falls back to the link")
+	require.Equal(t, time.Date(2024, 4, 29, 0, 0, 0, 0, time.UTC), *items[2].PublishedParsed)
+
+	require.Equal(t, "https://example.com/about", items[3].Link, "Items without dates go last")
+	require.Nil(t, items[3].PublishedParsed)
+
+	_, err = extractSitemapItems([]byte(`<sitemapindex><sitemap><loc>https://example.com/s1.xml</loc></sitemap></sitemapindex>`), base)
+	require.ErrorIs(t, err, SitemapIndexError)
+
+	_, err = extractSitemapItems([]byte(`<rss></rss>`), base)
+	require.Error(t, err)
+}
+
+func Test_Extractor_ExtractSitemapItems(t *testing.T) {
+	ctx := context.Background()
+
+	data, err := os.ReadFile("testdata/news-sitemap.xml")
+	require.NoError(t, err)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, err = zw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write(data)
+	})
+	mux.HandleFunc("/sitemap.xml.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Write(gz.Bytes())
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	e := New(&Config{Timeout: 5 * time.Second, MaxSize: 1 << 20})
+
+	items, err := e.ExtractSitemapItems(ctx, srv.URL+"/sitemap.xml")
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+
+	gzipped, err := e.ExtractSitemapItems(ctx, srv.URL+"/sitemap.xml.gz")
+	require.NoError(t, err)
+	require.Equal(t, items, gzipped)
+
+	small := New(&Config{Timeout: 5 * time.Second, MaxSize: int64(gz.Len()) + 10})
+	_, err = small.ExtractSitemapItems(ctx, srv.URL+"/sitemap.xml.gz")
+	require.ErrorIs(t, err, TooLargeError, "Decompressed size is limited too")
+}
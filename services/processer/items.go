@@ -0,0 +1,156 @@
+package processer
+
+import (
+	"broadcaster/structs"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
+)
+
+// Non-fatal problems found while converting parsed feed items.
+type parseWarning string
+
+const (
+	parseWarningNoGUID            parseWarning = "no_guid"
+	parseWarningNoLink            parseWarning = "no_link"
+	parseWarningNoPubDate         parseWarning = "no_pub_date"
+	parseWarningNoDates           parseWarning = "no_dates"
+	parseWarningUnknownIdStrategy parseWarning = "unknown_id_strategy"
+)
+
+// Converts parsed feed items to the feed items.
+// Returns converted items and the number of occurrences of each parse warning.
+func convertItems(feed structs.RssFeed, items []*gofeed.Item, fetched time.Time) ([]structs.RssFeedItem, map[parseWarning]int) {
+	warnings := make(map[parseWarning]int)
+
+	var result []structs.RssFeedItem
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+
+		converted := structs.RssFeedItem{
+			FeedId:      feed.Id,
+			Source:      feed.Source,
+			Categories:  item.Categories,
+			Title:       item.Title,
+			Description: item.Description,
+			Image:       itemImage(item),
+			Enclosures:  itemEnclosures(item),
+			Link:        item.Link,
+			Language:    feed.Language,
+		}
+
+		id, idWarnings := itemId(feed.IdStrategy, item)
+		for _, w := range idWarnings {
+			warnings[w]++
+		}
+		converted.Id = feed.Id + "/" + id
+
+		switch {
+		case item.PublishedParsed != nil:
+			converted.PubDate = *item.PublishedParsed
+		case item.UpdatedParsed != nil:
+			warnings[parseWarningNoPubDate]++
+			converted.PubDate = *item.UpdatedParsed
+		default:
+			warnings[parseWarningNoDates]++
+			converted.PubDate = fetched
+		}
+
+		result = append(result, converted)
+	}
+
+	return result, warnings
+}
+
+// Returns item identifier (without feed namespace) according to the strategy.
+func itemId(strategy structs.ItemIdStrategy, item *gofeed.Item) (string, []parseWarning) {
+	var warnings []parseWarning
+
+	switch strategy {
+	case "", structs.ItemIdStrategyGUID:
+		if item.GUID != "" {
+			return item.GUID, nil
+		}
+		warnings = append(warnings, parseWarningNoGUID)
+		fallthrough
+	case structs.ItemIdStrategyLink:
+		if item.Link != "" {
+			return item.Link, warnings
+		}
+		warnings = append(warnings, parseWarningNoLink)
+	case structs.ItemIdStrategyHash:
+	default:
+		warnings = append(warnings, parseWarningUnknownIdStrategy)
+	}
+
+	return itemHash(item), warnings
+}
+
+// Returns the hash of the item link and title.
+func itemHash(item *gofeed.Item) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\n%s", item.Link, item.Title)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Returns the item image URL from the item image, media extensions or image enclosures.
+func itemImage(item *gofeed.Item) string {
+	if item.Image != nil && item.Image.URL != "" {
+		return item.Image.URL
+	}
+
+	if media, ok := item.Extensions["media"]; ok {
+		// Media elements can be grouped into the 'media:group' element
+		elements := []map[string][]ext.Extension{media}
+		for _, group := range media["group"] {
+			elements = append(elements, group.Children)
+		}
+		for _, el := range elements {
+			for _, thumb := range el["thumbnail"] {
+				if thumb.Attrs["url"] != "" {
+					return thumb.Attrs["url"]
+				}
+			}
+			for _, content := range el["content"] {
+				if content.Attrs["url"] != "" && (content.Attrs["medium"] == "image" || isImageType(content.Attrs["type"])) {
+					return content.Attrs["url"]
+				}
+			}
+		}
+	}
+
+	for _, enc := range item.Enclosures {
+		if enc != nil && enc.URL != "" && isImageType(enc.Type) {
+			return enc.URL
+		}
+	}
+
+	return ""
+}
+
+func itemEnclosures(item *gofeed.Item) []structs.RssFeedEnclosure {
+	var result []structs.RssFeedEnclosure
+	for _, enc := range item.Enclosures {
+		if enc == nil || enc.URL == "" {
+			continue
+		}
+		length, _ := strconv.ParseInt(enc.Length, 10, 64)
+		result = append(result, structs.RssFeedEnclosure{
+			URL:    enc.URL,
+			Type:   enc.Type,
+			Length: length,
+		})
+	}
+	return result
+}
+
+func isImageType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "image/")
+}
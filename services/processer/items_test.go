@@ -0,0 +1,134 @@
+package processer
+
+import (
+	"broadcaster/structs"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_convertItems(t *testing.T) {
+	fetched := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	published := fetched.Add(-2 * time.Hour)
+	updated := fetched.Add(-time.Hour)
+
+	feed := structs.RssFeed{
+		Id:       "Dummy.Test",
+		Source:   "Dummy",
+		Language: "en",
+	}
+	items := []*gofeed.Item{
+		{GUID: "guid-1", Title: "First", Link: "https://example.com/1", PublishedParsed: &published},
+		{Title: "No GUID", Link: "https://example.com/2", UpdatedParsed: &updated},
+		{Title: "Nothing"},
+		nil,
+	}
+
+	result, warnings := convertItems(feed, items, fetched)
+	require.Len(t, result, 3, "Nil items should be skipped")
+
+	require.Equal(t, "Dummy.Test/guid-1", result[0].Id)
+	require.Equal(t, feed.Id, result[0].FeedId)
+	require.Equal(t, feed.Source, result[0].Source)
+	require.Equal(t, feed.Language, result[0].Language)
+	require.Equal(t, published, result[0].PubDate)
+
+	require.Equal(t, "Dummy.Test/https://example.com/2", result[1].Id)
+	require.Equal(t, updated, result[1].PubDate, "Updated date should be used without publication date")
+
+	require.Equal(t, "Dummy.Test/"+itemHash(items[2]), result[2].Id)
+	require.Equal(t, fetched, result[2].PubDate, "Fetch time should be used without dates")
+
+	require.Equal(t, 2, warnings[parseWarningNoGUID])
+	require.Equal(t, 1, warnings[parseWarningNoLink])
+	require.Equal(t, 1, warnings[parseWarningNoPubDate])
+	require.Equal(t, 1, warnings[parseWarningNoDates])
+}
+
+func Test_itemImage(t *testing.T) {
+	tests := []struct {
+		name string
+		item *gofeed.Item
+		want string
+	}{
+		{"none", &gofeed.Item{}, ""},
+		{"image", &gofeed.Item{Image: &gofeed.Image{URL: "https://example.com/image.jpg"}}, "https://example.com/image.jpg"},
+		{
+			"mediaThumbnail",
+			&gofeed.Item{Extensions: ext.Extensions{"media": {"thumbnail": {{Attrs: map[string]string{"url": "https://example.com/thumb.jpg"}}}}}},
+			"https://example.com/thumb.jpg",
+		},
+		{
+			"mediaContent",
+			&gofeed.Item{Extensions: ext.Extensions{"media": {"content": {
+				{Attrs: map[string]string{"url": "https://example.com/video.mp4", "medium": "video"}},
+				{Attrs: map[string]string{"url": "https://example.com/content.jpg", "medium": "image"}},
+			}}}},
+			"https://example.com/content.jpg",
+		},
+		{
+			"mediaGroup",
+			&gofeed.Item{Extensions: ext.Extensions{"media": {"group": {{Children: map[string][]ext.Extension{
+				"content": {{Attrs: map[string]string{"url": "https://example.com/group.png", "type": "image/png"}}},
+			}}}}}},
+			"https://example.com/group.png",
+		},
+		{
+			"enclosure",
+			&gofeed.Item{Enclosures: []*gofeed.Enclosure{
+				{URL: "https://example.com/audio.mp3", Type: "audio/mpeg"},
+				{URL: "https://example.com/enclosure.jpg", Type: "image/jpeg"},
+			}},
+			"https://example.com/enclosure.jpg",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, itemImage(tt.item))
+		})
+	}
+}
+
+func Test_itemEnclosures(t *testing.T) {
+	item := &gofeed.Item{Enclosures: []*gofeed.Enclosure{
+		{URL: "https://example.com/audio.mp3", Type: "audio/mpeg", Length: "1024"},
+		{URL: "https://example.com/file", Length: "unknown"},
+		{Type: "image/jpeg"},
+		nil,
+	}}
+	require.Equal(t, []structs.RssFeedEnclosure{
+		{URL: "https://example.com/audio.mp3", Type: "audio/mpeg", Length: 1024},
+		{URL: "https://example.com/file"},
+	}, itemEnclosures(item))
+}
+
+func Test_itemId(t *testing.T) {
+	item := &gofeed.Item{GUID: "guid", Link: "https://example.com/1", Title: "Title"}
+
+	tests := []struct {
+		name     string
+		strategy structs.ItemIdStrategy
+		item     *gofeed.Item
+		want     string
+		warnings []parseWarning
+	}{
+		{"default", "", item, "guid", nil},
+		{"guid", structs.ItemIdStrategyGUID, item, "guid", nil},
+		{"link", structs.ItemIdStrategyLink, item, "https://example.com/1", nil},
+		{"hash", structs.ItemIdStrategyHash, item, itemHash(item), nil},
+		{"linkWithoutLink", structs.ItemIdStrategyLink, &gofeed.Item{Title: "Title"}, itemHash(&gofeed.Item{Title: "Title"}), []parseWarning{parseWarningNoLink}},
+		{"unknown", "fake", item, itemHash(item), []parseWarning{parseWarningUnknownIdStrategy}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, warnings := itemId(tt.strategy, tt.item)
+			require.Equal(t, tt.want, id)
+			require.Equal(t, tt.warnings, warnings)
+		})
+	}
+
+	require.NotEqual(t, itemHash(item), itemHash(&gofeed.Item{Link: item.Link, Title: "Other"}))
+}
@@ -0,0 +1,11 @@
+package processer
+
+import "expvar"
+
+// Service metrics published with expvar (see /debug/vars).
+var (
+	// Number of parse warnings by feed and warning type. Key format: '<feed_id>.<warning>'
+	metricParseWarnings = expvar.NewMap("processer_parse_warnings")
+	// Number of failed feeds fetches by feed.
+	metricFeedErrors = expvar.NewMap("processer_feed_errors")
+)
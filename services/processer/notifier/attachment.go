@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"broadcaster/utils/strutil"
+	"html"
+	"strings"
+)
+
+// Maximum attachment text length. Both Mattermost and Rocket.Chat accept larger texts,
+// but long attachments are collapsed by clients anyway.
+const chatAttachmentTextLimit = 4000
+
+// Slack-like message attachment supported by Mattermost and Rocket.Chat.
+type chatAttachment struct {
+	Fallback   string `json:"fallback,omitempty"`
+	AuthorName string `json:"author_name,omitempty"`
+	Title      string `json:"title,omitempty"`
+	TitleLink  string `json:"title_link,omitempty"`
+	Text       string `json:"text,omitempty"`
+	ImageURL   string `json:"image_url,omitempty"`
+}
+
+// Renders the item attachment: source as the author, title with the item link and description.
+func newChatAttachment(item *structs.RssFeedItem) chatAttachment {
+	title := strings.TrimSpace(html.UnescapeString(item.Title))
+	return chatAttachment{
+		Fallback:   strutil.Coalesce(title, item.Link),
+		AuthorName: item.Source,
+		Title:      strutil.Coalesce(title, item.Link),
+		TitleLink:  item.Link,
+		Text:       truncate(plainText(item.Description), chatAttachmentTextLimit),
+	}
+}
+
+// Returns the attachment with the image.
+func (a chatAttachment) withImage(image string) chatAttachment {
+	a.ImageURL = image
+	return a
+}
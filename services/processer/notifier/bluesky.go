@@ -0,0 +1,375 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"broadcaster/utils/strutil"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap"
+)
+
+// Default Bluesky PDS (personal data server).
+const BlueskyDefaultURL = "https://bsky.social"
+
+type BlueskyNotifierConfig struct {
+	URL         string // PDS URL (default https://bsky.social)
+	Handle      string // Account handle or DID
+	AppPassword string // Account app password
+}
+
+// Publishes posts with link cards to a Bluesky account with the AT Protocol.
+type BlueskyNotifier struct {
+	cfg    *BlueskyNotifierConfig
+	logger *zap.SugaredLogger
+	mu     *sync.Mutex
+	// Current session
+	session *blueskySession
+}
+
+// Bluesky posts limits.
+const (
+	blueskyTextGraphemes = 300
+	blueskyTextBytes     = 3000
+	blueskyCardLimit     = 300     // Link card description length
+	blueskyThumbMaxSize  = 1000000 // Link card thumbnail size in bytes
+)
+
+type blueskySession struct {
+	AccessJwt string `json:"accessJwt"`
+	Did       string `json:"did"`
+	Handle    string `json:"handle"`
+}
+
+func init() {
+	Register("bluesky", Schema{
+		Fields: []Field{
+			{Name: "url", Env: "BLUESKY_URL", Default: BlueskyDefaultURL},
+			{Name: "handle", Env: "BLUESKY_HANDLE", Enables: true},
+			{Name: "app_password", Env: "BLUESKY_APP_PASSWORD", Secret: true},
+		},
+	}, func(s Settings, logger *zap.SugaredLogger) (Notifier, error) {
+		return NewBlueskyNotifier(&BlueskyNotifierConfig{
+			URL:         s.Get("url"),
+			Handle:      s.Get("handle"),
+			AppPassword: s.Get("app_password"),
+		}, logger)
+	})
+}
+
+func NewBlueskyNotifier(cfg *BlueskyNotifierConfig, logger *zap.SugaredLogger) (*BlueskyNotifier, error) {
+	if cfg.Handle == "" || cfg.AppPassword == "" {
+		return nil, errors.New("Bluesky handle and app password are required")
+	}
+	if cfg.URL == "" {
+		cfg.URL = BlueskyDefaultURL
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+
+	b := &BlueskyNotifier{
+		cfg:    cfg,
+		logger: logger,
+		mu:     &sync.Mutex{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	session, err := b.login(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create Bluesky session: %w", err)
+	}
+	b.logger.With("handle", session.Handle, "did", session.Did).Debug("Bluesky session created")
+
+	return b, nil
+}
+
+// Implement the Notifier interface
+var _ Notifier = (*BlueskyNotifier)(nil)
+
+// Bluesky post content.
+type blueskyPost struct {
+	Text        string
+	Title       string
+	Description string
+	Link        string
+	Language    string
+}
+
+func (b *BlueskyNotifier) Notify(ctx context.Context, r NotificationRequest) error {
+	post, ok := r.Payload.(blueskyPost)
+	if !ok {
+		return fmt.Errorf("Unexpected Bluesky payload type %T", r.Payload)
+	}
+
+	session, err := b.currentSession(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to create Bluesky session: %w", err)
+	}
+
+	record := map[string]interface{}{
+		"$type":     "app.bsky.feed.post",
+		"text":      post.Text,
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+	}
+	if post.Language != "" {
+		record["langs"] = []string{post.Language}
+	}
+
+	if post.Link != "" {
+		external := map[string]interface{}{
+			"uri":         post.Link,
+			"title":       post.Title,
+			"description": post.Description,
+		}
+		if r.Image != "" {
+			thumb, err := b.uploadThumb(ctx, r.Image)
+			if err != nil {
+				b.logger.With("err", err.Error()).Warn("Failed to upload link card thumbnail, posting without it")
+			} else {
+				external["thumb"] = thumb
+			}
+		}
+		record["embed"] = map[string]interface{}{
+			"$type":    "app.bsky.embed.external",
+			"external": external,
+		}
+	}
+
+	body := map[string]interface{}{
+		"repo":       session.Did,
+		"collection": "app.bsky.feed.post",
+		"record":     record,
+	}
+	if err := b.do(ctx, "com.atproto.repo.createRecord", body, nil); err != nil {
+		return fmt.Errorf("Failed to create Bluesky post: %w", err)
+	}
+	return nil
+}
+
+// Downloads the image and uploads it as a blob. Returns the blob reference.
+func (b *BlueskyNotifier) uploadThumb(ctx context.Context, image string) (interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, image, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new request: %w", err)
+	}
+	resp, err := mediaHttpCl.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to download image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Bad image response code (%d)", resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, "image/") {
+		return nil, fmt.Errorf("Unexpected image type '%s'", mediaType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, blueskyThumbMaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read image: %w", err)
+	}
+	if len(data) > blueskyThumbMaxSize {
+		return nil, errors.New("Image is too large")
+	}
+
+	var result struct {
+		Blob interface{} `json:"blob"`
+	}
+	err = b.withSession(ctx, func(session *blueskySession) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.cfg.URL+"/xrpc/com.atproto.repo.uploadBlob", bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("Failed to create new request: %w", err)
+		}
+		req.Header.Set("Content-Type", mediaType)
+		req.Header.Set("Authorization", "Bearer "+session.AccessJwt)
+		return doRequest(req, &result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Blob, nil
+}
+
+// Calls the XRPC procedure with the current session.
+func (b *BlueskyNotifier) do(ctx context.Context, method string, body, result interface{}) error {
+	return b.withSession(ctx, func(session *blueskySession) error {
+		headers := map[string]string{"Authorization": "Bearer " + session.AccessJwt}
+		return doJSON(ctx, http.MethodPost, b.cfg.URL+"/xrpc/"+method, headers, body, result)
+	})
+}
+
+// Runs the function with the current session. Creates a new session and retries once if the session is expired.
+func (b *BlueskyNotifier) withSession(ctx context.Context, fn func(session *blueskySession) error) error {
+	session, err := b.currentSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = fn(session)
+	if !blueskySessionExpired(err) {
+		return err
+	}
+
+	b.logger.Debug("Bluesky session is expired, logging in again")
+	if session, err = b.login(ctx); err != nil {
+		return err
+	}
+	return fn(session)
+}
+
+// Returns the current session, logging in if there is no session.
+func (b *BlueskyNotifier) currentSession(ctx context.Context) (*blueskySession, error) {
+	b.mu.Lock()
+	session := b.session
+	b.mu.Unlock()
+	if session != nil {
+		return session, nil
+	}
+	return b.login(ctx)
+}
+
+// Creates a new session with the app password.
+func (b *BlueskyNotifier) login(ctx context.Context) (*blueskySession, error) {
+	body := map[string]string{
+		"identifier": b.cfg.Handle,
+		"password":   b.cfg.AppPassword,
+	}
+	var session blueskySession
+	if err := doJSON(ctx, http.MethodPost, b.cfg.URL+"/xrpc/com.atproto.server.createSession", nil, body, &session); err != nil {
+		return nil, err
+	}
+	if session.AccessJwt == "" || session.Did == "" {
+		return nil, errors.New("Empty session in the response")
+	}
+
+	b.mu.Lock()
+	b.session = &session
+	b.mu.Unlock()
+
+	return &session, nil
+}
+
+// Checks whether the error is caused by the expired or invalid access token.
+func blueskySessionExpired(err error) bool {
+	var herr *httpError
+	if !errors.As(err, &herr) {
+		return false
+	}
+	return herr.Code == http.StatusUnauthorized ||
+		(herr.Code == http.StatusBadRequest && strings.Contains(herr.Body, "ExpiredToken"))
+}
+
+func (b *BlueskyNotifier) NewRequest(fn structs.RssFeedNotification, item *structs.RssFeedItem) NotificationRequest {
+	title := strings.TrimSpace(html.UnescapeString(item.Title))
+	description := plainText(item.Description)
+
+	// Link is shown in the card, so the text contains only the title and the description
+	text := title
+	if description != "" {
+		text = strings.TrimSpace(title + "\n\n" + description)
+	}
+	if text == "" {
+		text = item.Link
+	}
+
+	post := blueskyPost{
+		Text:        truncateGraphemes(text, blueskyTextGraphemes, blueskyTextBytes),
+		Title:       strutil.Coalesce(title, item.Link),
+		Description: truncateGraphemes(description, blueskyCardLimit, blueskyTextBytes),
+		Link:        item.Link,
+		Language:    languageCode(item.Language),
+	}
+
+	return NotificationRequest{
+		To:      fn.To,
+		Source:  item.Source,
+		Image:   item.Image,
+		Message: post.Text,
+		Payload: post,
+	}
+}
+
+// Truncates the text to the limits of graphemes and bytes adding ellipsis to the truncated text.
+func truncateGraphemes(text string, limit, bytesLimit int) string {
+	clusters := graphemes(text)
+	if len(clusters) <= limit && len(text) <= bytesLimit {
+		return text
+	}
+
+	ellipsis := "…"
+	var b strings.Builder
+	for i, c := range clusters {
+		if i >= limit-1 || b.Len()+len(c)+len(ellipsis) > bytesLimit {
+			break
+		}
+		b.WriteString(c)
+	}
+	return strings.TrimSpace(b.String()) + ellipsis
+}
+
+// Splits the text into grapheme clusters (user-perceived characters).
+// Approximation of Unicode text segmentation rules covering combining marks, variation selectors,
+// emoji modifiers, zero width joiner sequences and flags.
+func graphemes(text string) []string {
+	var result []string
+
+	start := 0
+	prev := rune(-1)
+	regional := 0 // Regional indicators in the current cluster
+
+	for i, r := range text {
+		if i > 0 && !graphemeExtends(prev, r, regional) {
+			result = append(result, text[start:i])
+			start = i
+			regional = 0
+		}
+		if isRegionalIndicator(r) {
+			regional++
+		}
+		prev = r
+	}
+	if start < len(text) {
+		result = append(result, text[start:])
+	}
+
+	return result
+}
+
+// Checks whether the rune continues the cluster ending with the previous rune.
+func graphemeExtends(prev, r rune, regional int) bool {
+	switch {
+	case prev == '\r' && r == '\n':
+		return true
+	case unicode.Is(unicode.Mn, r), unicode.Is(unicode.Me, r), unicode.Is(unicode.Mc, r):
+		return true
+	case r == 0x200D, r >= 0xFE00 && r <= 0xFE0F, r >= 0xE0020 && r <= 0xE007F:
+		// Zero width joiner, variation selectors and tags
+		return true
+	case r >= 0x1F3FB && r <= 0x1F3FF:
+		// Emoji skin tone modifiers
+		return true
+	case prev == 0x200D:
+		return true
+	case isRegionalIndicator(prev) && isRegionalIndicator(r):
+		// Flags are pairs of regional indicators
+		return regional%2 == 1
+	}
+	return false
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
@@ -0,0 +1,147 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// Local stand-in for Bluesky PDS. Access tokens expire after the first created record.
+type fakePDS struct {
+	srv      *httptest.Server
+	mu       sync.Mutex
+	sessions int
+	records  []map[string]interface{}
+	blobs    int
+}
+
+func newFakePDS(t *testing.T) *fakePDS {
+	f := &fakePDS{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xrpc/com.atproto.server.createSession", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["identifier"] != "bot.example.com" || body["password"] != "app-password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"AuthenticationRequired","message":"Invalid identifier or password"}`)
+			return
+		}
+		f.mu.Lock()
+		f.sessions++
+		fmt.Fprintf(w, `{"accessJwt":"token-%d","refreshJwt":"refresh","did":"did:plc:bot","handle":"bot.example.com"}`, f.sessions)
+		f.mu.Unlock()
+	})
+	mux.HandleFunc("/xrpc/com.atproto.repo.uploadBlob", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "image/png", r.Header.Get("Content-Type"))
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, "png", string(data))
+		f.mu.Lock()
+		f.blobs++
+		f.mu.Unlock()
+		fmt.Fprint(w, `{"blob":{"$type":"blob","ref":{"$link":"cid"},"mimeType":"image/png","size":3}}`)
+	})
+	mux.HandleFunc("/xrpc/com.atproto.repo.createRecord", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		// The first session expires after the first record
+		if r.Header.Get("Authorization") == "Bearer token-1" && len(f.records) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"ExpiredToken","message":"Token has expired"}`)
+			return
+		}
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, "did:plc:bot", body["repo"])
+		require.Equal(t, "app.bsky.feed.post", body["collection"])
+		f.records = append(f.records, body["record"].(map[string]interface{}))
+		fmt.Fprint(w, `{"uri":"at://did:plc:bot/app.bsky.feed.post/1","cid":"cid"}`)
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png"))
+	})
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func Test_BlueskyNotifier(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakePDS(t)
+
+	_, err := NewBlueskyNotifier(&BlueskyNotifierConfig{URL: fake.srv.URL, Handle: "bot.example.com", AppPassword: "wrong"}, zap.NewNop().Sugar())
+	require.Error(t, err)
+
+	bn, err := NewBlueskyNotifier(&BlueskyNotifierConfig{URL: fake.srv.URL, Handle: "bot.example.com", AppPassword: "app-password"}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	item := &structs.RssFeedItem{
+		Title:       "Title",
+		Description: "<p>" + strings.Repeat("Description 👍🏽. ", 40) + "</p>",
+		Link:        "https://example.com/news/1",
+		Image:       fake.srv.URL + "/image.png",
+		Language:    "fi",
+	}
+
+	req := bn.NewRequest(structs.RssFeedNotification{Type: "bluesky"}, item)
+	require.NoError(t, bn.Notify(ctx, req))
+	// Session is expired for the second post
+	require.NoError(t, bn.Notify(ctx, req))
+	require.Equal(t, 2, fake.sessions)
+	require.Len(t, fake.records, 2)
+	require.Equal(t, 2, fake.blobs)
+
+	record := fake.records[0]
+	require.Equal(t, "app.bsky.feed.post", record["$type"])
+	require.Equal(t, []interface{}{"fi"}, record["langs"])
+	require.NotEmpty(t, record["createdAt"])
+
+	text := record["text"].(string)
+	require.True(t, strings.HasPrefix(text, "Title\n\nDescription 👍🏽."))
+	require.True(t, strings.HasSuffix(text, "…"))
+	require.LessOrEqual(t, len(graphemes(text)), blueskyTextGraphemes)
+
+	embed := record["embed"].(map[string]interface{})
+	require.Equal(t, "app.bsky.embed.external", embed["$type"])
+	external := embed["external"].(map[string]interface{})
+	require.Equal(t, item.Link, external["uri"])
+	require.Equal(t, "Title", external["title"])
+	require.NotEmpty(t, external["description"])
+	require.Equal(t, "cid", external["thumb"].(map[string]interface{})["ref"].(map[string]interface{})["$link"])
+}
+
+func Test_graphemes(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"abc", []string{"a", "b", "c"}},
+		{"éa", []string{"é", "a"}},
+		{"👍🏽!", []string{"👍🏽", "!"}},
+		{"👩‍👩‍👧x", []string{"👩‍👩‍👧", "x"}},
+		{"🇫🇮🇺🇸", []string{"🇫🇮", "🇺🇸"}},
+		{"❤️a", []string{"❤️", "a"}},
+		{"a\r\nb", []string{"a", "\r\n", "b"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, graphemes(tt.text), tt.text)
+	}
+
+	require.Equal(t, "ab…", truncateGraphemes("abcdef", 3, 100))
+	require.Equal(t, "👍🏽👍🏽…", truncateGraphemes(strings.Repeat("👍🏽", 10), 3, 100))
+	require.Equal(t, "abc", truncateGraphemes("abc", 3, 100))
+	// Bytes limit
+	require.Equal(t, "ab…", truncateGraphemes("abcdef", 10, 5))
+}
@@ -0,0 +1,151 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"broadcaster/utils/strutil"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type GotifyNotifierConfig struct {
+	URL      string // Server URL
+	AppToken string // Application token, messages are pushed to this application
+}
+
+// Pushes messages to Gotify applications.
+type GotifyNotifier struct {
+	cfg    *GotifyNotifierConfig
+	logger *zap.SugaredLogger
+}
+
+func init() {
+	// Tokens are secrets, so several applications are several named instances instead of destinations
+	Register("gotify", Schema{
+		NoDestinations: true,
+		Fields: []Field{
+			{Name: "url", Env: "GOTIFY_URL", Enables: true},
+			{Name: "app_token", Env: "GOTIFY_APP_TOKEN", Secret: true},
+		},
+	}, func(s Settings, logger *zap.SugaredLogger) (Notifier, error) {
+		return NewGotifyNotifier(&GotifyNotifierConfig{
+			URL:      s.Get("url"),
+			AppToken: s.Get("app_token"),
+		}, logger)
+	})
+}
+
+func NewGotifyNotifier(cfg *GotifyNotifierConfig, logger *zap.SugaredLogger) (*GotifyNotifier, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("Gotify server URL is required")
+	}
+	if cfg.AppToken == "" {
+		return nil, errors.New("Gotify application token is required")
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+
+	g := &GotifyNotifier{
+		cfg:    cfg,
+		logger: logger,
+	}
+	return g, nil
+}
+
+// Implement the Notifier interface
+var _ Notifier = (*GotifyNotifier)(nil)
+
+// Gotify priorities by the priority level.
+// Clients don't notify about 0 priority messages and show 8+ as high priority.
+var gotifyPriorities = []int{0, 2, 5, 8, 10}
+
+// Maximum message length.
+const gotifyMessageLimit = 4000
+
+// Gotify message.
+type gotifyMessage struct {
+	Title    string                 `json:"title,omitempty"`
+	Message  string                 `json:"message"`
+	Priority int                    `json:"priority"`
+	Extras   map[string]interface{} `json:"extras,omitempty"`
+	// Item link opened by the notification click
+	link string
+}
+
+// Returns the message with the extras: markdown content type, click URL and the image.
+func (m gotifyMessage) withExtras(image string) gotifyMessage {
+	notification := map[string]interface{}{}
+	if m.link != "" {
+		notification["click"] = map[string]string{"url": m.link}
+	}
+	if image != "" {
+		notification["bigImageUrl"] = image
+	}
+
+	m.Extras = map[string]interface{}{
+		"client::display":      map[string]string{"contentType": "text/markdown"},
+		"client::notification": notification,
+	}
+	return m
+}
+
+func (g *GotifyNotifier) Notify(ctx context.Context, r NotificationRequest) error {
+	msg, ok := r.Payload.(gotifyMessage)
+	if !ok {
+		return fmt.Errorf("Unexpected Gotify payload type %T", r.Payload)
+	}
+
+	var image string
+	if r.Image != "" && imageAvailable(ctx, r.Image) {
+		image = r.Image
+	}
+	msg = msg.withExtras(image)
+
+	headers := map[string]string{"X-Gotify-Key": g.cfg.AppToken}
+	if err := doJSON(ctx, http.MethodPost, g.cfg.URL+"/message", headers, msg, nil); err != nil {
+		return fmt.Errorf("Failed to push Gotify message: %w", err)
+	}
+	return nil
+}
+
+func (g *GotifyNotifier) NewRequest(fn structs.RssFeedNotification, item *structs.RssFeedItem) NotificationRequest {
+	title := strings.TrimSpace(html.UnescapeString(item.Title))
+
+	var parts []string
+	if description := plainText(item.Description); description != "" {
+		parts = append(parts, escapeMarkdown(truncate(description, gotifyMessageLimit)))
+	}
+	if item.Link != "" {
+		parts = append(parts, "["+escapeMarkdown(strutil.Coalesce(item.Source, item.Link))+"]("+strings.ReplaceAll(item.Link, ")", "%29")+")")
+	}
+
+	msg := gotifyMessage{
+		Title:    strutil.Coalesce(title, item.Source),
+		Message:  strutil.Coalesce(strings.Join(parts, "\n\n"), escapeMarkdown(title)),
+		Priority: gotifyPriorities[fn.Priority.Level()],
+		link:     item.Link,
+	}
+
+	return NotificationRequest{
+		Source:  item.Source,
+		Image:   item.Image,
+		Message: msg.Message,
+		Payload: msg,
+	}
+}
+
+// Escapes Markdown special characters of the text.
+func escapeMarkdown(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune("\\`*_[]<>#|", r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_GotifyNotifier(t *testing.T) {
+	ctx := context.Background()
+
+	var received []map[string]interface{}
+	var tokens []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/message", r.URL.Path)
+		if r.Header.Get("X-Gotify-Key") == "invalid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"Unauthorized","errorCode":401}`))
+			return
+		}
+		var msg map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		received = append(received, msg)
+		tokens = append(tokens, r.Header.Get("X-Gotify-Key"))
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewGotifyNotifier(&GotifyNotifierConfig{AppToken: "app"}, zap.NewNop().Sugar())
+	require.Error(t, err)
+	_, err = NewGotifyNotifier(&GotifyNotifierConfig{URL: srv.URL}, zap.NewNop().Sugar())
+	require.Error(t, err, "Application token is required")
+
+	gn, err := NewGotifyNotifier(&GotifyNotifierConfig{URL: srv.URL, AppToken: "app"}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	item := &structs.RssFeedItem{
+		Source:      "Dummy_Source",
+		Title:       "Title",
+		Description: "<p>Some *bold* text</p>",
+		Link:        "https://example.com/wiki/Go_(language)",
+	}
+
+	require.NoError(t, gn.Notify(ctx, gn.NewRequest(structs.RssFeedNotification{Type: "gotify", Priority: structs.NotificationPriorityMax}, item)))
+	require.NoError(t, gn.Notify(ctx, gn.NewRequest(structs.RssFeedNotification{Type: "gotify"}, item)))
+
+	require.Equal(t, []string{"app", "app"}, tokens)
+
+	invalid, err := NewGotifyNotifier(&GotifyNotifierConfig{URL: srv.URL, AppToken: "invalid"}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	require.Error(t, invalid.Notify(ctx, invalid.NewRequest(structs.RssFeedNotification{Type: "gotify"}, item)))
+
+	msg := received[0]
+	require.Equal(t, "Title", msg["title"])
+	require.Equal(t, "Some \\*bold\\* text\n\n[Dummy\\_Source](https://example.com/wiki/Go_(language%29)", msg["message"])
+	require.Equal(t, float64(10), msg["priority"])
+	require.Equal(t, map[string]interface{}{
+		"client::display":      map[string]interface{}{"contentType": "text/markdown"},
+		"client::notification": map[string]interface{}{"click": map[string]interface{}{"url": item.Link}},
+	}, msg["extras"])
+	require.Equal(t, float64(5), received[1]["priority"])
+}
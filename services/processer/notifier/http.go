@@ -0,0 +1,89 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTP client for notifiers without SDK clients.
+var httpCl = &http.Client{Timeout: 30 * time.Second}
+
+// Maximum size of the error response body kept in errors.
+const httpErrorBodyLimit = 512
+
+// Unsuccessful HTTP response of a notification service.
+type httpError struct {
+	Code       int
+	Body       string
+	RetryAfter time.Duration // Delay requested by the service (if any)
+}
+
+func (e *httpError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("Bad response code (%d)", e.Code)
+	}
+	return fmt.Sprintf("Bad response code (%d): %s", e.Code, e.Body)
+}
+
+// Sends the request with JSON body (if not nil) and decodes JSON response to the result (if not nil).
+func doJSON(ctx context.Context, method, uri string, headers map[string]string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("Failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, uri, reader)
+	if err != nil {
+		return fmt.Errorf("Failed to create new request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	return doRequest(req, result)
+}
+
+// Sends the request and decodes JSON response to the result (if not nil).
+func doRequest(req *http.Request, result interface{}) error {
+	resp, err := httpCl.Do(req)
+	if err != nil {
+		return fmt.Errorf("Failed to do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, httpErrorBodyLimit))
+		herr := &httpError{
+			Code: resp.StatusCode,
+			Body: strings.TrimSpace(string(data)),
+		}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			herr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return herr
+	}
+
+	if result == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("Failed to decode response: %w", err)
+	}
+	return nil
+}
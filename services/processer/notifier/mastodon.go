@@ -0,0 +1,215 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+type MastodonNotifierConfig struct {
+	URL   string // Instance URL
+	Token string // Application access token with the 'write:statuses' scope
+}
+
+// Publishes statuses to a Mastodon account.
+type MastodonNotifier struct {
+	cfg    *MastodonNotifierConfig
+	logger *zap.SugaredLogger
+	// Instance statuses limits
+	maxChars     int
+	charsPerLink int
+}
+
+// Default Mastodon statuses limits, used if the instance doesn't report its own.
+const (
+	mastodonDefaultMaxChars     = 500
+	mastodonDefaultCharsPerLink = 23
+)
+
+// Mastodon statuses visibilities.
+var mastodonVisibilities = []string{"public", "unlisted", "private", "direct"}
+
+func init() {
+	Register("mastodon", Schema{
+		Fields: []Field{
+			{Name: "url", Env: "MASTODON_URL"},
+			{Name: "token", Env: "MASTODON_TOKEN", Secret: true, Enables: true},
+		},
+	}, func(s Settings, logger *zap.SugaredLogger) (Notifier, error) {
+		return NewMastodonNotifier(&MastodonNotifierConfig{
+			URL:   s.Get("url"),
+			Token: s.Get("token"),
+		}, logger)
+	})
+}
+
+func NewMastodonNotifier(cfg *MastodonNotifierConfig, logger *zap.SugaredLogger) (*MastodonNotifier, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("Mastodon instance URL is required")
+	}
+	if cfg.Token == "" {
+		return nil, errors.New("Mastodon access token is required")
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+
+	m := &MastodonNotifier{
+		cfg:          cfg,
+		logger:       logger,
+		maxChars:     mastodonDefaultMaxChars,
+		charsPerLink: mastodonDefaultCharsPerLink,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var account struct {
+		Acct string `json:"acct"`
+	}
+	if err := m.do(ctx, http.MethodGet, "/api/v1/accounts/verify_credentials", nil, nil, &account); err != nil {
+		return nil, fmt.Errorf("Failed to verify Mastodon credentials: %w", err)
+	}
+
+	var instance struct {
+		Configuration struct {
+			Statuses struct {
+				MaxCharacters            int `json:"max_characters"`
+				CharactersReservedPerURL int `json:"characters_reserved_per_url"`
+			} `json:"statuses"`
+		} `json:"configuration"`
+	}
+	if err := m.do(ctx, http.MethodGet, "/api/v2/instance", nil, nil, &instance); err != nil {
+		m.logger.With("err", err.Error()).Warn("Failed to get Mastodon instance limits, using defaults")
+	} else {
+		if v := instance.Configuration.Statuses.MaxCharacters; v > 0 {
+			m.maxChars = v
+		}
+		if v := instance.Configuration.Statuses.CharactersReservedPerURL; v > 0 {
+			m.charsPerLink = v
+		}
+	}
+
+	m.logger.With("account", account.Acct, "max_chars", m.maxChars).Debug("Mastodon client initialized")
+
+	return m, nil
+}
+
+// Implement the Notifier interface
+var _ Notifier = (*MastodonNotifier)(nil)
+
+// Mastodon status content.
+type mastodonStatus struct {
+	Title          string
+	Description    string
+	Link           string
+	Visibility     string
+	ContentWarning string
+	Language       string
+	// Unique key of the status, so retried requests don't create duplicates
+	IdempotencyKey string
+}
+
+func (m *MastodonNotifier) Notify(ctx context.Context, r NotificationRequest) error {
+	status, ok := r.Payload.(mastodonStatus)
+	if !ok {
+		return fmt.Errorf("Unexpected Mastodon payload type %T", r.Payload)
+	}
+	// Falling back to other visibility could expose non-public posts
+	if !slices.Contains(mastodonVisibilities, status.Visibility) {
+		return fmt.Errorf("Unsupported Mastodon visibility '%s'", status.Visibility)
+	}
+
+	body := map[string]interface{}{
+		"status":     m.text(status),
+		"visibility": status.Visibility,
+	}
+	if status.ContentWarning != "" {
+		body["spoiler_text"] = status.ContentWarning
+	}
+	if status.Language != "" {
+		body["language"] = status.Language
+	}
+
+	headers := map[string]string{"Idempotency-Key": status.IdempotencyKey}
+	if err := m.do(ctx, http.MethodPost, "/api/v1/statuses", headers, body, nil); err != nil {
+		return fmt.Errorf("Failed to post Mastodon status: %w", err)
+	}
+	return nil
+}
+
+// Renders the status text fitting the instance limit: title, description and the link.
+// Links are counted by the instance as fixed number of characters, content warning is counted as well.
+func (m *MastodonNotifier) text(status mastodonStatus) string {
+	available := m.maxChars - utf8.RuneCountInString(status.ContentWarning)
+
+	var footer string
+	if status.Link != "" {
+		footer = "\n\n" + status.Link
+		available -= 2 + m.charsPerLink
+	}
+
+	title := truncate(status.Title, max(available, 0))
+	available -= utf8.RuneCountInString(title)
+
+	var description string
+	// Too short description remainders are useless
+	if status.Description != "" && available-2 >= 50 {
+		description = "\n\n" + truncate(status.Description, available-2)
+	}
+
+	return strings.TrimSpace(title + description + footer)
+}
+
+func (m *MastodonNotifier) do(ctx context.Context, method, path string, headers map[string]string, body, result interface{}) error {
+	h := map[string]string{"Authorization": "Bearer " + m.cfg.Token}
+	for k, v := range headers {
+		h[k] = v
+	}
+	return doJSON(ctx, method, m.cfg.URL+path, h, body, result)
+}
+
+func (m *MastodonNotifier) NewRequest(fn structs.RssFeedNotification, item *structs.RssFeedItem) NotificationRequest {
+	visibility := fn.Visibility
+	if visibility == "" {
+		visibility = mastodonVisibilities[0]
+	}
+
+	status := mastodonStatus{
+		Title:          strings.TrimSpace(html.UnescapeString(item.Title)),
+		Description:    plainText(item.Description),
+		Link:           item.Link,
+		Visibility:     visibility,
+		ContentWarning: fn.ContentWarning,
+		Language:       languageCode(item.Language),
+	}
+	sum := sha256.Sum256([]byte(item.Id + "\n" + status.Language + "\n" + status.Title))
+	status.IdempotencyKey = hex.EncodeToString(sum[:16])
+
+	return NotificationRequest{
+		To:      fn.To,
+		Source:  item.Source,
+		Image:   item.Image,
+		Message: m.text(status),
+		Payload: status,
+	}
+}
+
+// Returns ISO 639-1 language code from the language tag (eg 'en' for 'en-US').
+func languageCode(lang string) string {
+	code, _, _ := strings.Cut(strings.ReplaceAll(lang, "_", "-"), "-")
+	code = strings.ToLower(strings.TrimSpace(code))
+	if len(code) != 2 {
+		return ""
+	}
+	return code
+}
@@ -0,0 +1,109 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_MastodonNotifier(t *testing.T) {
+	ctx := context.Background()
+
+	var statuses []map[string]interface{}
+	var keys []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/accounts/verify_credentials", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"The access token is invalid"}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":"1","acct":"bot"}`)
+	})
+	mux.HandleFunc("/api/v2/instance", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"configuration":{"statuses":{"max_characters":200,"characters_reserved_per_url":23}}}`)
+	})
+	mux.HandleFunc("/api/v1/statuses", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		var status map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&status))
+		statuses = append(statuses, status)
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		fmt.Fprint(w, `{"id":"1"}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	_, err := NewMastodonNotifier(&MastodonNotifierConfig{URL: srv.URL, Token: "invalid"}, zap.NewNop().Sugar())
+	require.ErrorContains(t, err, "access token is invalid")
+
+	mn, err := NewMastodonNotifier(&MastodonNotifierConfig{URL: srv.URL, Token: "test-token"}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	require.Equal(t, 200, mn.maxChars)
+
+	item := &structs.RssFeedItem{
+		Id:          "feed/1",
+		Source:      "Dummy",
+		Title:       "Title &amp; symbols",
+		Description: "<p>" + strings.Repeat("Long description. ", 20) + "</p>",
+		Link:        "https://example.com/news/1?with=a&very=long&query=string&that=exceeds&the=reserved&length=1",
+		Language:    "en-US",
+	}
+
+	nfn := structs.RssFeedNotification{Type: "mastodon", Visibility: "unlisted", ContentWarning: "News"}
+	req := mn.NewRequest(nfn, item)
+	require.NoError(t, mn.Notify(ctx, req))
+	require.NoError(t, mn.Notify(ctx, req))
+
+	require.Len(t, statuses, 2)
+	status := statuses[0]
+	require.Equal(t, "unlisted", status["visibility"])
+	require.Equal(t, "News", status["spoiler_text"])
+	require.Equal(t, "en", status["language"])
+
+	text := status["status"].(string)
+	require.True(t, strings.HasPrefix(text, "Title & symbols\n\nLong description."))
+	require.True(t, strings.HasSuffix(text, "…\n\n"+item.Link))
+	// Link is counted as the reserved number of characters
+	counted := utf8.RuneCountInString(strings.TrimSuffix(text, item.Link)) + 23 + utf8.RuneCountInString("News")
+	require.LessOrEqual(t, counted, 200)
+
+	// Retries of the same status use the same key
+	require.NotEmpty(t, keys[0])
+	require.Equal(t, keys[0], keys[1])
+
+	t.Run("Defaults", func(t *testing.T) {
+		statuses = nil
+		short := &structs.RssFeedItem{Id: "feed/2", Title: "Title", Description: "Short", Link: "https://example.com/2"}
+		require.NoError(t, mn.Notify(ctx, mn.NewRequest(structs.RssFeedNotification{Type: "mastodon"}, short)))
+		require.Len(t, statuses, 1)
+		require.Equal(t, "public", statuses[0]["visibility"])
+		require.NotContains(t, statuses[0], "spoiler_text")
+		require.NotContains(t, statuses[0], "language")
+		require.Equal(t, "Title\n\nShort\n\nhttps://example.com/2", statuses[0]["status"])
+	})
+
+	t.Run("InvalidVisibility", func(t *testing.T) {
+		statuses = nil
+		require.Error(t, mn.Notify(ctx, mn.NewRequest(structs.RssFeedNotification{Type: "mastodon", Visibility: "privte"}, item)))
+		require.Empty(t, statuses)
+	})
+}
+
+func Test_languageCode(t *testing.T) {
+	require.Equal(t, "en", languageCode("en"))
+	require.Equal(t, "en", languageCode("en-US"))
+	require.Equal(t, "pt", languageCode("pt_BR"))
+	require.Equal(t, "fi", languageCode("FI"))
+	require.Equal(t, "", languageCode(""))
+	require.Equal(t, "", languageCode("auto"))
+}
@@ -0,0 +1,271 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"broadcaster/utils/strutil"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type MatrixNotifierConfig struct {
+	Homeserver  string // Homeserver base URL, eg https://matrix.org
+	AccessToken string // Bot user access token
+	MaxRetries  int    // Maximum retries of rate limited requests (default 3)
+}
+
+// Sends messages to Matrix rooms with the Client-Server API.
+type MatrixNotifier struct {
+	cfg    *MatrixNotifierConfig
+	logger *zap.SugaredLogger
+	// Bot user id, resolved with the first notification
+	userId string
+	// Transactions counter, makes transactions ids unique within the process
+	txn atomic.Uint64
+	mu  *sync.Mutex
+	// Resolved rooms aliases. Alias -> room id
+	rooms map[string]string
+}
+
+// Delays limits of rate limited requests.
+const (
+	matrixDefaultRetryAfter = time.Second
+	matrixMaxRetryAfter     = time.Minute
+)
+
+func init() {
+	Register("matrix", Schema{
+		Fields: []Field{
+			{Name: "homeserver", Env: "MATRIX_HOMESERVER"},
+			{Name: "access_token", Env: "MATRIX_ACCESS_TOKEN", Secret: true, Enables: true},
+		},
+	}, func(s Settings, logger *zap.SugaredLogger) (Notifier, error) {
+		return NewMatrixNotifier(&MatrixNotifierConfig{
+			Homeserver:  s.Get("homeserver"),
+			AccessToken: s.Get("access_token"),
+		}, logger)
+	})
+}
+
+func NewMatrixNotifier(cfg *MatrixNotifierConfig, logger *zap.SugaredLogger) (*MatrixNotifier, error) {
+	if cfg.Homeserver == "" {
+		return nil, errors.New("Matrix homeserver URL is required")
+	}
+	if cfg.AccessToken == "" {
+		return nil, errors.New("Matrix access token is required")
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 3
+	}
+	cfg.Homeserver = strings.TrimRight(cfg.Homeserver, "/")
+
+	return &MatrixNotifier{
+		cfg:    cfg,
+		logger: logger,
+		mu:     &sync.Mutex{},
+		rooms:  make(map[string]string),
+	}, nil
+}
+
+// Checks the access token and resolves the bot user id once. It's done with notifications,
+// so unavailable homeservers don't fail startup and config reloads. Failed checks are retried.
+func (m *MatrixNotifier) checkUser(ctx context.Context) error {
+	m.mu.Lock()
+	checked := m.userId != ""
+	m.mu.Unlock()
+	if checked {
+		return nil
+	}
+
+	var whoami struct {
+		UserId string `json:"user_id"`
+	}
+	if err := m.do(ctx, http.MethodGet, "/account/whoami", nil, &whoami); err != nil {
+		return fmt.Errorf("Failed to check Matrix access token: %w", err)
+	}
+
+	m.mu.Lock()
+	m.userId = whoami.UserId
+	m.mu.Unlock()
+	m.logger.With("user_id", whoami.UserId).Debug("Matrix access token is checked")
+
+	return nil
+}
+
+// Implement the Notifier interface
+var _ Notifier = (*MatrixNotifier)(nil)
+
+// Matrix message content.
+type matrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format,omitempty"`
+	FormattedBody string `json:"formatted_body,omitempty"`
+}
+
+func (m *MatrixNotifier) Notify(ctx context.Context, r NotificationRequest) error {
+	msg, ok := r.Payload.(matrixMessage)
+	if !ok {
+		return fmt.Errorf("Unexpected Matrix payload type %T", r.Payload)
+	}
+	if err := m.checkUser(ctx); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, to := range r.To {
+		roomId, err := m.roomId(ctx, to)
+		if err != nil {
+			m.logger.With("err", err.Error()).Errorf("Failed to resolve Matrix room '%s'", to)
+			errs = append(errs, &DestinationError{To: to, Err: err})
+			continue
+		}
+
+		txnId := fmt.Sprintf("%d.%d", time.Now().UnixNano(), m.txn.Add(1))
+		path := "/rooms/" + url.PathEscape(roomId) + "/send/m.room.message/" + url.PathEscape(txnId)
+		if err := m.do(ctx, http.MethodPut, path, msg, nil); err != nil {
+			m.logger.With("err", err.Error()).Errorf("Failed to notify Matrix to '%s'", to)
+			errs = append(errs, &DestinationError{To: to, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Returns the room id of the destination: room id or room alias.
+func (m *MatrixNotifier) roomId(ctx context.Context, to string) (string, error) {
+	switch {
+	case strings.HasPrefix(to, "!"):
+		return to, nil
+	case strings.HasPrefix(to, "#"):
+	default:
+		return "", errors.New("Room id or alias expected")
+	}
+
+	m.mu.Lock()
+	roomId, exists := m.rooms[to]
+	m.mu.Unlock()
+	if exists {
+		return roomId, nil
+	}
+
+	var resp struct {
+		RoomId string `json:"room_id"`
+	}
+	if err := m.do(ctx, http.MethodGet, "/directory/room/"+url.PathEscape(to), nil, &resp); err != nil {
+		return "", err
+	}
+	if resp.RoomId == "" {
+		return "", errors.New("Empty room id in the directory response")
+	}
+
+	m.mu.Lock()
+	m.rooms[to] = resp.RoomId
+	m.mu.Unlock()
+
+	return resp.RoomId, nil
+}
+
+// Sends the Client-Server API request retrying rate limited requests.
+// Retries of the message sending are safe, as the same transaction id is used.
+func (m *MatrixNotifier) do(ctx context.Context, method, path string, body, result interface{}) error {
+	uri := m.cfg.Homeserver + "/_matrix/client/v3" + path
+	headers := map[string]string{"Authorization": "Bearer " + m.cfg.AccessToken}
+
+	for attempt := 0; ; attempt++ {
+		err := doJSON(ctx, method, uri, headers, body, result)
+
+		var herr *httpError
+		if !errors.As(err, &herr) || herr.Code != http.StatusTooManyRequests || attempt >= m.cfg.MaxRetries {
+			return err
+		}
+
+		delay := matrixRetryAfter(herr)
+		m.logger.With("delay", delay.String()).Debug("Rate limited by Matrix homeserver, retrying")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Returns the delay requested by the rate limit response.
+func matrixRetryAfter(herr *httpError) time.Duration {
+	delay := herr.RetryAfter
+
+	var resp struct {
+		RetryAfterMs int64 `json:"retry_after_ms"`
+	}
+	if err := json.Unmarshal([]byte(herr.Body), &resp); err == nil && resp.RetryAfterMs > 0 {
+		delay = time.Duration(resp.RetryAfterMs) * time.Millisecond
+	}
+
+	if delay <= 0 {
+		return matrixDefaultRetryAfter
+	}
+	if delay > matrixMaxRetryAfter {
+		return matrixMaxRetryAfter
+	}
+	return delay
+}
+
+// Maximum description length. Events are limited by 64 KiB including the HTML body.
+const matrixDescriptionLimit = 4000
+
+func (m *MatrixNotifier) NewRequest(fn structs.RssFeedNotification, item *structs.RssFeedItem) NotificationRequest {
+	title := strings.TrimSpace(html.UnescapeString(item.Title))
+	description := truncate(plainText(item.Description), matrixDescriptionLimit)
+	msg := newMatrixMessage(title, description, item.Source, item.Link)
+
+	return NotificationRequest{
+		To:      fn.To,
+		Source:  item.Source,
+		Image:   item.Image,
+		Message: msg.Body,
+		Payload: msg,
+	}
+}
+
+// Renders the message with plain text and HTML bodies.
+func newMatrixMessage(title, description, source, link string) matrixMessage {
+	var plain, formatted []string
+
+	if title != "" {
+		plain = append(plain, title)
+		formatted = append(formatted, "<p><strong>"+html.EscapeString(title)+"</strong></p>")
+	}
+	if description != "" {
+		plain = append(plain, description)
+		paragraphs := strings.Split(description, "\n\n")
+		for i, p := range paragraphs {
+			paragraphs[i] = strings.ReplaceAll(html.EscapeString(p), "\n", "<br>")
+		}
+		formatted = append(formatted, "<p>"+strings.Join(paragraphs, "</p><p>")+"</p>")
+	}
+	if link != "" {
+		plain = append(plain, link)
+		formatted = append(formatted, `<p><a href="`+html.EscapeString(link)+`">`+html.EscapeString(strutil.Coalesce(source, link))+"</a></p>")
+	} else if source != "" {
+		plain = append(plain, source)
+		formatted = append(formatted, "<p>"+html.EscapeString(source)+"</p>")
+	}
+
+	// Bots messages are notices, so other bots don't react to them
+	return matrixMessage{
+		MsgType:       "m.notice",
+		Body:          strings.Join(plain, "\n\n"),
+		Format:        "org.matrix.custom.html",
+		FormattedBody: strings.Join(formatted, ""),
+	}
+}
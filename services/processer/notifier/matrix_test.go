@@ -0,0 +1,156 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// Local stand-in for Matrix homeserver. Rate limits the first message of each room.
+type fakeHomeserver struct {
+	srv *httptest.Server
+	mu  sync.Mutex
+	// Received events by room id
+	events map[string][]matrixMessage
+	// Transactions ids by room id
+	txns map[string][]string
+	// Directory requests count
+	lookups int
+}
+
+func newFakeHomeserver(t *testing.T) *fakeHomeserver {
+	f := &fakeHomeserver{
+		events: make(map[string][]matrixMessage),
+		txns:   make(map[string][]string),
+	}
+	limited := make(map[string]bool)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_matrix/client/v3/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"errcode":"M_UNKNOWN_TOKEN","error":"Invalid access token"}`)
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.EscapedPath(), "/_matrix/client/v3")
+		parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		switch {
+		case path == "/account/whoami":
+			fmt.Fprint(w, `{"user_id":"@bot:example.org"}`)
+
+		case len(parts) == 3 && parts[0] == "directory" && parts[1] == "room":
+			f.lookups++
+			if parts[2] == "%23news:example.org" {
+				fmt.Fprint(w, `{"room_id":"!news:example.org"}`)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errcode":"M_NOT_FOUND","error":"Room alias not found"}`)
+
+		case len(parts) == 5 && parts[0] == "rooms" && r.Method == http.MethodPut:
+			room := strings.ReplaceAll(parts[1], "%21", "!")
+			txn := parts[4]
+			if !limited[room] {
+				limited[room] = true
+				w.WriteHeader(http.StatusTooManyRequests)
+				fmt.Fprint(w, `{"errcode":"M_LIMIT_EXCEEDED","error":"Too many requests","retry_after_ms":10}`)
+				f.txns[room] = append(f.txns[room], txn)
+				return
+			}
+			var msg matrixMessage
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+			f.events[room] = append(f.events[room], msg)
+			f.txns[room] = append(f.txns[room], txn)
+			fmt.Fprint(w, `{"event_id":"$1"}`)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errcode":"M_UNRECOGNIZED"}`)
+		}
+	})
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func Test_MatrixNotifier(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeHomeserver(t)
+
+	item := &structs.RssFeedItem{
+		Source:      "Dummy",
+		Title:       "Title &amp; <symbols>",
+		Description: "<p>First paragraph</p>\n\n<p>Second <b>one</b></p>",
+		Link:        "https://example.com/news/1?a=1&b=2",
+	}
+	nfn := structs.RssFeedNotification{
+		Type: "matrix",
+		To:   []string{"!room:example.org", "#news:example.org", "#missing:example.org", "plain"},
+	}
+
+	// Unavailable homeserver doesn't fail the notifier creation
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	_, err := NewMatrixNotifier(&MatrixNotifierConfig{Homeserver: down.URL, AccessToken: "test-token"}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	// Access token is checked with notifications
+	invalid, err := NewMatrixNotifier(&MatrixNotifierConfig{Homeserver: fake.srv.URL, AccessToken: "invalid"}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	err = invalid.Notify(ctx, invalid.NewRequest(nfn, item))
+	require.ErrorContains(t, err, "M_UNKNOWN_TOKEN")
+	_, ok := FailedDestinations(err)
+	require.False(t, ok, "All destinations fail")
+	require.Empty(t, fake.events)
+
+	mn, err := NewMatrixNotifier(&MatrixNotifierConfig{Homeserver: fake.srv.URL + "/", AccessToken: "test-token"}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	req := mn.NewRequest(nfn, item)
+	requireFailedDestinations(t, mn.Notify(ctx, req), "#missing:example.org", "plain")
+	require.Equal(t, "@bot:example.org", mn.userId)
+	// Aliases are resolved once
+	requireFailedDestinations(t, mn.Notify(ctx, req), "#missing:example.org", "plain")
+	require.Equal(t, 3, fake.lookups)
+
+	for _, room := range []string{"!room:example.org", "!news:example.org"} {
+		require.Len(t, fake.events[room], 2, room)
+		// Rate limited request is retried with the same transaction id
+		require.Len(t, fake.txns[room], 3)
+		require.Equal(t, fake.txns[room][0], fake.txns[room][1])
+		require.NotEqual(t, fake.txns[room][1], fake.txns[room][2])
+	}
+
+	msg := fake.events["!room:example.org"][0]
+	require.Equal(t, "m.notice", msg.MsgType)
+	require.Equal(t, "org.matrix.custom.html", msg.Format)
+	require.Equal(t, "Title & <symbols>\n\nFirst paragraph\n\nSecond one\n\nhttps://example.com/news/1?a=1&b=2", msg.Body)
+	require.Equal(t,
+		"<p><strong>Title &amp; &lt;symbols&gt;</strong></p>"+
+			"<p>First paragraph</p><p>Second one</p>"+
+			`<p><a href="https://example.com/news/1?a=1&amp;b=2">Dummy</a></p>`,
+		msg.FormattedBody,
+	)
+}
+
+func Test_matrixRetryAfter(t *testing.T) {
+	require.Equal(t, matrixDefaultRetryAfter, matrixRetryAfter(&httpError{Code: 429}))
+	require.Equal(t, 1500*time.Millisecond, matrixRetryAfter(&httpError{Code: 429, Body: `{"retry_after_ms":1500}`}))
+	require.Equal(t, matrixMaxRetryAfter, matrixRetryAfter(&httpError{Code: 429, Body: `{"retry_after_ms":3600000}`}))
+	require.Equal(t, 2*matrixDefaultRetryAfter, matrixRetryAfter(&httpError{Code: 429, RetryAfter: 2 * matrixDefaultRetryAfter}))
+}
@@ -0,0 +1,177 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+type MattermostNotifierConfig struct {
+	URL        string // Server URL, required for the API posting
+	Token      string // Bot access token
+	WebhookURL string // Incoming webhook URL, used instead of the API if specified
+}
+
+// Posts messages to Mattermost with the API or incoming webhooks.
+type MattermostNotifier struct {
+	cfg    *MattermostNotifierConfig
+	logger *zap.SugaredLogger
+	mu     *sync.Mutex
+	// Resolved channels ids. team/channel -> channel id
+	channels map[string]string
+}
+
+func init() {
+	Register("mattermost", Schema{
+		Fields: []Field{
+			{Name: "url", Env: "MATTERMOST_URL"},
+			{Name: "token", Env: "MATTERMOST_TOKEN", Secret: true, Enables: true},
+			{Name: "webhook_url", Env: "MATTERMOST_WEBHOOK_URL", Secret: true, Enables: true},
+		},
+	}, func(s Settings, logger *zap.SugaredLogger) (Notifier, error) {
+		return NewMattermostNotifier(&MattermostNotifierConfig{
+			URL:        s.Get("url"),
+			Token:      s.Get("token"),
+			WebhookURL: s.Get("webhook_url"),
+		}, logger)
+	})
+}
+
+func NewMattermostNotifier(cfg *MattermostNotifierConfig, logger *zap.SugaredLogger) (*MattermostNotifier, error) {
+	if cfg.WebhookURL == "" {
+		if cfg.Token == "" {
+			return nil, errors.New("Mattermost token or webhook URL is required")
+		}
+		if cfg.URL == "" {
+			return nil, errors.New("Mattermost server URL is required")
+		}
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+
+	m := &MattermostNotifier{
+		cfg:      cfg,
+		logger:   logger,
+		mu:       &sync.Mutex{},
+		channels: make(map[string]string),
+	}
+	return m, nil
+}
+
+// Implement the Notifier interface
+var _ Notifier = (*MattermostNotifier)(nil)
+
+func (m *MattermostNotifier) Notify(ctx context.Context, r NotificationRequest) error {
+	attachment, ok := r.Payload.(chatAttachment)
+	if !ok {
+		return fmt.Errorf("Unexpected Mattermost payload type %T", r.Payload)
+	}
+
+	if r.Image != "" && imageAvailable(ctx, r.Image) {
+		attachment = attachment.withImage(r.Image)
+	}
+
+	if m.cfg.WebhookURL != "" && len(r.To) == 0 {
+		// Posting to the webhook default channel
+		if err := m.notifyWebhook(ctx, "", attachment); err != nil {
+			return fmt.Errorf("Failed to notify Mattermost webhook: %w", err)
+		}
+		return nil
+	}
+
+	var errs []error
+	for _, to := range r.To {
+		var err error
+		if m.cfg.WebhookURL != "" {
+			err = m.notifyWebhook(ctx, to, attachment)
+		} else {
+			err = m.notifyAPI(ctx, to, attachment)
+		}
+		if err != nil {
+			m.logger.With("err", err.Error()).Errorf("Failed to notify Mattermost to '%s'", to)
+			errs = append(errs, &DestinationError{To: to, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Posts the message to the webhook. Channel overrides the webhook default channel if specified.
+func (m *MattermostNotifier) notifyWebhook(ctx context.Context, channel string, attachment chatAttachment) error {
+	msg := map[string]interface{}{
+		"attachments": []chatAttachment{attachment},
+	}
+	if channel != "" {
+		msg["channel"] = channel
+	}
+	return doJSON(ctx, http.MethodPost, m.cfg.WebhookURL, nil, msg, nil)
+}
+
+// Posts the message with the API to the channel: channel id or 'team/channel' names.
+func (m *MattermostNotifier) notifyAPI(ctx context.Context, to string, attachment chatAttachment) error {
+	channelId, err := m.channelId(ctx, to)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve channel: %w", err)
+	}
+
+	post := map[string]interface{}{
+		"channel_id": channelId,
+		"props": map[string]interface{}{
+			"attachments": []chatAttachment{attachment},
+		},
+	}
+	return m.do(ctx, http.MethodPost, "/posts", post, nil)
+}
+
+// Returns the channel id of the destination.
+func (m *MattermostNotifier) channelId(ctx context.Context, to string) (string, error) {
+	team, channel, found := strings.Cut(to, "/")
+	if !found {
+		return to, nil
+	}
+
+	m.mu.Lock()
+	id, exists := m.channels[to]
+	m.mu.Unlock()
+	if exists {
+		return id, nil
+	}
+
+	var resp struct {
+		Id string `json:"id"`
+	}
+	path := "/teams/name/" + url.PathEscape(team) + "/channels/name/" + url.PathEscape(strings.TrimPrefix(channel, "~"))
+	if err := m.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return "", err
+	}
+	if resp.Id == "" {
+		return "", errors.New("Empty channel id in the response")
+	}
+
+	m.mu.Lock()
+	m.channels[to] = resp.Id
+	m.mu.Unlock()
+
+	return resp.Id, nil
+}
+
+func (m *MattermostNotifier) do(ctx context.Context, method, path string, body, result interface{}) error {
+	headers := map[string]string{"Authorization": "Bearer " + m.cfg.Token}
+	return doJSON(ctx, method, m.cfg.URL+"/api/v4"+path, headers, body, result)
+}
+
+func (m *MattermostNotifier) NewRequest(fn structs.RssFeedNotification, item *structs.RssFeedItem) NotificationRequest {
+	attachment := newChatAttachment(item)
+	return NotificationRequest{
+		To:      fn.To,
+		Source:  item.Source,
+		Image:   item.Image,
+		Message: attachment.Fallback,
+		Payload: attachment,
+	}
+}
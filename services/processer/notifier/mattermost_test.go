@@ -0,0 +1,123 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// Local stand-in for Mattermost API and incoming webhooks. Stores received posts.
+type fakeMattermost struct {
+	srv *httptest.Server
+	mu  sync.Mutex
+	// Received posts: channel and the first attachment
+	posts []map[string]interface{}
+}
+
+func newFakeMattermost(t *testing.T) *fakeMattermost {
+	f := &fakeMattermost{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/teams/name/team/channels/name/town-square", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"id":"channel-id-2"}`)
+	})
+	mux.HandleFunc("/api/v4/posts", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		var post struct {
+			ChannelId string `json:"channel_id"`
+			Props     struct {
+				Attachments []map[string]interface{} `json:"attachments"`
+			} `json:"props"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&post))
+		require.Len(t, post.Props.Attachments, 1)
+		f.store(post.ChannelId, post.Props.Attachments[0])
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id":"post-id"}`)
+	})
+	mux.HandleFunc("/hooks/key", func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			Channel     string                   `json:"channel"`
+			Attachments []map[string]interface{} `json:"attachments"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		require.Len(t, msg.Attachments, 1)
+		f.store(msg.Channel, msg.Attachments[0])
+		fmt.Fprint(w, "ok")
+	})
+	mux.HandleFunc("/image.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+	})
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeMattermost) store(channel string, attachment map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	attachment["channel"] = channel
+	f.posts = append(f.posts, attachment)
+}
+
+func Test_MattermostNotifier(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeMattermost(t)
+
+	item := &structs.RssFeedItem{
+		Source:      "Dummy",
+		Title:       "Title &amp; symbols",
+		Description: "<p>Description with <b>HTML</b></p>",
+		Link:        "https://example.com/news/1",
+		Image:       fake.srv.URL + "/image.jpg",
+	}
+
+	_, err := NewMattermostNotifier(&MattermostNotifierConfig{Token: "test-token"}, zap.NewNop().Sugar())
+	require.Error(t, err)
+
+	t.Run("api", func(t *testing.T) {
+		fake.posts = nil
+		mn, err := NewMattermostNotifier(&MattermostNotifierConfig{URL: fake.srv.URL + "/", Token: "test-token"}, zap.NewNop().Sugar())
+		require.NoError(t, err)
+
+		nfn := structs.RssFeedNotification{Type: "mattermost", To: []string{"channel-id-1", "team/town-square", "team/missing"}}
+		req := mn.NewRequest(nfn, item)
+		require.Equal(t, "Title & symbols", req.Message)
+		requireFailedDestinations(t, mn.Notify(ctx, req), "team/missing")
+
+		require.Len(t, fake.posts, 2)
+		require.Equal(t, "channel-id-1", fake.posts[0]["channel"])
+		require.Equal(t, "channel-id-2", fake.posts[1]["channel"])
+
+		post := fake.posts[0]
+		require.Equal(t, "Dummy", post["author_name"])
+		require.Equal(t, "Title & symbols", post["title"])
+		require.Equal(t, item.Link, post["title_link"])
+		require.Equal(t, "Description with HTML", post["text"])
+		require.Equal(t, item.Image, post["image_url"])
+	})
+
+	t.Run("webhook", func(t *testing.T) {
+		fake.posts = nil
+		mn, err := NewMattermostNotifier(&MattermostNotifierConfig{WebhookURL: fake.srv.URL + "/hooks/key"}, zap.NewNop().Sugar())
+		require.NoError(t, err)
+
+		noImage := *item
+		noImage.Image = ""
+		require.NoError(t, mn.Notify(ctx, mn.NewRequest(structs.RssFeedNotification{Type: "mattermost"}, &noImage)))
+		require.NoError(t, mn.Notify(ctx, mn.NewRequest(structs.RssFeedNotification{Type: "mattermost", To: []string{"news"}}, &noImage)))
+
+		require.Len(t, fake.posts, 2)
+		require.Equal(t, "", fake.posts[0]["channel"])
+		require.Equal(t, "news", fake.posts[1]["channel"])
+		require.NotContains(t, fake.posts[0], "image_url")
+	})
+}
@@ -0,0 +1,49 @@
+package notifier
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTP client to check media files availability.
+var mediaHttpCl = &http.Client{Timeout: 10 * time.Second}
+
+// Checks whether the media URL is reachable and points to an image.
+// Used to fallback to text-only notifications before sending broken media.
+func imageAvailable(ctx context.Context, uri string) bool {
+	if !strings.HasPrefix(uri, "http://") && !strings.HasPrefix(uri, "https://") {
+		return false
+	}
+
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, uri, nil)
+		if err != nil {
+			return false
+		}
+		if method == http.MethodGet {
+			// Some servers don't support HEAD requests, downloading only first bytes
+			req.Header.Set("Range", "bytes=0-0")
+		}
+
+		resp, err := mediaHttpCl.Do(req)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented {
+			continue
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return false
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		return mediaType == "" || strings.HasPrefix(mediaType, "image/")
+	}
+
+	return false
+}
@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_imageAvailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+		case "/nohead.png":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Content-Type", "image/png")
+			w.WriteHeader(http.StatusPartialContent)
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+
+	require.True(t, imageAvailable(ctx, srv.URL+"/image.jpg"))
+	require.True(t, imageAvailable(ctx, srv.URL+"/nohead.png"), "GET should be used when HEAD isn't allowed")
+	require.False(t, imageAvailable(ctx, srv.URL+"/page.html"))
+	require.False(t, imageAvailable(ctx, srv.URL+"/missing.jpg"))
+	require.False(t, imageAvailable(ctx, "ftp://example.com/image.jpg"))
+	require.False(t, imageAvailable(ctx, ""))
+}
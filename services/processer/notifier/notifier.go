@@ -3,6 +3,11 @@ package notifier
 import (
 	"broadcaster/structs"
 	"context"
+	"errors"
+	"html"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
 )
 
 type Notifier interface {
@@ -14,4 +19,59 @@ type NotificationRequest struct {
 	Source  string
 	To      []string
 	Message string
+	Image   string // Optional image URL
+	// Notifier specific message content prepared by the notifier NewRequest
+	Payload interface{}
+}
+
+// Failed delivery to one of the request destinations.
+// Notifiers join these errors, so only failed destinations are retried by other feeds.
+type DestinationError struct {
+	To  string
+	Err error
+}
+
+// Doesn't include the destination, as some of them are secrets (eg webhooks URLs).
+func (e *DestinationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DestinationError) Unwrap() error {
+	return e.Err
+}
+
+// Returns destinations failed by the Notify error.
+// Returns false if the whole request failed or the error isn't a notify error.
+func FailedDestinations(err error) ([]string, bool) {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	var result []string
+	for _, err := range errs {
+		var derr *DestinationError
+		if !errors.As(err, &derr) {
+			return nil, false
+		}
+		result = append(result, derr.To)
+	}
+	return result, len(result) > 0
+}
+
+// Truncates the text to the limit of characters adding ellipsis to the truncated text.
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	if limit <= 1 {
+		return string(runes[:limit])
+	}
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
+
+// Returns plain text from HTML text.
+func plainText(text string) string {
+	return strings.TrimSpace(html.UnescapeString(bluemonday.StrictPolicy().Sanitize(text)))
 }
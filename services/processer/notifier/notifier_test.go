@@ -0,0 +1,36 @@
+package notifier
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FailedDestinations(t *testing.T) {
+	failed, ok := FailedDestinations(errors.Join(
+		&DestinationError{To: "#news", Err: errors.New("Not found")},
+		fmt.Errorf("Wrapped: %w", &DestinationError{To: "#sports", Err: errors.New("Throttled")}),
+	))
+	require.True(t, ok)
+	require.Equal(t, []string{"#news", "#sports"}, failed)
+
+	failed, ok = FailedDestinations(&DestinationError{To: "#news", Err: errors.New("Not found")})
+	require.True(t, ok)
+	require.Equal(t, []string{"#news"}, failed)
+
+	_, ok = FailedDestinations(errors.Join(&DestinationError{To: "#news", Err: errors.New("Not found")}, errors.New("Unavailable")))
+	require.False(t, ok, "Whole request failed")
+
+	_, ok = FailedDestinations(errors.New("Unavailable"))
+	require.False(t, ok)
+}
+
+// Requires the Notify error to fail exactly the destinations.
+func requireFailedDestinations(t *testing.T, err error, expected ...string) {
+	t.Helper()
+	failed, ok := FailedDestinations(err)
+	require.True(t, ok, err)
+	require.Equal(t, expected, failed)
+}
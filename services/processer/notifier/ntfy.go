@@ -0,0 +1,156 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"broadcaster/utils/strutil"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Public ntfy server.
+const NtfyDefaultURL = "https://ntfy.sh"
+
+type NtfyNotifierConfig struct {
+	URL   string // Server URL (default https://ntfy.sh)
+	Token string // Optional access token
+	Topic string // Optional topic of notifications without destinations
+}
+
+// Publishes messages to ntfy topics.
+type NtfyNotifier struct {
+	cfg    *NtfyNotifierConfig
+	logger *zap.SugaredLogger
+}
+
+func init() {
+	Register("ntfy", Schema{
+		Fields: []Field{
+			{Name: "url", Env: "NTFY_URL", Default: NtfyDefaultURL},
+			{Name: "token", Env: "NTFY_TOKEN", Secret: true, Enables: true},
+			{Name: "topic", Env: "NTFY_TOPIC", Enables: true},
+		},
+	}, func(s Settings, logger *zap.SugaredLogger) (Notifier, error) {
+		return NewNtfyNotifier(&NtfyNotifierConfig{
+			URL:   s.Get("url"),
+			Token: s.Get("token"),
+			Topic: s.Get("topic"),
+		}, logger)
+	})
+}
+
+func NewNtfyNotifier(cfg *NtfyNotifierConfig, logger *zap.SugaredLogger) (*NtfyNotifier, error) {
+	if cfg.URL == "" {
+		cfg.URL = NtfyDefaultURL
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+
+	n := &NtfyNotifier{
+		cfg:    cfg,
+		logger: logger,
+	}
+	return n, nil
+}
+
+// Implement the Notifier interface
+var _ Notifier = (*NtfyNotifier)(nil)
+
+// ntfy priorities by the priority level.
+var ntfyPriorities = []int{1, 2, 3, 4, 5}
+
+// Message size limit. Larger messages are converted to attachments by ntfy.
+const ntfyMessageLimit = 4000
+
+// ntfy JSON message.
+type ntfyMessage struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title,omitempty"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Click    string   `json:"click,omitempty"`
+	Attach   string   `json:"attach,omitempty"`
+}
+
+func (n *NtfyNotifier) Notify(ctx context.Context, r NotificationRequest) error {
+	msg, ok := r.Payload.(ntfyMessage)
+	if !ok {
+		return fmt.Errorf("Unexpected ntfy payload type %T", r.Payload)
+	}
+
+	if r.Image != "" && imageAvailable(ctx, r.Image) {
+		msg.Attach = r.Image
+	}
+
+	destinations := r.To
+	if len(destinations) == 0 && n.cfg.Topic != "" {
+		destinations = []string{n.cfg.Topic}
+	}
+
+	var errs []error
+	for _, to := range destinations {
+		server, topic, err := n.topic(to)
+		if err != nil {
+			n.logger.With("err", err.Error()).Errorf("Invalid ntfy topic '%s'", to)
+			errs = append(errs, &DestinationError{To: to, Err: err})
+			continue
+		}
+
+		var headers map[string]string
+		if n.cfg.Token != "" && server == n.cfg.URL {
+			// Token is never sent to other servers
+			headers = map[string]string{"Authorization": "Bearer " + n.cfg.Token}
+		}
+
+		msg.Topic = topic
+		if err := doJSON(ctx, http.MethodPost, server, headers, msg, nil); err != nil {
+			n.logger.With("err", err.Error()).Errorf("Failed to notify ntfy to '%s'", to)
+			errs = append(errs, &DestinationError{To: to, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Returns the server URL and the topic name of the destination: topic name or topic URL.
+func (n *NtfyNotifier) topic(to string) (string, string, error) {
+	if !strings.HasPrefix(to, "http://") && !strings.HasPrefix(to, "https://") {
+		if to == "" || strings.Contains(to, "/") {
+			return "", "", fmt.Errorf("Invalid topic name")
+		}
+		return n.cfg.URL, to, nil
+	}
+
+	u, err := url.Parse(to)
+	if err != nil {
+		return "", "", fmt.Errorf("Failed to parse topic URL: %w", err)
+	}
+	topic := strings.Trim(u.Path, "/")
+	if topic == "" || strings.Contains(topic, "/") {
+		return "", "", fmt.Errorf("Invalid topic URL")
+	}
+	return u.Scheme + "://" + u.Host, topic, nil
+}
+
+func (n *NtfyNotifier) NewRequest(fn structs.RssFeedNotification, item *structs.RssFeedItem) NotificationRequest {
+	title := strings.TrimSpace(html.UnescapeString(item.Title))
+	msg := ntfyMessage{
+		Title:    strutil.Coalesce(title, item.Source),
+		Message:  truncate(strutil.Coalesce(plainText(item.Description), title, item.Link), ntfyMessageLimit),
+		Priority: ntfyPriorities[fn.Priority.Level()],
+		Tags:     fn.Tags,
+		Click:    item.Link,
+	}
+	return NotificationRequest{
+		To:      fn.To,
+		Source:  item.Source,
+		Image:   item.Image,
+		Message: msg.Message,
+		Payload: msg,
+	}
+}
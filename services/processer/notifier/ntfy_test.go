@@ -0,0 +1,117 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// Local stand-in for ntfy server. Stores received messages with authorization headers.
+type fakeNtfy struct {
+	srv      *httptest.Server
+	mu       sync.Mutex
+	messages []ntfyMessage
+	auth     []string
+}
+
+func newFakeNtfy(t *testing.T) *fakeNtfy {
+	f := &fakeNtfy{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/", r.URL.Path)
+		var msg ntfyMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		f.mu.Lock()
+		f.messages = append(f.messages, msg)
+		f.auth = append(f.auth, r.Header.Get("Authorization"))
+		f.mu.Unlock()
+		w.Write([]byte(`{"id":"1"}`))
+	})
+	mux.HandleFunc("/image.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+	})
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func Test_NtfyNotifier(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeNtfy(t)
+	other := newFakeNtfy(t)
+
+	nn, err := NewNtfyNotifier(&NtfyNotifierConfig{URL: fake.srv.URL + "/", Token: "tk_test"}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	item := &structs.RssFeedItem{
+		Source:      "Dummy",
+		Title:       "Title &amp; symbols",
+		Description: "<p>Description</p>",
+		Link:        "https://example.com/news/1",
+		Image:       fake.srv.URL + "/image.jpg",
+	}
+	nfn := structs.RssFeedNotification{
+		Type:     "ntfy",
+		To:       []string{"alerts", other.srv.URL + "/news", "bad/topic"},
+		Priority: structs.NotificationPriorityHigh,
+		Tags:     []string{"warning", "newspaper"},
+	}
+
+	requireFailedDestinations(t, nn.Notify(ctx, nn.NewRequest(nfn, item)), "bad/topic")
+
+	require.Len(t, fake.messages, 1)
+	require.Equal(t, ntfyMessage{
+		Topic:    "alerts",
+		Title:    "Title & symbols",
+		Message:  "Description",
+		Priority: 4,
+		Tags:     []string{"warning", "newspaper"},
+		Click:    item.Link,
+		Attach:   item.Image,
+	}, fake.messages[0])
+	require.Equal(t, "Bearer tk_test", fake.auth[0])
+
+	// Token is not sent to other servers
+	require.Len(t, other.messages, 1)
+	require.Equal(t, "news", other.messages[0].Topic)
+	require.Empty(t, other.auth[0])
+
+	t.Run("DefaultPriority", func(t *testing.T) {
+		req := nn.NewRequest(structs.RssFeedNotification{Type: "ntfy"}, item)
+		require.Equal(t, 3, req.Payload.(ntfyMessage).Priority)
+
+		req = nn.NewRequest(structs.RssFeedNotification{Type: "ntfy", Priority: "unknown"}, item)
+		require.Equal(t, 3, req.Payload.(ntfyMessage).Priority)
+
+		req = nn.NewRequest(structs.RssFeedNotification{Type: "ntfy", Priority: structs.NotificationPriorityMin}, item)
+		require.Equal(t, 1, req.Payload.(ntfyMessage).Priority)
+	})
+}
+
+func Test_NtfyNotifier_fromEnv(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeNtfy(t)
+	logger := zap.NewNop().Sugar()
+
+	t.Setenv("BCTR_NTFY_URL", fake.srv.URL)
+	n, err := NewFromEnv("ntfy", logger)
+	require.NoError(t, err)
+	require.Nil(t, n, "Server URL alone doesn't enable the default instance")
+
+	t.Setenv("BCTR_NTFY_TOPIC", "alerts")
+	n, err = NewFromEnv("ntfy", logger)
+	require.NoError(t, err)
+	require.NotNil(t, n)
+
+	item := &structs.RssFeedItem{Title: "Title", Link: "https://example.com/news/1"}
+	require.NoError(t, n.Notify(ctx, n.NewRequest(structs.RssFeedNotification{Type: "ntfy"}, item)))
+	require.Len(t, fake.messages, 1)
+	require.Equal(t, "alerts", fake.messages[0].Topic, "Default topic is used without destinations")
+}
@@ -0,0 +1,173 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"broadcaster/utils/strutil"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Pushover messages API.
+const PushoverDefaultAPIURL = "https://api.pushover.net/1/messages.json"
+
+type PushoverNotifierConfig struct {
+	AppToken string // Application API token
+	UserKey  string // Default user or group key, used for notifications without destinations
+	APIURL   string // Optional custom API URL (eg for testing)
+}
+
+// Sends push notifications with Pushover.
+type PushoverNotifier struct {
+	cfg    *PushoverNotifierConfig
+	logger *zap.SugaredLogger
+}
+
+func init() {
+	Register("pushover", Schema{
+		Fields: []Field{
+			{Name: "app_token", Env: "PUSHOVER_APP_TOKEN", Secret: true, Enables: true},
+			{Name: "user_key", Env: "PUSHOVER_USER_KEY", Secret: true},
+		},
+	}, func(s Settings, logger *zap.SugaredLogger) (Notifier, error) {
+		return NewPushoverNotifier(&PushoverNotifierConfig{
+			AppToken: s.Get("app_token"),
+			UserKey:  s.Get("user_key"),
+		}, logger)
+	})
+}
+
+func NewPushoverNotifier(cfg *PushoverNotifierConfig, logger *zap.SugaredLogger) (*PushoverNotifier, error) {
+	if cfg.AppToken == "" {
+		return nil, errors.New("Pushover application token is required")
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = PushoverDefaultAPIURL
+	}
+
+	p := &PushoverNotifier{
+		cfg:    cfg,
+		logger: logger,
+	}
+	return p, nil
+}
+
+// Implement the Notifier interface
+var _ Notifier = (*PushoverNotifier)(nil)
+
+// Pushover priorities by the priority level.
+var pushoverPriorities = []int{-2, -1, 0, 1, 2}
+
+// Pushover limits.
+const (
+	pushoverTitleLimit    = 250
+	pushoverMessageLimit  = 1024
+	pushoverURLTitleLimit = 100
+	// Emergency priority messages are repeated until acknowledged
+	pushoverEmergencyRetry  = 60   // seconds
+	pushoverEmergencyExpire = 3600 // seconds
+)
+
+// Pushover message.
+type pushoverMessage struct {
+	Title    string
+	Message  string
+	URL      string
+	URLTitle string
+	Priority int
+}
+
+// Returns the message form values for the user.
+func (m pushoverMessage) values(token, user string) url.Values {
+	v := url.Values{}
+	v.Set("token", token)
+	v.Set("user", user)
+	v.Set("message", m.Message)
+	v.Set("priority", strconv.Itoa(m.Priority))
+	if m.Title != "" {
+		v.Set("title", m.Title)
+	}
+	if m.URL != "" {
+		v.Set("url", m.URL)
+		v.Set("url_title", m.URLTitle)
+	}
+	if m.Priority == 2 {
+		v.Set("retry", strconv.Itoa(pushoverEmergencyRetry))
+		v.Set("expire", strconv.Itoa(pushoverEmergencyExpire))
+	}
+	return v
+}
+
+// Pushover API response.
+type pushoverResponse struct {
+	Status int      `json:"status"`
+	Errors []string `json:"errors"`
+}
+
+func (p *PushoverNotifier) Notify(ctx context.Context, r NotificationRequest) error {
+	msg, ok := r.Payload.(pushoverMessage)
+	if !ok {
+		return fmt.Errorf("Unexpected Pushover payload type %T", r.Payload)
+	}
+
+	// Destinations are users or groups keys
+	users := r.To
+	if len(users) == 0 && p.cfg.UserKey != "" {
+		users = []string{p.cfg.UserKey}
+	}
+	if len(users) == 0 {
+		return errors.New("Pushover user key is not specified")
+	}
+
+	var errs []error
+	for i, user := range users {
+		if err := p.notify(ctx, msg.values(p.cfg.AppToken, user)); err != nil {
+			// Keys are secrets, logging only the position
+			p.logger.With("err", err.Error()).Errorf("Failed to notify Pushover user #%d", i+1)
+			errs = append(errs, &DestinationError{To: user, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *PushoverNotifier) notify(ctx context.Context, values url.Values) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.APIURL, strings.NewReader(values.Encode()))
+	if err != nil {
+		return fmt.Errorf("Failed to create new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var resp pushoverResponse
+	if err := doRequest(req, &resp); err != nil {
+		return err
+	}
+	if resp.Status != 1 {
+		return fmt.Errorf("Unsuccessful response: %s", strings.Join(resp.Errors, "; "))
+	}
+	return nil
+}
+
+func (p *PushoverNotifier) NewRequest(fn structs.RssFeedNotification, item *structs.RssFeedItem) NotificationRequest {
+	title := strings.TrimSpace(html.UnescapeString(item.Title))
+	msg := pushoverMessage{
+		Title:    truncate(strutil.Coalesce(title, item.Source), pushoverTitleLimit),
+		Message:  truncate(strutil.Coalesce(plainText(item.Description), title, item.Link), pushoverMessageLimit),
+		URL:      item.Link,
+		URLTitle: truncate(strutil.Coalesce(item.Source, "Open"), pushoverURLTitleLimit),
+		Priority: pushoverPriorities[fn.Priority.Level()],
+	}
+	return NotificationRequest{
+		To:      fn.To,
+		Source:  item.Source,
+		Image:   item.Image,
+		Message: msg.Message,
+		Payload: msg,
+	}
+}
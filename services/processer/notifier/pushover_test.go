@@ -0,0 +1,66 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_PushoverNotifier(t *testing.T) {
+	ctx := context.Background()
+
+	var received []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.Form.Get("user") == "invalid" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"user":"invalid","errors":["user identifier is invalid"],"status":0}`))
+			return
+		}
+		received = append(received, r.Form)
+		w.Write([]byte(`{"status":1,"request":"1"}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewPushoverNotifier(&PushoverNotifierConfig{UserKey: "user"}, zap.NewNop().Sugar())
+	require.Error(t, err)
+
+	pn, err := NewPushoverNotifier(&PushoverNotifierConfig{AppToken: "app", UserKey: "user", APIURL: srv.URL}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	item := &structs.RssFeedItem{
+		Source:      "Dummy",
+		Title:       "Title",
+		Description: "<p>Description</p>",
+		Link:        "https://example.com/news/1",
+	}
+
+	require.NoError(t, pn.Notify(ctx, pn.NewRequest(structs.RssFeedNotification{Type: "pushover"}, item)))
+	nfn := structs.RssFeedNotification{Type: "pushover", To: []string{"group", "invalid"}, Priority: structs.NotificationPriorityMax}
+	requireFailedDestinations(t, pn.Notify(ctx, pn.NewRequest(nfn, item)), "invalid")
+
+	require.Len(t, received, 2)
+
+	msg := received[0]
+	require.Equal(t, "app", msg.Get("token"))
+	require.Equal(t, "user", msg.Get("user"))
+	require.Equal(t, "Title", msg.Get("title"))
+	require.Equal(t, "Description", msg.Get("message"))
+	require.Equal(t, item.Link, msg.Get("url"))
+	require.Equal(t, "Dummy", msg.Get("url_title"))
+	require.Equal(t, "0", msg.Get("priority"))
+	require.Empty(t, msg.Get("retry"))
+
+	// Emergency priority requires retry parameters
+	msg = received[1]
+	require.Equal(t, "group", msg.Get("user"))
+	require.Equal(t, "2", msg.Get("priority"))
+	require.Equal(t, "60", msg.Get("retry"))
+	require.Equal(t, "3600", msg.Get("expire"))
+}
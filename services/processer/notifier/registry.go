@@ -0,0 +1,258 @@
+package notifier
+
+import (
+	"broadcaster/utils/info"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Creates a notifier from the instance settings.
+type Factory func(s Settings, logger *zap.SugaredLogger) (Notifier, error)
+
+// Notifier type settings field.
+type Field struct {
+	Name    string // Key in the instance config
+	Env     string // Env variable without the app prefix, used for the default instance
+	Default string
+	// Secret values can be references to env variables ('env:NAME') or files ('file:/path')
+	Secret bool
+	// Default instance is created if any of enabling fields is set in env
+	Enables bool
+}
+
+// Notifier type settings schema.
+type Schema struct {
+	Fields []Field
+	// Notifications can't have 'to' destinations, instances settings define them
+	NoDestinations bool
+}
+
+// Returns the field by name.
+func (s Schema) Field(name string) (Field, bool) {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+type registration struct {
+	schema  Schema
+	factory Factory
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]registration)
+)
+
+// Registers the notifier type. Panics if the type is already registered.
+func Register(typ string, schema Schema, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[typ]; exists {
+		panic(fmt.Sprintf("Notifier type '%s' is already registered", typ))
+	}
+	registry[typ] = registration{schema: schema, factory: factory}
+}
+
+// Returns registered notifier types sorted by name.
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	result := make([]string, 0, len(registry))
+	for typ := range registry {
+		result = append(result, typ)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Returns the notifier type schema.
+func SchemaOf(typ string) (Schema, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	r, ok := registry[typ]
+	return r.schema, ok
+}
+
+// Creates a notifier instance of the type from the config.
+// Config values are scalars, lists (joined with commas) or maps (joined as 'key=value' pairs).
+func New(typ string, config map[string]interface{}, logger *zap.SugaredLogger) (Notifier, error) {
+	r, ok := lookup(typ)
+	if !ok {
+		return nil, fmt.Errorf("Unknown notifier type '%s'", typ)
+	}
+
+	settings := make(Settings, len(r.schema.Fields))
+	for _, f := range r.schema.Fields {
+		settings[f.Name] = f.Default
+	}
+	for key, value := range config {
+		f, ok := r.schema.Field(key)
+		if !ok {
+			return nil, fmt.Errorf("Unknown %s notifier setting '%s'", typ, key)
+		}
+		v, err := settingValue(value, f.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s notifier setting '%s': %w", typ, key, err)
+		}
+		settings[key] = v
+	}
+
+	return r.factory(settings, logger)
+}
+
+// Creates the default notifier instance of the type from env variables.
+// Returns nil notifier if none of the type enabling variables is set.
+func NewFromEnv(typ string, logger *zap.SugaredLogger) (Notifier, error) {
+	r, ok := lookup(typ)
+	if !ok {
+		return nil, fmt.Errorf("Unknown notifier type '%s'", typ)
+	}
+
+	settings := make(Settings, len(r.schema.Fields))
+	enabled := false
+	for _, f := range r.schema.Fields {
+		settings[f.Name] = f.Default
+		if f.Env == "" {
+			continue
+		}
+		v, ok := os.LookupEnv(strings.ToUpper(info.EnvPrefix + "_" + f.Env))
+		if !ok {
+			continue
+		}
+		settings[f.Name] = v
+		// Defaults never enable instances, only explicitly set variables
+		if f.Enables && strings.TrimSpace(v) != "" {
+			enabled = true
+		}
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	return r.factory(settings, logger)
+}
+
+func lookup(typ string) (registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	r, ok := registry[typ]
+	return r, ok
+}
+
+// Converts config value to the setting string. Secret references are resolved in secret values.
+func settingValue(value interface{}, secret bool) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		if secret {
+			return resolveSecret(v)
+		}
+		return v, nil
+	case bool, int, int64, float64:
+		return fmt.Sprint(v), nil
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := settingValue(item, secret)
+			if err != nil {
+				return "", err
+			}
+			values = append(values, s)
+		}
+		return strings.Join(values, ","), nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		values := make([]string, 0, len(v))
+		for _, key := range keys {
+			s, err := settingValue(v[key], secret)
+			if err != nil {
+				return "", err
+			}
+			values = append(values, key+"="+s)
+		}
+		return strings.Join(values, ","), nil
+	}
+	return "", fmt.Errorf("Unsupported value type %T", value)
+}
+
+// Resolves secret references: 'env:NAME' for env variables and 'file:/path' for files contents.
+// Other values are returned as is.
+func resolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "env:"):
+		name := strings.TrimPrefix(value, "env:")
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("Env variable '%s' is not set", name)
+		}
+		return v, nil
+	case strings.HasPrefix(value, "file:"):
+		data, err := os.ReadFile(strings.TrimPrefix(value, "file:"))
+		if err != nil {
+			return "", fmt.Errorf("Failed to read secret file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return value, nil
+}
+
+// Notifier instance settings by fields names.
+type Settings map[string]string
+
+func (s Settings) Get(name string) string {
+	return strings.TrimSpace(s[name])
+}
+
+func (s Settings) Bool(name string) (bool, error) {
+	v := s.Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("Invalid '%s' value, expected boolean", name)
+	}
+	return b, nil
+}
+
+// Parses comma separated values in 'name=value' format.
+// Values aren't exposed in errors as they are usually secrets.
+func (s Settings) Named(name string) (map[string]string, error) {
+	result := make(map[string]string)
+	v := s.Get(name)
+	if v == "" {
+		return result, nil
+	}
+	for _, pair := range strings.Split(v, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" || value == "" {
+			return nil, fmt.Errorf("Invalid '%s' value '%s', expected 'name=value' format", name, key)
+		}
+		if _, exists := result[key]; exists {
+			return nil, fmt.Errorf("Duplicate '%s' name '%s'", name, key)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
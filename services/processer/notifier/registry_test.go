@@ -0,0 +1,170 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// Notifier keeping its settings.
+type settingsNotifier struct {
+	settings Settings
+}
+
+func (n *settingsNotifier) Notify(ctx context.Context, r NotificationRequest) error {
+	return nil
+}
+
+func (n *settingsNotifier) NewRequest(fn structs.RssFeedNotification, item *structs.RssFeedItem) NotificationRequest {
+	return NotificationRequest{}
+}
+
+func init() {
+	Register("registry_test", Schema{
+		Fields: []Field{
+			{Name: "url", Env: "REGISTRY_TEST_URL", Default: "https://example.com"},
+			{Name: "token", Env: "REGISTRY_TEST_TOKEN", Secret: true, Enables: true},
+			{Name: "channels", Env: "REGISTRY_TEST_CHANNELS", Secret: true},
+			{Name: "silent"},
+		},
+	}, func(s Settings, logger *zap.SugaredLogger) (Notifier, error) {
+		return &settingsNotifier{settings: s}, nil
+	})
+}
+
+func Test_Registry(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+
+	t.Run("Types", func(t *testing.T) {
+		types := Types()
+		require.Contains(t, types, "registry_test")
+		require.Contains(t, types, "telegram")
+		require.Contains(t, types, "slack")
+		require.IsIncreasing(t, types)
+
+		schema, ok := SchemaOf("registry_test")
+		require.True(t, ok)
+		field, ok := schema.Field("token")
+		require.True(t, ok)
+		require.True(t, field.Secret)
+
+		_, ok = SchemaOf("unknown")
+		require.False(t, ok)
+	})
+
+	t.Run("DuplicateType", func(t *testing.T) {
+		require.Panics(t, func() {
+			Register("registry_test", Schema{}, nil)
+		})
+	})
+
+	t.Run("New", func(t *testing.T) {
+		secretFile := filepath.Join(t.TempDir(), "token")
+		require.NoError(t, os.WriteFile(secretFile, []byte("file-token\n"), 0600))
+		t.Setenv("REGISTRY_TEST_SECRET", "env-token")
+
+		n, err := New("registry_test", map[string]interface{}{
+			"token":    "file:" + secretFile,
+			"channels": map[string]interface{}{"news": "env:REGISTRY_TEST_SECRET", "alerts": "plain"},
+			"silent":   true,
+		}, logger)
+		require.NoError(t, err)
+
+		settings := n.(*settingsNotifier).settings
+		require.Equal(t, "https://example.com", settings.Get("url"))
+		require.Equal(t, "file-token", settings.Get("token"))
+
+		channels, err := settings.Named("channels")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"news": "env-token", "alerts": "plain"}, channels)
+
+		silent, err := settings.Bool("silent")
+		require.NoError(t, err)
+		require.True(t, silent)
+	})
+
+	t.Run("NewErrors", func(t *testing.T) {
+		_, err := New("unknown", nil, logger)
+		require.ErrorContains(t, err, "Unknown notifier type")
+
+		_, err = New("registry_test", map[string]interface{}{"tokn": "abc"}, logger)
+		require.ErrorContains(t, err, "Unknown registry_test notifier setting 'tokn'")
+
+		_, err = New("registry_test", map[string]interface{}{"token": "env:REGISTRY_TEST_MISSING"}, logger)
+		require.ErrorContains(t, err, "REGISTRY_TEST_MISSING")
+
+		_, err = New("registry_test", map[string]interface{}{"token": "file:/not/exists"}, logger)
+		require.Error(t, err)
+	})
+
+	t.Run("NotSecretReference", func(t *testing.T) {
+		n, err := New("registry_test", map[string]interface{}{"url": "env:REGISTRY_TEST_MISSING"}, logger)
+		require.NoError(t, err)
+		require.Equal(t, "env:REGISTRY_TEST_MISSING", n.(*settingsNotifier).settings.Get("url"))
+	})
+
+	t.Run("NewFromEnv", func(t *testing.T) {
+		n, err := NewFromEnv("registry_test", logger)
+		require.NoError(t, err)
+		require.Nil(t, n)
+
+		t.Setenv("BCTR_REGISTRY_TEST_URL", "https://custom.example.com")
+		n, err = NewFromEnv("registry_test", logger)
+		require.NoError(t, err)
+		require.Nil(t, n, "Not enabling variables don't create instances")
+
+		t.Setenv("BCTR_REGISTRY_TEST_TOKEN", "env-token")
+		t.Setenv("BCTR_REGISTRY_TEST_URL", "https://custom.example.com")
+
+		n, err = NewFromEnv("registry_test", logger)
+		require.NoError(t, err)
+		require.NotNil(t, n)
+
+		settings := n.(*settingsNotifier).settings
+		require.Equal(t, "env-token", settings.Get("token"))
+		require.Equal(t, "https://custom.example.com", settings.Get("url"))
+
+		_, err = NewFromEnv("unknown", logger)
+		require.Error(t, err)
+	})
+}
+
+func Test_Settings(t *testing.T) {
+	s := Settings{
+		"flag":    " true ",
+		"invalid": "maybe",
+		"named":   "one=1, two = 2",
+		"bad":     "one=1,two",
+		"dup":     "one=1,one=2",
+	}
+
+	v, err := s.Bool("flag")
+	require.NoError(t, err)
+	require.True(t, v)
+
+	v, err = s.Bool("missing")
+	require.NoError(t, err)
+	require.False(t, v)
+
+	_, err = s.Bool("invalid")
+	require.Error(t, err)
+
+	named, err := s.Named("named")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"one": "1", "two": "2"}, named)
+
+	named, err = s.Named("missing")
+	require.NoError(t, err)
+	require.Empty(t, named)
+
+	_, err = s.Named("bad")
+	require.ErrorContains(t, err, "expected 'name=value' format")
+
+	_, err = s.Named("dup")
+	require.ErrorContains(t, err, "Duplicate")
+}
@@ -0,0 +1,139 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"broadcaster/utils/strutil"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type RocketChatNotifierConfig struct {
+	URL        string // Server URL, required for the API posting
+	UserId     string // Bot user id
+	Token      string // Bot personal access token
+	WebhookURL string // Incoming webhook URL, used instead of the API if specified
+}
+
+// Posts messages to Rocket.Chat with the REST API or incoming webhooks.
+type RocketChatNotifier struct {
+	cfg    *RocketChatNotifierConfig
+	logger *zap.SugaredLogger
+}
+
+func init() {
+	Register("rocketchat", Schema{
+		Fields: []Field{
+			{Name: "url", Env: "ROCKETCHAT_URL"},
+			{Name: "user_id", Env: "ROCKETCHAT_USER_ID"},
+			{Name: "token", Env: "ROCKETCHAT_TOKEN", Secret: true, Enables: true},
+			{Name: "webhook_url", Env: "ROCKETCHAT_WEBHOOK_URL", Secret: true, Enables: true},
+		},
+	}, func(s Settings, logger *zap.SugaredLogger) (Notifier, error) {
+		return NewRocketChatNotifier(&RocketChatNotifierConfig{
+			URL:        s.Get("url"),
+			UserId:     s.Get("user_id"),
+			Token:      s.Get("token"),
+			WebhookURL: s.Get("webhook_url"),
+		}, logger)
+	})
+}
+
+func NewRocketChatNotifier(cfg *RocketChatNotifierConfig, logger *zap.SugaredLogger) (*RocketChatNotifier, error) {
+	if cfg.WebhookURL == "" {
+		if cfg.UserId == "" || cfg.Token == "" {
+			return nil, errors.New("Rocket.Chat user id and token or webhook URL are required")
+		}
+		if cfg.URL == "" {
+			return nil, errors.New("Rocket.Chat server URL is required")
+		}
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+
+	r := &RocketChatNotifier{
+		cfg:    cfg,
+		logger: logger,
+	}
+	return r, nil
+}
+
+// Implement the Notifier interface
+var _ Notifier = (*RocketChatNotifier)(nil)
+
+// Rocket.Chat API response status.
+type rocketChatResponse struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+}
+
+func (rc *RocketChatNotifier) Notify(ctx context.Context, r NotificationRequest) error {
+	attachment, ok := r.Payload.(chatAttachment)
+	if !ok {
+		return fmt.Errorf("Unexpected Rocket.Chat payload type %T", r.Payload)
+	}
+
+	if r.Image != "" && imageAvailable(ctx, r.Image) {
+		attachment = attachment.withImage(r.Image)
+	}
+
+	if rc.cfg.WebhookURL != "" && len(r.To) == 0 {
+		// Posting to the webhook default channel
+		if err := rc.notify(ctx, "", attachment); err != nil {
+			return fmt.Errorf("Failed to notify Rocket.Chat webhook: %w", err)
+		}
+		return nil
+	}
+
+	var errs []error
+	for _, to := range r.To {
+		if err := rc.notify(ctx, to, attachment); err != nil {
+			rc.logger.With("err", err.Error()).Errorf("Failed to notify Rocket.Chat to '%s'", to)
+			errs = append(errs, &DestinationError{To: to, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Posts the message to the channel ('#channel', '@user' or room id) with the webhook or the API.
+// Channel overrides the webhook default channel if specified.
+func (rc *RocketChatNotifier) notify(ctx context.Context, channel string, attachment chatAttachment) error {
+	msg := map[string]interface{}{
+		"attachments": []chatAttachment{attachment},
+	}
+	if channel != "" {
+		msg["channel"] = channel
+	}
+
+	uri, headers := rc.cfg.WebhookURL, map[string]string(nil)
+	if uri == "" {
+		uri = rc.cfg.URL + "/api/v1/chat.postMessage"
+		headers = map[string]string{
+			"X-User-Id":    rc.cfg.UserId,
+			"X-Auth-Token": rc.cfg.Token,
+		}
+	}
+
+	var resp rocketChatResponse
+	if err := doJSON(ctx, http.MethodPost, uri, headers, msg, &resp); err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("Unsuccessful response: %s", strutil.Coalesce(resp.Error, "unknown error"))
+	}
+	return nil
+}
+
+func (rc *RocketChatNotifier) NewRequest(fn structs.RssFeedNotification, item *structs.RssFeedItem) NotificationRequest {
+	attachment := newChatAttachment(item)
+	return NotificationRequest{
+		To:      fn.To,
+		Source:  item.Source,
+		Image:   item.Image,
+		Message: attachment.Fallback,
+		Payload: attachment,
+	}
+}
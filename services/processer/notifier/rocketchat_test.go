@@ -0,0 +1,107 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// Local stand-in for Rocket.Chat API and incoming webhooks. Stores received messages.
+type fakeRocketChat struct {
+	srv *httptest.Server
+	mu  sync.Mutex
+	// Received messages: channel and the first attachment
+	messages []map[string]interface{}
+}
+
+func newFakeRocketChat(t *testing.T) *fakeRocketChat {
+	f := &fakeRocketChat{}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			Channel     string                   `json:"channel"`
+			Attachments []map[string]interface{} `json:"attachments"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		if msg.Channel == "#missing" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"success":false,"error":"error-invalid-channel"}`)
+			return
+		}
+		require.Len(t, msg.Attachments, 1)
+
+		f.mu.Lock()
+		msg.Attachments[0]["channel"] = msg.Channel
+		f.messages = append(f.messages, msg.Attachments[0])
+		f.mu.Unlock()
+		fmt.Fprint(w, `{"success":true}`)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/chat.postMessage", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-User-Id") != "user-id" || r.Header.Get("X-Auth-Token") != "test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"status":"error","message":"You must be logged in to do this."}`)
+			return
+		}
+		handler(w, r)
+	})
+	mux.HandleFunc("/hooks/id/token", handler)
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func Test_RocketChatNotifier(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeRocketChat(t)
+
+	item := &structs.RssFeedItem{
+		Source:      "Dummy",
+		Title:       "Title",
+		Description: "Description",
+		Link:        "https://example.com/news/1",
+	}
+
+	_, err := NewRocketChatNotifier(&RocketChatNotifierConfig{URL: fake.srv.URL, Token: "test-token"}, zap.NewNop().Sugar())
+	require.Error(t, err)
+
+	t.Run("api", func(t *testing.T) {
+		fake.messages = nil
+		rc, err := NewRocketChatNotifier(&RocketChatNotifierConfig{URL: fake.srv.URL, UserId: "user-id", Token: "test-token"}, zap.NewNop().Sugar())
+		require.NoError(t, err)
+
+		nfn := structs.RssFeedNotification{Type: "rocketchat", To: []string{"#general", "#missing", "@user"}}
+		requireFailedDestinations(t, rc.Notify(ctx, rc.NewRequest(nfn, item)), "#missing")
+
+		require.Len(t, fake.messages, 2)
+		require.Equal(t, "#general", fake.messages[0]["channel"])
+		require.Equal(t, "@user", fake.messages[1]["channel"])
+
+		msg := fake.messages[0]
+		require.Equal(t, "Dummy", msg["author_name"])
+		require.Equal(t, "Title", msg["title"])
+		require.Equal(t, item.Link, msg["title_link"])
+		require.Equal(t, "Description", msg["text"])
+	})
+
+	t.Run("webhook", func(t *testing.T) {
+		fake.messages = nil
+		rc, err := NewRocketChatNotifier(&RocketChatNotifierConfig{WebhookURL: fake.srv.URL + "/hooks/id/token"}, zap.NewNop().Sugar())
+		require.NoError(t, err)
+
+		require.NoError(t, rc.Notify(ctx, rc.NewRequest(structs.RssFeedNotification{Type: "rocketchat"}, item)))
+		require.NoError(t, rc.Notify(ctx, rc.NewRequest(structs.RssFeedNotification{Type: "rocketchat", To: []string{"#news"}}, item)))
+
+		require.Len(t, fake.messages, 2)
+		require.Equal(t, "", fake.messages[0]["channel"])
+		require.Equal(t, "#news", fake.messages[1]["channel"])
+	})
+}
@@ -2,58 +2,239 @@ package notifier
 
 import (
 	"broadcaster/structs"
+	"broadcaster/utils/strutil"
 	"context"
+	"errors"
 	"fmt"
+	"html"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/microcosm-cc/bluemonday"
 	"github.com/slack-go/slack"
 	"go.uber.org/zap"
 )
 
+type SlackNotifierConfig struct {
+	Token      string // Bot API token
+	WebhookURL string // Incoming webhook URL, used instead of the API if specified
+	APIURL     string // Optional custom API URL (eg for testing)
+}
+
 type SlackNotifier struct {
+	cfg    *SlackNotifierConfig
 	cl     *slack.Client
 	logger *zap.SugaredLogger
 }
 
-func NewSlackNotifier(token string, logger *zap.SugaredLogger) *SlackNotifier {
+func init() {
+	Register("slack", Schema{
+		Fields: []Field{
+			{Name: "token", Env: "SLACK_API_TOKEN", Secret: true, Enables: true},
+			{Name: "webhook_url", Env: "SLACK_WEBHOOK_URL", Secret: true, Enables: true},
+		},
+	}, func(s Settings, logger *zap.SugaredLogger) (Notifier, error) {
+		return NewSlackNotifier(&SlackNotifierConfig{
+			Token:      s.Get("token"),
+			WebhookURL: s.Get("webhook_url"),
+		}, logger)
+	})
+}
+
+func NewSlackNotifier(cfg *SlackNotifierConfig, logger *zap.SugaredLogger) (*SlackNotifier, error) {
+	if cfg.Token == "" && cfg.WebhookURL == "" {
+		return nil, errors.New("Slack API token or webhook URL is required")
+	}
+
+	var opts []slack.Option
+	if cfg.APIURL != "" {
+		opts = append(opts, slack.OptionAPIURL(strings.TrimRight(cfg.APIURL, "/")+"/"))
+	}
+
 	s := &SlackNotifier{
-		cl:     slack.New(token),
+		cfg:    cfg,
+		cl:     slack.New(cfg.Token, opts...),
 		logger: logger,
 	}
-	return s
+	return s, nil
 }
 
 // Implement the Notifier interface
 var _ Notifier = (*SlackNotifier)(nil)
 
 func (s *SlackNotifier) Notify(ctx context.Context, r NotificationRequest) error {
+	msg, ok := r.Payload.(slackMessage)
+	if !ok {
+		return fmt.Errorf("Unexpected Slack payload type %T", r.Payload)
+	}
+
+	var image string
+	if r.Image != "" && imageAvailable(ctx, r.Image) {
+		image = r.Image
+	}
+
+	if s.cfg.WebhookURL != "" && len(r.To) == 0 {
+		// Posting to the webhook default channel
+		if err := s.notify(ctx, "", r.Source, r.Message, msg, image); err != nil {
+			return fmt.Errorf("Failed to notify Slack webhook: %w", err)
+		}
+		return nil
+	}
+
+	var errs []error
 	for _, to := range r.To {
-		if err := s.notify(ctx, to, r.Source, r.Message); err != nil {
+		if err := s.notify(ctx, to, r.Source, r.Message, msg, image); err != nil {
 			s.logger.With("err", err.Error()).Errorf("Failed to notify Slack to '%s'", to)
+			errs = append(errs, &DestinationError{To: to, Err: err})
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
-func (s *SlackNotifier) notify(ctx context.Context, channel, username, msg string) error {
+func (s *SlackNotifier) notify(ctx context.Context, channel, username, text string, msg slackMessage, image string) error {
+	if image != "" {
+		err := s.post(ctx, channel, username, text, msg.blocks(image))
+		if err == nil {
+			return nil
+		}
+		s.logger.With("err", err.Error()).Warnf("Failed to post message with image to '%s', posting without it", channel)
+	}
+	return s.post(ctx, channel, username, text, msg.blocks(""))
+}
+
+// Posts the message with the API or the incoming webhook.
+// Text is used as a fallback for notifications and clients without blocks support.
+func (s *SlackNotifier) post(ctx context.Context, channel, username, text string, blocks []slack.Block) error {
+	if s.cfg.WebhookURL != "" {
+		msg := &slack.WebhookMessage{
+			Username: username,
+			Channel:  channel,
+			Text:     text,
+			Blocks:   &slack.Blocks{BlockSet: blocks},
+		}
+		return slack.PostWebhookContext(ctx, s.cfg.WebhookURL, msg)
+	}
+
 	opts := []slack.MsgOption{
-		slack.MsgOptionText(msg, false),
+		slack.MsgOptionText(text, false),
+		slack.MsgOptionBlocks(blocks...),
 		slack.MsgOptionPostMessageParameters(slack.PostMessageParameters{
 			Username: username,
 		}),
 	}
-	_, _, err := s.cl.PostMessage(channel, opts...)
+	_, _, err := s.cl.PostMessageContext(ctx, channel, opts...)
 	return err
 }
 
 func (s *SlackNotifier) NewRequest(fn structs.RssFeedNotification, item *structs.RssFeedItem) NotificationRequest {
+	msg := newSlackMessage(item)
 	return NotificationRequest{
-		To:     fn.To,
-		Source: item.Source,
-		Message: fmt.Sprintf(
-			"<%s|%s>\n\n%s",
-			item.Link,
-			item.Title,
-			item.Description,
-		),
+		To:      fn.To,
+		Source:  item.Source,
+		Image:   item.Image,
+		Message: fmt.Sprintf("<%s|%s>", item.Link, slackEscape(strutil.Coalesce(msg.Title, item.Link))),
+		Payload: msg,
+	}
+}
+
+// Block Kit limits
+const (
+	slackHeaderLimit  = 150
+	slackSectionLimit = 3000
+)
+
+// Slack message content.
+type slackMessage struct {
+	Title       string
+	Description string
+	Source      string
+	Link        string
+	PubDate     time.Time
+}
+
+func newSlackMessage(item *structs.RssFeedItem) slackMessage {
+	p := bluemonday.StrictPolicy()
+	return slackMessage{
+		Title:       strings.TrimSpace(html.UnescapeString(item.Title)),
+		Description: strings.TrimSpace(html.UnescapeString(p.Sanitize(item.Description))),
+		Source:      item.Source,
+		Link:        item.Link,
+		PubDate:     item.PubDate,
+	}
+}
+
+// Renders the message Block Kit layout: header, description section, optional image,
+// context with the source and publication date and the link button.
+func (m slackMessage) blocks(image string) []slack.Block {
+	var blocks []slack.Block
+
+	if m.Title != "" {
+		blocks = append(blocks, slack.NewHeaderBlock(
+			slack.NewTextBlockObject(slack.PlainTextType, truncate(m.Title, slackHeaderLimit), false, false),
+		))
+	}
+
+	if m.Description != "" {
+		blocks = append(blocks, slack.NewSectionBlock(
+			slack.NewTextBlockObject(slack.MarkdownType, slackEscapeLimit(m.Description, slackSectionLimit), false, false),
+			nil, nil,
+		))
+	}
+
+	if image != "" {
+		blocks = append(blocks, slack.NewImageBlock(image, truncate(strutil.Coalesce(m.Title, m.Source, "image"), 2000), "", nil))
+	}
+
+	var elements []slack.MixedElement
+	if m.Source != "" {
+		elements = append(elements, slack.NewTextBlockObject(slack.MarkdownType, "*"+slackEscape(m.Source)+"*", false, false))
+	}
+	if !m.PubDate.IsZero() {
+		date := fmt.Sprintf(
+			"<!date^%d^{date_short_pretty} {time}|%s>",
+			m.PubDate.Unix(), m.PubDate.UTC().Format("2006-01-02 15:04 UTC"),
+		)
+		elements = append(elements, slack.NewTextBlockObject(slack.MarkdownType, date, false, false))
+	}
+	if len(elements) > 0 {
+		blocks = append(blocks, slack.NewContextBlock("", elements...))
+	}
+
+	if m.Link != "" {
+		button := slack.NewButtonBlockElement(
+			"open_link", "open_link",
+			slack.NewTextBlockObject(slack.PlainTextType, "Open", false, false),
+		).WithURL(m.Link)
+		blocks = append(blocks, slack.NewActionBlock("", button))
+	}
+
+	return blocks
+}
+
+// Escapes control characters of Slack mrkdwn.
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// Escapes and truncates the text so the escaped result fits the limit.
+func slackEscapeLimit(text string, limit int) string {
+	escaped := slackEscape(text)
+	if utf8.RuneCountInString(escaped) <= limit {
+		return escaped
+	}
+
+	var (
+		b strings.Builder
+		n int
+	)
+	for _, r := range text {
+		e := slackEscape(string(r))
+		if n+utf8.RuneCountInString(e) > limit-1 {
+			break
+		}
+		b.WriteString(e)
+		n += utf8.RuneCountInString(e)
 	}
+	return strings.TrimSpace(b.String()) + "…"
 }
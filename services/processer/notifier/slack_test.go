@@ -0,0 +1,184 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// Local stand-in for Slack API and incoming webhooks. Stores received messages.
+type fakeSlack struct {
+	srv *httptest.Server
+	mu  sync.Mutex
+	// Received messages: channel, text and blocks JSON
+	messages []map[string]string
+}
+
+func newFakeSlack(t *testing.T) *fakeSlack {
+	f := &fakeSlack{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/chat.postMessage", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "test-token", r.Form.Get("token"))
+
+		blocks := r.Form.Get("blocks")
+		if strings.Contains(blocks, "broken.jpg") {
+			fmt.Fprint(w, `{"ok":false,"error":"invalid_blocks"}`)
+			return
+		}
+		f.store(r.Form.Get("channel"), r.Form.Get("text"), r.Form.Get("username"), blocks)
+		fmt.Fprint(w, `{"ok":true,"channel":"C1","ts":"1.1"}`)
+	})
+	mux.HandleFunc("/webhook", func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			Channel  string          `json:"channel"`
+			Text     string          `json:"text"`
+			Username string          `json:"username"`
+			Blocks   json.RawMessage `json:"blocks"`
+		}
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &msg))
+		f.store(msg.Channel, msg.Text, msg.Username, string(msg.Blocks))
+		fmt.Fprint(w, "ok")
+	})
+	mux.HandleFunc("/image.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+	})
+	mux.HandleFunc("/broken.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+	})
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeSlack) store(channel, text, username, blocks string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// JSON encoder escapes HTML symbols
+	blocks = strings.NewReplacer(`\u003c`, "<", `\u003e`, ">", `\u0026`, "&").Replace(blocks)
+	f.messages = append(f.messages, map[string]string{
+		"channel":  channel,
+		"text":     text,
+		"username": username,
+		"blocks":   blocks,
+	})
+}
+
+// Returns received blocks types.
+func blockTypes(t *testing.T, blocks string) []string {
+	var parsed []struct {
+		Type string `json:"type"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(blocks), &parsed))
+	var result []string
+	for _, b := range parsed {
+		result = append(result, b.Type)
+	}
+	return result
+}
+
+func Test_SlackNotifier(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeSlack(t)
+
+	item := &structs.RssFeedItem{
+		Source:      "Dummy",
+		Title:       "Title <with> & symbols",
+		Description: "<p>Description with <b>HTML</b></p>",
+		Link:        "https://example.com/news/1",
+		PubDate:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+	nfn := structs.RssFeedNotification{Type: "slack", To: []string{"#news"}}
+
+	t.Run("api", func(t *testing.T) {
+		fake.messages = nil
+		sn, err := NewSlackNotifier(&SlackNotifierConfig{Token: "test-token", APIURL: fake.srv.URL + "/api"}, zap.NewNop().Sugar())
+		require.NoError(t, err)
+
+		req := sn.NewRequest(nfn, item)
+		require.Equal(t, "<https://example.com/news/1|Title &lt;with&gt; &amp; symbols>", req.Message)
+		require.NoError(t, sn.Notify(ctx, req))
+
+		require.Len(t, fake.messages, 1)
+		msg := fake.messages[0]
+		require.Equal(t, "#news", msg["channel"])
+		require.Equal(t, "Dummy", msg["username"])
+		require.Equal(t, []string{"header", "section", "context", "actions"}, blockTypes(t, msg["blocks"]))
+		require.Contains(t, msg["blocks"], `"text":"Title <with> & symbols"`)
+		require.Contains(t, msg["blocks"], `"text":"Description with HTML"`)
+		require.Contains(t, msg["blocks"], `<!date^1714564800^{date_short_pretty} {time}|2024-05-01 12:00 UTC>`)
+		require.Contains(t, msg["blocks"], `"url":"https://example.com/news/1"`)
+	})
+	t.Run("image", func(t *testing.T) {
+		fake.messages = nil
+		sn, err := NewSlackNotifier(&SlackNotifierConfig{Token: "test-token", APIURL: fake.srv.URL + "/api"}, zap.NewNop().Sugar())
+		require.NoError(t, err)
+
+		withImage := *item
+		withImage.Image = fake.srv.URL + "/image.jpg"
+		require.NoError(t, sn.Notify(ctx, sn.NewRequest(nfn, &withImage)))
+
+		withImage.Image = fake.srv.URL + "/missing.jpg"
+		require.NoError(t, sn.Notify(ctx, sn.NewRequest(nfn, &withImage)))
+
+		withImage.Image = fake.srv.URL + "/broken.jpg"
+		require.NoError(t, sn.Notify(ctx, sn.NewRequest(nfn, &withImage)))
+
+		require.Len(t, fake.messages, 3)
+		require.Equal(t, []string{"header", "section", "image", "context", "actions"}, blockTypes(t, fake.messages[0]["blocks"]))
+		require.NotContains(t, fake.messages[1]["blocks"], `"image"`, "Unreachable image should be skipped")
+		require.NotContains(t, fake.messages[2]["blocks"], `"image"`, "Message should be reposted without rejected image")
+	})
+	t.Run("webhook", func(t *testing.T) {
+		fake.messages = nil
+		sn, err := NewSlackNotifier(&SlackNotifierConfig{WebhookURL: fake.srv.URL + "/webhook"}, zap.NewNop().Sugar())
+		require.NoError(t, err)
+
+		require.NoError(t, sn.Notify(ctx, sn.NewRequest(nfn, item)))
+		require.NoError(t, sn.Notify(ctx, sn.NewRequest(structs.RssFeedNotification{Type: "slack"}, item)))
+
+		require.Len(t, fake.messages, 2)
+		require.Equal(t, "#news", fake.messages[0]["channel"])
+		require.Equal(t, "", fake.messages[1]["channel"], "Webhook default channel should be used without destinations")
+		require.Equal(t, []string{"header", "section", "context", "actions"}, blockTypes(t, fake.messages[1]["blocks"]))
+	})
+	t.Run("noCredentials", func(t *testing.T) {
+		_, err := NewSlackNotifier(&SlackNotifierConfig{}, zap.NewNop().Sugar())
+		require.Error(t, err)
+	})
+}
+
+func Test_slackMessage_blocks_limits(t *testing.T) {
+	msg := newSlackMessage(&structs.RssFeedItem{
+		Title:       strings.Repeat("t", 200),
+		Description: strings.Repeat("<", 2000),
+	})
+	blocks := msg.blocks("")
+	require.Len(t, blocks, 2)
+
+	data, err := json.Marshal(blocks)
+	require.NoError(t, err)
+
+	var parsed []struct {
+		Text struct {
+			Text string `json:"text"`
+		} `json:"text"`
+	}
+	require.NoError(t, json.Unmarshal(data, &parsed))
+	require.Equal(t, slackHeaderLimit, len([]rune(parsed[0].Text.Text)))
+	require.LessOrEqual(t, len([]rune(parsed[1].Text.Text)), slackSectionLimit)
+	require.True(t, strings.HasSuffix(parsed[1].Text.Text, "&lt;…"))
+}
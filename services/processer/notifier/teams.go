@@ -0,0 +1,219 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"broadcaster/utils/strutil"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type TeamsNotifierConfig struct {
+	WebhookURL string            // Default webhook URL, used for notifications without destinations
+	Webhooks   map[string]string // Named webhooks URLs, referenced by notifications destinations
+}
+
+// Posts Adaptive Cards to Microsoft Teams incoming webhooks or workflows webhooks.
+type TeamsNotifier struct {
+	cfg    *TeamsNotifierConfig
+	logger *zap.SugaredLogger
+}
+
+func init() {
+	Register("teams", Schema{
+		Fields: []Field{
+			{Name: "webhook_url", Env: "TEAMS_WEBHOOK_URL", Secret: true, Enables: true},
+			{Name: "webhooks", Env: "TEAMS_WEBHOOKS", Secret: true, Enables: true},
+		},
+	}, func(s Settings, logger *zap.SugaredLogger) (Notifier, error) {
+		webhooks, err := s.Named("webhooks")
+		if err != nil {
+			return nil, err
+		}
+		return NewTeamsNotifier(&TeamsNotifierConfig{
+			WebhookURL: s.Get("webhook_url"),
+			Webhooks:   webhooks,
+		}, logger)
+	})
+}
+
+func NewTeamsNotifier(cfg *TeamsNotifierConfig, logger *zap.SugaredLogger) (*TeamsNotifier, error) {
+	if cfg.WebhookURL == "" && len(cfg.Webhooks) == 0 {
+		return nil, errors.New("Teams webhook URL is required")
+	}
+	t := &TeamsNotifier{
+		cfg:    cfg,
+		logger: logger,
+	}
+	return t, nil
+}
+
+// Implement the Notifier interface
+var _ Notifier = (*TeamsNotifier)(nil)
+
+func (t *TeamsNotifier) Notify(ctx context.Context, r NotificationRequest) error {
+	msg, ok := r.Payload.(teamsMessage)
+	if !ok {
+		return fmt.Errorf("Unexpected Teams payload type %T", r.Payload)
+	}
+
+	var image string
+	if r.Image != "" && imageAvailable(ctx, r.Image) {
+		image = r.Image
+	}
+
+	to := r.To
+	if len(to) == 0 && t.cfg.WebhookURL != "" {
+		to = []string{t.cfg.WebhookURL}
+	}
+
+	var errs []error
+	for _, dest := range to {
+		webhook, err := t.webhook(dest)
+		if err != nil {
+			t.logger.With("err", err.Error()).Errorf("Invalid Teams destination '%s'", dest)
+			errs = append(errs, &DestinationError{To: dest, Err: err})
+			continue
+		}
+		if err := doJSON(ctx, http.MethodPost, webhook, nil, msg.card(image), nil); err != nil {
+			// Webhooks URLs are secrets, logging only names
+			t.logger.With("err", err.Error()).Errorf("Failed to notify Teams to '%s'", teamsDestinationName(dest))
+			errs = append(errs, &DestinationError{To: dest, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Returns the webhook URL by the destination: named webhook or webhook URL.
+func (t *TeamsNotifier) webhook(dest string) (string, error) {
+	if url, exists := t.cfg.Webhooks[dest]; exists {
+		return url, nil
+	}
+	if strings.HasPrefix(dest, "https://") || strings.HasPrefix(dest, "http://") {
+		return dest, nil
+	}
+	return "", fmt.Errorf("Unknown Teams webhook '%s'", dest)
+}
+
+// Returns the destination name safe for logging.
+func teamsDestinationName(dest string) string {
+	if i := strings.Index(dest, "://"); i >= 0 {
+		host, _, _ := strings.Cut(dest[i+3:], "/")
+		return host
+	}
+	return dest
+}
+
+func (t *TeamsNotifier) NewRequest(fn structs.RssFeedNotification, item *structs.RssFeedItem) NotificationRequest {
+	msg := teamsMessage{
+		Title:       strings.TrimSpace(html.UnescapeString(item.Title)),
+		Description: plainText(item.Description),
+		Source:      item.Source,
+		Link:        item.Link,
+		PubDate:     item.PubDate,
+	}
+	return NotificationRequest{
+		To:      fn.To,
+		Source:  item.Source,
+		Image:   item.Image,
+		Message: strutil.Coalesce(msg.Title, item.Link),
+		Payload: msg,
+	}
+}
+
+// Adaptive Card limits
+const (
+	teamsTitleLimit       = 250
+	teamsDescriptionLimit = 2000
+	teamsCardVersion      = "1.4"
+)
+
+// Teams message content.
+type teamsMessage struct {
+	Title       string
+	Description string
+	Source      string
+	Link        string
+	PubDate     time.Time
+}
+
+// Renders the message Adaptive Card: title, optional image, summary, source with the publication date
+// and the open link action.
+func (m teamsMessage) card(image string) map[string]interface{} {
+	var body []map[string]interface{}
+
+	if m.Title != "" {
+		body = append(body, map[string]interface{}{
+			"type":   "TextBlock",
+			"text":   truncate(m.Title, teamsTitleLimit),
+			"size":   "Medium",
+			"weight": "Bolder",
+			"wrap":   true,
+		})
+	}
+	if image != "" {
+		body = append(body, map[string]interface{}{
+			"type":    "Image",
+			"url":     image,
+			"size":    "Stretch",
+			"altText": strutil.Coalesce(m.Title, m.Source, "image"),
+		})
+	}
+	if m.Description != "" {
+		body = append(body, map[string]interface{}{
+			"type": "TextBlock",
+			"text": truncate(m.Description, teamsDescriptionLimit),
+			"wrap": true,
+		})
+	}
+
+	var footer []string
+	if m.Source != "" {
+		footer = append(footer, m.Source)
+	}
+	if !m.PubDate.IsZero() {
+		// Formatted in the reader timezone by Teams
+		date := m.PubDate.UTC().Format(time.RFC3339)
+		footer = append(footer, fmt.Sprintf("{{DATE(%s, SHORT)}} {{TIME(%s)}}", date, date))
+	}
+	if len(footer) > 0 {
+		body = append(body, map[string]interface{}{
+			"type":     "TextBlock",
+			"text":     strings.Join(footer, " · "),
+			"isSubtle": true,
+			"size":     "Small",
+			"spacing":  "Small",
+			"wrap":     true,
+		})
+	}
+
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": teamsCardVersion,
+		"body":    body,
+		"msteams": map[string]interface{}{"width": "Full"},
+	}
+	if m.Link != "" {
+		card["actions"] = []map[string]interface{}{
+			{"type": "Action.OpenUrl", "title": "Open", "url": m.Link},
+		}
+	}
+
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{
+			{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"contentUrl":  nil,
+				"content":     card,
+			},
+		},
+	}
+}
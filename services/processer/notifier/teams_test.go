@@ -0,0 +1,162 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// Local stand-in for Teams webhooks. Stores received messages by webhook path.
+type fakeTeams struct {
+	srv      *httptest.Server
+	mu       sync.Mutex
+	messages map[string][]json.RawMessage
+}
+
+func newFakeTeams(t *testing.T) *fakeTeams {
+	f := &fakeTeams{messages: make(map[string][]json.RawMessage)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhook/", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		if r.URL.Path == "/webhook/throttled" {
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		var msg json.RawMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		f.mu.Lock()
+		f.messages[r.URL.Path] = append(f.messages[r.URL.Path], msg)
+		f.mu.Unlock()
+		// Workflows webhooks respond with Accepted
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/image.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+	})
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+// Adaptive Card message structure used in assertions.
+type teamsTestMessage struct {
+	Type        string `json:"type"`
+	Attachments []struct {
+		ContentType string `json:"contentType"`
+		Content     struct {
+			Type    string `json:"type"`
+			Version string `json:"version"`
+			Body    []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+				URL  string `json:"url"`
+			} `json:"body"`
+			Actions []struct {
+				Type string `json:"type"`
+				URL  string `json:"url"`
+			} `json:"actions"`
+		} `json:"content"`
+	} `json:"attachments"`
+}
+
+func Test_TeamsNotifier(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeTeams(t)
+
+	_, err := NewTeamsNotifier(&TeamsNotifierConfig{}, zap.NewNop().Sugar())
+	require.Error(t, err)
+
+	tn, err := NewTeamsNotifier(&TeamsNotifierConfig{
+		WebhookURL: fake.srv.URL + "/webhook/default",
+		Webhooks:   map[string]string{"news": fake.srv.URL + "/webhook/news"},
+	}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	item := &structs.RssFeedItem{
+		Source:      "Dummy",
+		Title:       "Title &amp; symbols",
+		Description: "<p>Description with <b>HTML</b></p>",
+		Link:        "https://example.com/news/1",
+		Image:       fake.srv.URL + "/image.jpg",
+		PubDate:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	nfn := structs.RssFeedNotification{
+		Type: "teams",
+		To:   []string{"news", fake.srv.URL + "/webhook/direct", "unknown", fake.srv.URL + "/webhook/throttled"},
+	}
+	req := tn.NewRequest(nfn, item)
+	require.Equal(t, "Title & symbols", req.Message)
+	requireFailedDestinations(t, tn.Notify(ctx, req), "unknown", fake.srv.URL+"/webhook/throttled")
+
+	require.Len(t, fake.messages["/webhook/news"], 1)
+	require.Len(t, fake.messages["/webhook/direct"], 1)
+	require.Empty(t, fake.messages["/webhook/default"])
+
+	var msg teamsTestMessage
+	require.NoError(t, json.Unmarshal(fake.messages["/webhook/news"][0], &msg))
+	require.Equal(t, "message", msg.Type)
+	require.Len(t, msg.Attachments, 1)
+	require.Equal(t, "application/vnd.microsoft.card.adaptive", msg.Attachments[0].ContentType)
+
+	card := msg.Attachments[0].Content
+	require.Equal(t, "AdaptiveCard", card.Type)
+	require.Len(t, card.Body, 4)
+	require.Equal(t, "Title & symbols", card.Body[0].Text)
+	require.Equal(t, "Image", card.Body[1].Type)
+	require.Equal(t, item.Image, card.Body[1].URL)
+	require.Equal(t, "Description with HTML", card.Body[2].Text)
+	require.Equal(t, "Dummy · {{DATE(2024-05-01T12:00:00Z, SHORT)}} {{TIME(2024-05-01T12:00:00Z)}}", card.Body[3].Text)
+	require.Len(t, card.Actions, 1)
+	require.Equal(t, "Action.OpenUrl", card.Actions[0].Type)
+	require.Equal(t, item.Link, card.Actions[0].URL)
+
+	t.Run("DefaultWebhook", func(t *testing.T) {
+		noImage := *item
+		noImage.Image = ""
+		require.NoError(t, tn.Notify(ctx, tn.NewRequest(structs.RssFeedNotification{Type: "teams"}, &noImage)))
+		require.Len(t, fake.messages["/webhook/default"], 1)
+
+		var msg teamsTestMessage
+		require.NoError(t, json.Unmarshal(fake.messages["/webhook/default"][0], &msg))
+		require.Len(t, msg.Attachments[0].Content.Body, 3)
+	})
+}
+
+func Test_doJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":"slow down"}`))
+			return
+		}
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"id":"1"}`))
+	}))
+	defer srv.Close()
+
+	var result struct {
+		Id string `json:"id"`
+	}
+	err := doJSON(context.Background(), http.MethodPost, srv.URL, map[string]string{"Authorization": "Bearer token"}, map[string]string{"a": "b"}, &result)
+	require.NoError(t, err)
+	require.Equal(t, "1", result.Id)
+
+	err = doJSON(context.Background(), http.MethodGet, srv.URL+"/error", nil, nil, nil)
+	var herr *httpError
+	require.ErrorAs(t, err, &herr)
+	require.Equal(t, http.StatusTooManyRequests, herr.Code)
+	require.Equal(t, 3*time.Second, herr.RetryAfter)
+	require.Equal(t, `Bad response code (429): {"error":"slow down"}`, herr.Error())
+}
@@ -3,30 +3,120 @@ package notifier
 import (
 	"broadcaster/structs"
 	"context"
+	"errors"
 	"fmt"
+	"html"
 	"strconv"
+	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"github.com/microcosm-cc/bluemonday"
 	"go.uber.org/zap"
 )
 
+type TelegramNotifierConfig struct {
+	Token          string            // Default bot token
+	Bots           map[string]string // Named bots tokens, referenced by notifications
+	APIEndpoint    string            // Optional custom API endpoint (eg for testing)
+	ParseMode      string            // HTML (default) or MarkdownV2
+	DisablePreview bool              // Disables links previews
+	LongMessages   string            // Long messages handling: split (default) or truncate
+}
+
 type TelegramNotifier struct {
-	bot    *tgbotapi.BotAPI
-	logger *zap.SugaredLogger
+	cfg *TelegramNotifierConfig
+	// Bots clients by name. Default bot has an empty name
+	bots     map[string]*tgbotapi.BotAPI
+	renderer telegramRenderer
+	logger   *zap.SugaredLogger
 }
 
-func NewTelegramNotifier(botToken string, logger *zap.SugaredLogger) (*TelegramNotifier, error) {
+func init() {
+	Register("telegram", Schema{
+		Fields: []Field{
+			{Name: "token", Env: "TELEGRAM_BOT_TOKEN", Secret: true, Enables: true},
+			{Name: "bots", Env: "TELEGRAM_BOTS", Secret: true, Enables: true},
+			{Name: "parse_mode", Env: "TELEGRAM_PARSE_MODE", Default: TelegramParseModeHTML},
+			{Name: "disable_preview", Env: "TELEGRAM_DISABLE_PREVIEW"},
+			{Name: "long_messages", Env: "TELEGRAM_LONG_MESSAGES", Default: TelegramLongMessagesSplit},
+		},
+	}, func(s Settings, logger *zap.SugaredLogger) (Notifier, error) {
+		bots, err := s.Named("bots")
+		if err != nil {
+			return nil, err
+		}
+		noPreview, err := s.Bool("disable_preview")
+		if err != nil {
+			return nil, err
+		}
+		return NewTelegramNotifier(&TelegramNotifierConfig{
+			Token:          s.Get("token"),
+			Bots:           bots,
+			ParseMode:      s.Get("parse_mode"),
+			DisablePreview: noPreview,
+			LongMessages:   s.Get("long_messages"),
+		}, logger)
+	})
+}
+
+func NewTelegramNotifier(cfg *TelegramNotifierConfig, logger *zap.SugaredLogger) (*TelegramNotifier, error) {
+	switch cfg.ParseMode {
+	case "", "html", TelegramParseModeHTML:
+		cfg.ParseMode = TelegramParseModeHTML
+	case "markdownv2", TelegramParseModeMarkdownV2:
+		cfg.ParseMode = TelegramParseModeMarkdownV2
+	default:
+		return nil, fmt.Errorf("Unsupported Telegram parse mode '%s'", cfg.ParseMode)
+	}
+
+	switch cfg.LongMessages {
+	case "":
+		cfg.LongMessages = TelegramLongMessagesSplit
+	case TelegramLongMessagesSplit, TelegramLongMessagesTruncate:
+	default:
+		return nil, fmt.Errorf("Unsupported Telegram long messages mode '%s'", cfg.LongMessages)
+	}
+
+	if cfg.Token == "" && len(cfg.Bots) == 0 {
+		return nil, errors.New("Telegram bot token is required")
+	}
+
 	t := &TelegramNotifier{
+		cfg:  cfg,
+		bots: make(map[string]*tgbotapi.BotAPI),
+		renderer: telegramRenderer{
+			parseMode:    cfg.ParseMode,
+			longMessages: cfg.LongMessages,
+		},
 		logger: logger,
 	}
 
-	tgbot, err := tgbotapi.NewBotAPI(botToken)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to init a telegram client: %w", err)
+	endpoint := cfg.APIEndpoint
+	if endpoint == "" {
+		endpoint = tgbotapi.APIEndpoint
+	}
+
+	tokens := make(map[string]string, len(cfg.Bots)+1)
+	for name, token := range cfg.Bots {
+		if name == "" {
+			return nil, errors.New("Telegram bot name is required")
+		}
+		tokens[name] = token
+	}
+	if cfg.Token != "" {
+		tokens[""] = cfg.Token
+	}
+
+	for name, token := range tokens {
+		tgbot, err := tgbotapi.NewBotAPIWithAPIEndpoint(token, endpoint)
+		if err != nil {
+			if name != "" {
+				return nil, fmt.Errorf("Failed to init a telegram client for bot '%s': %w", name, err)
+			}
+			return nil, fmt.Errorf("Failed to init a telegram client: %w", err)
+		}
+		t.bots[name] = tgbot
+		t.logger.With("bot", name, "username", tgbot.Self.UserName).Debug("Bot client initialized")
 	}
-	t.bot = tgbot
-	t.logger.Debug("Bot client initialized")
 
 	return t, nil
 }
@@ -34,50 +124,151 @@ func NewTelegramNotifier(botToken string, logger *zap.SugaredLogger) (*TelegramN
 // Implement the Notifier interface
 var _ Notifier = (*TelegramNotifier)(nil)
 
+// Rendered Telegram messages.
+type telegramPayload struct {
+	// Message parts to send as text messages
+	Messages []string
+	// Message parts to send with media, where the first part is the media caption
+	Captioned []string
+	// Sending options
+	Bot            string
+	Silent         bool
+	ProtectContent bool
+	DisablePreview bool
+}
+
+// Telegram message destination.
+type telegramChat struct {
+	Id       string // Numeric chat id or @username of a channel or supergroup
+	ThreadId int64  // Forum topic id
+}
+
+// Parses the destination in formats: 'chat_id', '@username', 'chat_id:thread_id' or '@username:thread_id'.
+func parseTelegramChat(to string) (telegramChat, error) {
+	var result telegramChat
+
+	chat := strings.TrimSpace(to)
+	if i := strings.LastIndex(chat, ":"); i >= 0 {
+		threadId, err := strconv.ParseInt(chat[i+1:], 10, 64)
+		if err != nil || threadId <= 0 {
+			return result, fmt.Errorf("Invalid thread id '%s'", chat[i+1:])
+		}
+		result.ThreadId = threadId
+		chat = chat[:i]
+	}
+
+	if strings.HasPrefix(chat, "@") {
+		if len(chat) < 2 || strings.ContainsAny(chat, " /") {
+			return result, fmt.Errorf("Invalid chat username '%s'", chat)
+		}
+	} else if _, err := strconv.ParseInt(chat, 10, 64); err != nil {
+		return result, fmt.Errorf("Invalid chat id '%s'", chat)
+	}
+	result.Id = chat
+
+	return result, nil
+}
+
 func (t *TelegramNotifier) Notify(ctx context.Context, r NotificationRequest) error {
+	payload, ok := r.Payload.(telegramPayload)
+	if !ok {
+		return fmt.Errorf("Unexpected Telegram payload type %T", r.Payload)
+	}
+
+	bot, exists := t.bots[payload.Bot]
+	if !exists {
+		if payload.Bot == "" {
+			return errors.New("Default Telegram bot is not configured")
+		}
+		return fmt.Errorf("Unknown Telegram bot '%s'", payload.Bot)
+	}
+
+	withImage := r.Image != "" && len(payload.Captioned) > 0 && imageAvailable(ctx, r.Image)
+
+	var errs []error
 	for _, to := range r.To {
-		chatId, err := strconv.ParseInt(to, 10, 64)
-		if err == nil {
-			if err := t.notify(ctx, chatId, r.Message); err != nil {
+		chat, err := parseTelegramChat(to)
+		if err != nil {
+			t.logger.With("err", err.Error()).Errorf("Invalid Telegram destination '%s'", to)
+			errs = append(errs, &DestinationError{To: to, Err: err})
+			continue
+		}
+
+		messages := payload.Messages
+		if withImage {
+			err := t.notifyPhoto(bot, chat, r.Image, payload.Captioned[0], payload)
+			if err == nil {
+				messages = payload.Captioned[1:]
+			} else {
+				t.logger.With("err", err.Error()).Warnf("Failed to send photo to '%s', sending text", to)
+			}
+		}
+
+		for _, msg := range messages {
+			if err := t.notify(bot, chat, msg, payload); err != nil {
 				t.logger.With("err", err.Error()).Errorf("Failed to notify Telegram to '%s'", to)
-				t.logger.Debug(r.Message)
+				t.logger.Debug(msg)
+				errs = append(errs, &DestinationError{To: to, Err: err})
+				break
 			}
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
-func (t *TelegramNotifier) notify(ctx context.Context, chatId int64, message string) error {
-	msg := tgbotapi.NewMessage(chatId, message)
-	msg.ParseMode = "markdown"
-	msg.DisableWebPagePreview = false
-
-	if _, err := t.bot.Send(msg); err != nil {
-		return err
+// Returns request params common for all messages types.
+func (t *TelegramNotifier) params(chat telegramChat, payload telegramPayload) tgbotapi.Params {
+	params := tgbotapi.Params{
+		"chat_id":    chat.Id,
+		"parse_mode": t.cfg.ParseMode,
 	}
-	return nil
+	params.AddNonZero64("message_thread_id", chat.ThreadId)
+	params.AddBool("disable_notification", payload.Silent)
+	params.AddBool("protect_content", payload.ProtectContent)
+	return params
 }
 
-func (t *TelegramNotifier) NewRequest(fn structs.RssFeedNotification, item *structs.RssFeedItem) NotificationRequest {
-	var message string
+func (t *TelegramNotifier) notify(bot *tgbotapi.BotAPI, chat telegramChat, message string, payload telegramPayload) error {
+	params := t.params(chat, payload)
+	params["text"] = message
+	params.AddBool("disable_web_page_preview", payload.DisablePreview)
+
+	_, err := bot.MakeRequest("sendMessage", params)
+	return err
+}
 
-	if len(item.Description) > 0 {
-		p := bluemonday.StrictPolicy()
-		item.Description = p.Sanitize(item.Description)
+func (t *TelegramNotifier) notifyPhoto(bot *tgbotapi.BotAPI, chat telegramChat, image, caption string, payload telegramPayload) error {
+	params := t.params(chat, payload)
+	params["photo"] = image
+	params["caption"] = caption
 
-		message = fmt.Sprintf(
-			"*%s* \n\n%s\n\n[%s](%s)",
-			item.Title, item.Description, item.Source, item.Link,
-		)
-	} else {
-		message = fmt.Sprintf(
-			"*%s* \n\n[%s](%s)",
-			item.Title, item.Source, item.Link,
-		)
+	_, err := bot.MakeRequest("sendPhoto", params)
+	return err
+}
+
+func (t *TelegramNotifier) NewRequest(fn structs.RssFeedNotification, item *structs.RssFeedItem) NotificationRequest {
+	title := strings.TrimSpace(html.UnescapeString(item.Title))
+	description := plainText(item.Description)
+
+	payload := telegramPayload{
+		Messages:       t.renderer.render(title, description, item.Source, item.Link, telegramMessageLimit),
+		Bot:            fn.Bot,
+		Silent:         fn.Silent,
+		ProtectContent: fn.ProtectContent,
+		DisablePreview: t.cfg.DisablePreview,
+	}
+	if fn.Preview != nil {
+		payload.DisablePreview = !*fn.Preview
+	}
+	if item.Image != "" {
+		payload.Captioned = t.renderer.render(title, description, item.Source, item.Link, telegramCaptionLimit)
 	}
 
 	return NotificationRequest{
 		To:      fn.To,
-		Message: message,
+		Source:  item.Source,
+		Message: payload.Messages[0],
+		Image:   item.Image,
+		Payload: payload,
 	}
 }
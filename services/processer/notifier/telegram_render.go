@@ -0,0 +1,210 @@
+package notifier
+
+import (
+	"broadcaster/utils/strutil"
+	"html"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Telegram messages parse modes.
+const (
+	TelegramParseModeHTML       = "HTML"
+	TelegramParseModeMarkdownV2 = "MarkdownV2"
+)
+
+// How messages exceeding Telegram limits are handled.
+const (
+	TelegramLongMessagesSplit    = "split"
+	TelegramLongMessagesTruncate = "truncate"
+)
+
+// Telegram limits (in UTF-16 code units of the text without markup).
+const (
+	telegramMessageLimit = 4096
+	telegramCaptionLimit = 1024
+	telegramTitleLimit   = 256
+	telegramFooterLimit  = 256
+)
+
+// Renders feed items to Telegram messages.
+type telegramRenderer struct {
+	parseMode    string
+	longMessages string
+}
+
+// Renders the item message. The first message is limited by the first limit
+// (eg media caption limit), others by the message limit.
+// Long descriptions are either split into several messages or truncated.
+func (r telegramRenderer) render(title, description, source, link string, firstLimit int) []string {
+	title = truncateUTF16(strings.TrimSpace(title), telegramTitleLimit)
+	description = strings.TrimSpace(description)
+	// Footer is repeated in split messages limits, so long links leave space for the text
+	footer := truncateUTF16(strutil.Coalesce(source, link), telegramFooterLimit)
+
+	tLen, dLen, fLen := utf16Len(title), utf16Len(description), utf16Len(footer)
+
+	if description == "" || tLen+dLen+fLen+4 <= firstLimit {
+		return []string{r.message(title, description, footer, link)}
+	}
+
+	if r.longMessages == TelegramLongMessagesTruncate {
+		description = truncateUTF16(description, firstLimit-tLen-fLen-4)
+		return []string{r.message(title, description, footer, link)}
+	}
+
+	// Footer space is reserved in every part, as it's unknown which part is the last one
+	chunks := splitUTF16(description, firstLimit-tLen-fLen-4, telegramMessageLimit-fLen-2)
+
+	var result []string
+	for i, chunk := range chunks {
+		var t, f string
+		if i == 0 {
+			t = title
+		}
+		if i == len(chunks)-1 {
+			f = footer
+		}
+		result = append(result, r.message(t, chunk, f, link))
+	}
+	return result
+}
+
+// Formats the message parts with the parse mode markup.
+func (r telegramRenderer) message(title, text, footer, link string) string {
+	var parts []string
+
+	switch r.parseMode {
+	case TelegramParseModeMarkdownV2:
+		if title != "" {
+			parts = append(parts, "*"+escapeMarkdownV2(title)+"*")
+		}
+		if text != "" {
+			parts = append(parts, escapeMarkdownV2(text))
+		}
+		if footer != "" {
+			if link != "" {
+				parts = append(parts, "["+escapeMarkdownV2(footer)+"]("+escapeMarkdownV2Link(link)+")")
+			} else {
+				parts = append(parts, escapeMarkdownV2(footer))
+			}
+		}
+	default:
+		if title != "" {
+			parts = append(parts, "<b>"+html.EscapeString(title)+"</b>")
+		}
+		if text != "" {
+			parts = append(parts, html.EscapeString(text))
+		}
+		if footer != "" {
+			if link != "" {
+				parts = append(parts, `<a href="`+html.EscapeString(link)+`">`+html.EscapeString(footer)+"</a>")
+			} else {
+				parts = append(parts, html.EscapeString(footer))
+			}
+		}
+	}
+
+	return strings.Join(parts, "\n\n")
+}
+
+// Escapes all special characters of the MarkdownV2 text.
+func escapeMarkdownV2(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune("_*[]()~`>#+-=|{}.!\\", r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Escapes special characters of the MarkdownV2 inline link URL.
+func escapeMarkdownV2Link(link string) string {
+	return strings.NewReplacer(`\`, `\\`, `)`, `\)`).Replace(link)
+}
+
+// Returns the text length in UTF-16 code units, as Telegram counts it.
+func utf16Len(text string) int {
+	var n int
+	for _, r := range text {
+		n += utf16RuneLen(r)
+	}
+	return n
+}
+
+// Returns the number of UTF-16 code units of the rune.
+func utf16RuneLen(r rune) int {
+	if utf16.IsSurrogate(r) || r < 0x10000 {
+		return 1
+	}
+	return 2
+}
+
+// Returns the longest prefix of the text fitting the limit (in UTF-16 code units).
+func prefixUTF16(text string, limit int) string {
+	var n int
+	for i, r := range text {
+		n += utf16RuneLen(r)
+		if n > limit {
+			return text[:i]
+		}
+	}
+	return text
+}
+
+// Truncates the text to the limit (in UTF-16 code units) adding ellipsis to the truncated text.
+func truncateUTF16(text string, limit int) string {
+	if utf16Len(text) <= limit {
+		return text
+	}
+	if limit <= 0 {
+		return ""
+	}
+	return strings.TrimSpace(prefixUTF16(text, limit-1)) + "…"
+}
+
+// Splits the text into chunks. The first chunk is limited by the first limit, others by the limit.
+// Text is split by paragraphs, lines, sentences or words if possible.
+func splitUTF16(text string, first, limit int) []string {
+	var result []string
+
+	for text != "" {
+		max := limit
+		if len(result) == 0 {
+			max = first
+		}
+
+		if utf16Len(text) <= max {
+			result = append(result, text)
+			break
+		}
+
+		prefix := prefixUTF16(text, max)
+		if prefix == "" {
+			if len(result) == 0 {
+				// No space for text in the first chunk
+				result = append(result, "")
+				continue
+			}
+			// The rune doesn't fit the limit, it's kept whole to make progress
+			_, size := utf8.DecodeRuneInString(text)
+			prefix = text[:size]
+		}
+		cut := len(prefix)
+		for _, sep := range []string{"\n\n", "\n", ". ", " "} {
+			// Too short chunks are worse than splitting inside sentences
+			if i := strings.LastIndex(prefix, sep); i > len(prefix)/2 {
+				cut = i + len(strings.TrimRight(sep, " \n"))
+				break
+			}
+		}
+
+		result = append(result, strings.TrimSpace(text[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+
+	return result
+}
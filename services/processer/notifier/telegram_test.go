@@ -0,0 +1,295 @@
+package notifier
+
+import (
+	"broadcaster/structs"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// Local stand-in for Telegram Bot API. Stores received requests params.
+type fakeTelegram struct {
+	srv *httptest.Server
+	mu  sync.Mutex
+	// Received requests: method, bot token and params
+	requests []map[string]string
+}
+
+func newFakeTelegram(t *testing.T) *fakeTelegram {
+	f := &fakeTelegram{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// Path format: /bot<token>/<method>
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/bot"), "/")
+		require.Len(t, parts, 2)
+		token, method := parts[0], parts[1]
+
+		if token == "invalid" {
+			fmt.Fprint(w, `{"ok":false,"error_code":401,"description":"Unauthorized"}`)
+			return
+		}
+		if method == "getMe" {
+			fmt.Fprintf(w, `{"ok":true,"result":{"id":1,"is_bot":true,"username":"%s_bot"}}`, token)
+			return
+		}
+
+		require.NoError(t, r.ParseForm())
+		if r.Form.Get("chat_id") == "@private" {
+			fmt.Fprint(w, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`)
+			return
+		}
+
+		params := map[string]string{"method": method, "token": token}
+		for k := range r.Form {
+			params[k] = r.Form.Get(k)
+		}
+		f.mu.Lock()
+		f.requests = append(f.requests, params)
+		f.mu.Unlock()
+		fmt.Fprint(w, `{"ok":true,"result":{"message_id":1,"date":0,"chat":{"id":1,"type":"supergroup"}}}`)
+	})
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func Test_parseTelegramChat(t *testing.T) {
+	tests := []struct {
+		to      string
+		want    telegramChat
+		wantErr bool
+	}{
+		{"-1001234567890", telegramChat{Id: "-1001234567890"}, false},
+		{"-1001234567890:42", telegramChat{Id: "-1001234567890", ThreadId: 42}, false},
+		{"@channel", telegramChat{Id: "@channel"}, false},
+		{"@supergroup:7", telegramChat{Id: "@supergroup", ThreadId: 7}, false},
+		{"channel", telegramChat{}, true},
+		{"@", telegramChat{}, true},
+		{"-100:topic", telegramChat{}, true},
+		{"-100:0", telegramChat{}, true},
+		{"", telegramChat{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.to, func(t *testing.T) {
+			got, err := parseTelegramChat(tt.to)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_TelegramNotifier(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeTelegram(t)
+	endpoint := fake.srv.URL + "/bot%s/%s"
+
+	tn, err := NewTelegramNotifier(&TelegramNotifierConfig{
+		Token:       "default",
+		Bots:        map[string]string{"alerts": "alerts"},
+		APIEndpoint: endpoint,
+	}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	item := &structs.RssFeedItem{
+		Source:      "Dummy",
+		Title:       "Title",
+		Description: "Description",
+		Link:        "https://example.com/news/1",
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		fake.requests = nil
+		nfn := structs.RssFeedNotification{Type: "telegram", To: []string{"-100123", "-100123:42", "@channel", "bad", "@private"}}
+		requireFailedDestinations(t, tn.Notify(ctx, tn.NewRequest(nfn, item)), "bad", "@private")
+
+		require.Len(t, fake.requests, 3)
+		for _, req := range fake.requests {
+			require.Equal(t, "sendMessage", req["method"])
+			require.Equal(t, "default", req["token"])
+			require.Equal(t, TelegramParseModeHTML, req["parse_mode"])
+			require.Empty(t, req["disable_notification"])
+			require.Empty(t, req["protect_content"])
+			require.Empty(t, req["disable_web_page_preview"])
+		}
+		require.Equal(t, "-100123", fake.requests[0]["chat_id"])
+		require.Empty(t, fake.requests[0]["message_thread_id"])
+		require.Equal(t, "-100123", fake.requests[1]["chat_id"])
+		require.Equal(t, "42", fake.requests[1]["message_thread_id"])
+		require.Equal(t, "@channel", fake.requests[2]["chat_id"])
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		fake.requests = nil
+		preview := false
+		nfn := structs.RssFeedNotification{
+			Type:           "telegram",
+			To:             []string{"-100123:42"},
+			Bot:            "alerts",
+			Silent:         true,
+			ProtectContent: true,
+			Preview:        &preview,
+		}
+		require.NoError(t, tn.Notify(ctx, tn.NewRequest(nfn, item)))
+
+		require.Len(t, fake.requests, 1)
+		req := fake.requests[0]
+		require.Equal(t, "alerts", req["token"])
+		require.Equal(t, "42", req["message_thread_id"])
+		require.Equal(t, "true", req["disable_notification"])
+		require.Equal(t, "true", req["protect_content"])
+		require.Equal(t, "true", req["disable_web_page_preview"])
+	})
+
+	t.Run("UnknownBot", func(t *testing.T) {
+		fake.requests = nil
+		nfn := structs.RssFeedNotification{Type: "telegram", To: []string{"-100123"}, Bot: "missing"}
+		require.Error(t, tn.Notify(ctx, tn.NewRequest(nfn, item)))
+		require.Empty(t, fake.requests)
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		_, err := NewTelegramNotifier(&TelegramNotifierConfig{
+			Bots:        map[string]string{"broken": "invalid"},
+			APIEndpoint: endpoint,
+		}, zap.NewNop().Sugar())
+		require.ErrorContains(t, err, "broken")
+	})
+}
+
+func Test_telegramRenderer_render(t *testing.T) {
+	html := telegramRenderer{parseMode: TelegramParseModeHTML, longMessages: TelegramLongMessagesSplit}
+	md := telegramRenderer{parseMode: TelegramParseModeMarkdownV2, longMessages: TelegramLongMessagesSplit}
+
+	tests := []struct {
+		name     string
+		renderer telegramRenderer
+		title    string
+		desc     string
+		source   string
+		link     string
+		want     []string
+	}{
+		{
+			"HTML",
+			html, "Tom & Jerry <3", "1 < 2 > 0 \"quoted\"", "Source", "https://example.com/?a=1&b=2",
+			[]string{"<b>Tom &amp; Jerry &lt;3</b>\n\n1 &lt; 2 &gt; 0 &#34;quoted&#34;\n\n<a href=\"https://example.com/?a=1&amp;b=2\">Source</a>"},
+		},
+		{
+			"HTML without description",
+			html, "Title", "", "", "https://example.com",
+			[]string{"<b>Title</b>\n\n<a href=\"https://example.com\">https://example.com</a>"},
+		},
+		{
+			"HTML without link",
+			html, "Title", "Text", "Source", "",
+			[]string{"<b>Title</b>\n\nText\n\nSource"},
+		},
+		{
+			"MarkdownV2",
+			md, "Breaking: 1+1=2!", "_a_ *b* [c](d) ~e~ `f` >g #h -i |j| {k}. \\l",
+			"Source.com", "https://example.com/wiki/Go_(language)",
+			[]string{
+				"*Breaking: 1\\+1\\=2\\!*\n\n" +
+					"\\_a\\_ \\*b\\* \\[c\\]\\(d\\) \\~e\\~ \\`f\\` \\>g \\#h \\-i \\|j\\| \\{k\\}\\. \\\\l\n\n" +
+					"[Source\\.com](https://example.com/wiki/Go_(language\\))",
+			},
+		},
+		{
+			"MarkdownV2 without title",
+			md, "", "Text.", "", "https://example.com",
+			[]string{"Text\\.\n\n[https://example\\.com](https://example.com)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.renderer.render(tt.title, tt.desc, tt.source, tt.link, telegramMessageLimit)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_telegramRenderer_renderLong(t *testing.T) {
+	paragraph := strings.Repeat("Sentence of the paragraph. ", 30) // 810 characters
+	desc := strings.TrimSpace(strings.Repeat(paragraph+"\n\n", 8))
+
+	t.Run("Split", func(t *testing.T) {
+		r := telegramRenderer{parseMode: TelegramParseModeHTML, longMessages: TelegramLongMessagesSplit}
+		got := r.render("Title", desc, "Source", "https://example.com", telegramMessageLimit)
+		require.Len(t, got, 2)
+		require.True(t, strings.HasPrefix(got[0], "<b>Title</b>"))
+		require.NotContains(t, got[0], "Source")
+		require.False(t, strings.HasPrefix(got[1], "<b>Title</b>"))
+		require.True(t, strings.HasSuffix(got[1], `<a href="https://example.com">Source</a>`))
+		for _, msg := range got {
+			require.LessOrEqual(t, utf16Len(msg), telegramMessageLimit)
+			// Split by paragraphs
+			require.True(t, strings.HasSuffix(strings.TrimSuffix(strings.Split(msg, "\n\n<a")[0], "."), "paragraph"))
+		}
+	})
+
+	t.Run("Caption", func(t *testing.T) {
+		r := telegramRenderer{parseMode: TelegramParseModeMarkdownV2, longMessages: TelegramLongMessagesSplit}
+		got := r.render("Title", desc, "Source", "https://example.com", telegramCaptionLimit)
+		require.Greater(t, len(got), 2)
+		require.LessOrEqual(t, utf16Len(plainText(strings.ReplaceAll(got[0], "\\", ""))), telegramCaptionLimit)
+	})
+
+	t.Run("Truncate", func(t *testing.T) {
+		r := telegramRenderer{parseMode: TelegramParseModeHTML, longMessages: TelegramLongMessagesTruncate}
+		got := r.render("Title", desc, "Source", "https://example.com", telegramCaptionLimit)
+		require.Len(t, got, 1)
+		require.Contains(t, got[0], "…\n\n<a")
+		require.True(t, strings.HasSuffix(got[0], `<a href="https://example.com">Source</a>`))
+	})
+
+	t.Run("Long link", func(t *testing.T) {
+		r := telegramRenderer{parseMode: TelegramParseModeHTML, longMessages: TelegramLongMessagesSplit}
+		link := "https://example.com/" + strings.Repeat("l", telegramMessageLimit)
+		got := r.render("Title", desc, "", link, telegramMessageLimit)
+		require.Greater(t, len(got), 1)
+		for _, msg := range got {
+			require.LessOrEqual(t, utf16Len(plainText(msg)), telegramMessageLimit)
+		}
+		require.Contains(t, got[len(got)-1], `<a href="`+link+`">`, "Only the footer text is truncated")
+	})
+
+	t.Run("Long title", func(t *testing.T) {
+		r := telegramRenderer{parseMode: TelegramParseModeHTML, longMessages: TelegramLongMessagesSplit}
+		got := r.render(strings.Repeat("t", 300), "", "", "", telegramMessageLimit)
+		require.Equal(t, []string{"<b>" + strings.Repeat("t", 255) + "…</b>"}, got)
+	})
+}
+
+func Test_utf16(t *testing.T) {
+	// Emoji outside of the BMP takes two UTF-16 code units
+	require.Equal(t, 3, utf16Len("a😀"))
+	require.Equal(t, 2, utf16Len("äö"))
+	require.Equal(t, "a", prefixUTF16("a😀", 2))
+	require.Equal(t, "a😀", prefixUTF16("a😀", 3))
+	require.Equal(t, "ab…", truncateUTF16("abcdef", 3))
+	require.Equal(t, "abc", truncateUTF16("abc", 3))
+
+	chunks := splitUTF16("😀😀😀😀", 4, 4)
+	require.Equal(t, []string{"😀😀", "😀😀"}, chunks)
+
+	// Surrogate pairs don't fit a single unit, splitting still makes progress
+	require.Equal(t, []string{"", "😀", "😀"}, splitUTF16("😀😀", 1, 1))
+	require.Equal(t, []string{"", "a", "b"}, splitUTF16("ab", 0, 0), "No space for text")
+}
+
+func Test_plainText(t *testing.T) {
+	require.Equal(t, "Tom & Jerry <3", plainText("<p>Tom &amp; Jerry &lt;3</p>"))
+	require.Equal(t, "text link", plainText(`text <a href="https://example.com">link</a><script>x</script>`))
+}
@@ -0,0 +1,242 @@
+package processer
+
+import (
+	"broadcaster/structs"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // Time zones for images without system tzdata
+)
+
+// Limit of notifications queued until quiet hours end, the oldest ones are dropped above it.
+const maxQueuedNotifications = 1000
+
+// Parsed notification quiet hours.
+type quietHours struct {
+	location *time.Location
+	from     time.Duration // Since midnight
+	to       time.Duration // Since midnight
+	weekdays map[time.Weekday]bool
+	action   structs.QuietHoursAction
+}
+
+func parseQuietHours(q *structs.QuietHours) (*quietHours, error) {
+	location, err := time.LoadLocation(q.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid timezone '%s'", q.Timezone)
+	}
+
+	from, err := parseClock(q.From)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid 'from' time: %w", err)
+	}
+	to, err := parseClock(q.To)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid 'to' time: %w", err)
+	}
+	if from == to {
+		return nil, errors.New("Empty quiet hours window")
+	}
+
+	result := &quietHours{
+		location: location,
+		from:     from,
+		to:       to,
+		action:   q.Action,
+	}
+
+	switch q.Action {
+	case "":
+		result.action = structs.QuietHoursActionQueue
+	case structs.QuietHoursActionQueue, structs.QuietHoursActionSilent, structs.QuietHoursActionDrop:
+	default:
+		return nil, fmt.Errorf("Unsupported quiet hours action '%s'", q.Action)
+	}
+
+	if len(q.Weekdays) > 0 {
+		result.weekdays = make(map[time.Weekday]bool, len(q.Weekdays))
+		for _, day := range q.Weekdays {
+			wd, ok := parseWeekday(day)
+			if !ok {
+				return nil, fmt.Errorf("Invalid weekday '%s'", day)
+			}
+			result.weekdays[wd] = true
+		}
+	}
+
+	return result, nil
+}
+
+// Parses quiet hours of the feeds notifications, keyed by their settings.
+func parseFeedsQuietHours(feeds []structs.RssFeed) (map[string]*quietHours, error) {
+	result := make(map[string]*quietHours)
+	for _, feed := range feeds {
+		for _, nfn := range feed.Notifications {
+			if nfn.QuietHours == nil || nfn.Muted {
+				continue
+			}
+			q, err := parseQuietHours(nfn.QuietHours)
+			if err != nil {
+				return nil, fmt.Errorf("Feed '%s' notification '%s' quiet hours: %w", feed.Id, notifierName(nfn), err)
+			}
+			result[quietHoursKey(nfn.QuietHours)] = q
+		}
+	}
+	return result, nil
+}
+
+// Returns the key of quiet hours settings. Feeds updated by the API keep their keys if settings are the same.
+func quietHoursKey(q *structs.QuietHours) string {
+	return strings.Join([]string{q.Timezone, q.From, q.To, strings.Join(q.Weekdays, ","), string(q.Action)}, "|")
+}
+
+// Returns parsed quiet hours of the notification. Quiet hours are parsed on load,
+// the ones of feeds created after it are parsed on the first use.
+func (s *Service) notificationQuietHours(q *structs.QuietHours) (*quietHours, error) {
+	key := quietHoursKey(q)
+
+	s.mu.RLock()
+	parsed, exists := s.quietHours[key]
+	s.mu.RUnlock()
+	if exists {
+		return parsed, nil
+	}
+
+	parsed, err := parseQuietHours(q)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.quietHours == nil {
+		s.quietHours = make(map[string]*quietHours)
+	}
+	s.quietHours[key] = parsed
+	return parsed, nil
+}
+
+// Parses weekday full ('monday') or short ('mon') name.
+func parseWeekday(value string) (time.Weekday, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if value == name || value == name[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// Parses time of day in 'HH:MM' format.
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("Expected 'HH:MM' format, got '%s'", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Checks whether the time is within quiet hours. Returns the window end if so.
+// Windows spanning midnight belong to the day they start.
+func (q *quietHours) active(t time.Time) (bool, time.Time) {
+	local := t.In(q.location)
+
+	// The window started today or, if spanning midnight, yesterday
+	for _, offset := range []int{0, -1} {
+		day := local.AddDate(0, 0, offset)
+		midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, q.location)
+		if q.weekdays != nil && !q.weekdays[midnight.Weekday()] {
+			continue
+		}
+
+		start := clockTime(midnight, q.from)
+		end := clockTime(midnight, q.to)
+		if q.to < q.from {
+			end = clockTime(midnight.AddDate(0, 0, 1), q.to)
+		}
+
+		if !local.Before(start) && local.Before(end) {
+			return true, end
+		}
+	}
+	return false, time.Time{}
+}
+
+// Returns the time of day on the date. Computed from date components to respect DST changes.
+func clockTime(date time.Time, clock time.Duration) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), int(clock/time.Hour), int(clock%time.Hour/time.Minute), 0, 0, date.Location())
+}
+
+// Notification postponed until the quiet hours end.
+type queuedNotification struct {
+	Feed         structs.RssFeed
+	Notification structs.RssFeedNotification
+	Item         structs.RssFeedItem // Original item, as claimed by the deduplication
+	Translated   structs.RssFeedItem // Item translated into the notification language
+	Release      time.Time
+}
+
+// Queues the notification, dropping the oldest ones if the queue is full.
+// Stories of dropped notifications can be delivered by other feeds.
+func (s *Service) enqueue(n queuedNotification) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if over := len(s.queue) - maxQueuedNotifications + 1; over > 0 {
+		for _, dropped := range s.queue[:over] {
+			s.logger.With("feed_id", dropped.Feed.Id, "item_id", dropped.Item.Id).Warnf(
+				"Quiet hours queue is full ('%d' notifications), dropping the oldest one", maxQueuedNotifications,
+			)
+			s.releaseDestinations(dropped.Notification, dropped.Item)
+		}
+		s.queue = append(s.queue[:0:0], s.queue[over:]...)
+	}
+	s.queue = append(s.queue, n)
+}
+
+// Drops all queued notifications, eg when one-shot runs exit. Returns the number of dropped notifications.
+func (s *Service) DropQueued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, n := range s.queue {
+		s.releaseDestinations(n.Notification, n.Item)
+	}
+	dropped := len(s.queue)
+	s.queue = nil
+	return dropped
+}
+
+// Sends queued notifications with passed quiet hours.
+func (s *Service) releaseQueued(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	var released, remaining []queuedNotification
+	for _, n := range s.queue {
+		if now.Before(n.Release) {
+			remaining = append(remaining, n)
+			continue
+		}
+		released = append(released, n)
+	}
+	s.queue = remaining
+	s.mu.Unlock()
+
+	if len(released) == 0 {
+		return
+	}
+	s.logger.Infof("Releasing '%d' notifications queued during quiet hours", len(released))
+
+	for _, n := range released {
+		name := notifierName(n.Notification)
+		logger := s.logger.With("feed_id", n.Feed.Id, "notify_type", n.Notification.Type, "notifier", name)
+
+		nfr, exists := s.notifier(name)
+		if !exists {
+			logger.Warnf("Notifier '%s' isn't configured, dropping queued notification", name)
+			continue
+		}
+		s.sendItem(ctx, logger, nfr, n.Notification, n.Item, n.Translated)
+	}
+}
@@ -0,0 +1,264 @@
+package processer
+
+import (
+	"broadcaster/services/processer/dedup"
+	"broadcaster/services/processer/notifier"
+	"broadcaster/structs"
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseQuietHours(t *testing.T) {
+	q, err := parseQuietHours(&structs.QuietHours{From: "22:00", To: "07:30"})
+	require.NoError(t, err)
+	require.Equal(t, time.UTC, q.location)
+	require.Equal(t, 22*time.Hour, q.from)
+	require.Equal(t, 7*time.Hour+30*time.Minute, q.to)
+	require.Equal(t, structs.QuietHoursActionQueue, q.action)
+	require.Nil(t, q.weekdays)
+
+	q, err = parseQuietHours(&structs.QuietHours{
+		Timezone: "Europe/Helsinki",
+		From:     "9:00",
+		To:       "18:00",
+		Weekdays: []string{"Sat", "sunday"},
+		Action:   structs.QuietHoursActionSilent,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Helsinki", q.location.String())
+	require.Equal(t, map[time.Weekday]bool{time.Saturday: true, time.Sunday: true}, q.weekdays)
+
+	invalid := []structs.QuietHours{
+		{Timezone: "Mars/Olympus", From: "22:00", To: "07:00"},
+		{From: "22", To: "07:00"},
+		{From: "22:00", To: "25:00"},
+		{From: "22:00", To: "22:00"},
+		{From: "22:00", To: "07:00", Weekdays: []string{"someday"}},
+		{From: "22:00", To: "07:00", Action: "postpone"},
+	}
+	for _, cfg := range invalid {
+		_, err := parseQuietHours(&cfg)
+		require.Error(t, err, cfg)
+	}
+}
+
+func Test_quietHours_active(t *testing.T) {
+	helsinki, err := time.LoadLocation("Europe/Helsinki")
+	require.NoError(t, err)
+
+	t.Run("SameDay", func(t *testing.T) {
+		q, err := parseQuietHours(&structs.QuietHours{From: "12:00", To: "14:00"})
+		require.NoError(t, err)
+
+		active, end := q.active(time.Date(2024, 3, 4, 13, 0, 0, 0, time.UTC))
+		require.True(t, active)
+		require.Equal(t, time.Date(2024, 3, 4, 14, 0, 0, 0, time.UTC), end)
+
+		active, _ = q.active(time.Date(2024, 3, 4, 14, 0, 0, 0, time.UTC))
+		require.False(t, active, "Window end is excluded")
+		active, _ = q.active(time.Date(2024, 3, 4, 11, 59, 0, 0, time.UTC))
+		require.False(t, active)
+	})
+
+	t.Run("OverMidnight", func(t *testing.T) {
+		q, err := parseQuietHours(&structs.QuietHours{Timezone: "Europe/Helsinki", From: "22:00", To: "07:00"})
+		require.NoError(t, err)
+
+		active, end := q.active(time.Date(2024, 3, 4, 23, 0, 0, 0, helsinki))
+		require.True(t, active)
+		require.Equal(t, time.Date(2024, 3, 5, 7, 0, 0, 0, helsinki), end)
+
+		// 03:00 UTC is 05:00 in Helsinki
+		active, end = q.active(time.Date(2024, 3, 5, 3, 0, 0, 0, time.UTC))
+		require.True(t, active)
+		require.True(t, end.Equal(time.Date(2024, 3, 5, 7, 0, 0, 0, helsinki)))
+
+		active, _ = q.active(time.Date(2024, 3, 5, 12, 0, 0, 0, helsinki))
+		require.False(t, active)
+	})
+
+	t.Run("Weekdays", func(t *testing.T) {
+		// Friday and Saturday nights
+		q, err := parseQuietHours(&structs.QuietHours{From: "23:00", To: "09:00", Weekdays: []string{"fri", "sat"}})
+		require.NoError(t, err)
+
+		// 2024-03-08 is Friday
+		active, _ := q.active(time.Date(2024, 3, 8, 23, 30, 0, 0, time.UTC))
+		require.True(t, active)
+		active, _ = q.active(time.Date(2024, 3, 9, 8, 0, 0, 0, time.UTC))
+		require.True(t, active, "Saturday morning belongs to the Friday window")
+		active, _ = q.active(time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC))
+		require.True(t, active, "Sunday morning belongs to the Saturday window")
+		active, _ = q.active(time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC))
+		require.False(t, active, "Monday morning belongs to the Sunday window")
+		active, _ = q.active(time.Date(2024, 3, 7, 23, 30, 0, 0, time.UTC))
+		require.False(t, active)
+	})
+
+	t.Run("DST", func(t *testing.T) {
+		q, err := parseQuietHours(&structs.QuietHours{Timezone: "Europe/Helsinki", From: "22:00", To: "07:00"})
+		require.NoError(t, err)
+
+		// Clocks are moved forward on 2024-03-31 at 03:00
+		active, end := q.active(time.Date(2024, 3, 30, 23, 0, 0, 0, helsinki))
+		require.True(t, active)
+		require.Equal(t, 7*time.Hour, end.Sub(time.Date(2024, 3, 30, 23, 0, 0, 0, helsinki)))
+	})
+}
+
+// Notifier recording requests.
+type recordingNotifier struct {
+	mu       sync.Mutex
+	requests []structs.RssFeedNotification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, r notifier.NotificationRequest) error {
+	return nil
+}
+
+func (n *recordingNotifier) NewRequest(fn structs.RssFeedNotification, item *structs.RssFeedItem) notifier.NotificationRequest {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.requests = append(n.requests, fn)
+	return notifier.NotificationRequest{}
+}
+
+func Test_Service_quietHours(t *testing.T) {
+	ctx := context.Background()
+
+	rec := &recordingNotifier{}
+	s := &Service{
+		cfg:       &Config{},
+		logger:    tservice.logger,
+		notifiers: map[string]notifierInstance{"test": {Type: "test", Notifier: rec}},
+		mu:        &sync.RWMutex{},
+	}
+
+	// Window around the current time
+	now := time.Now().UTC()
+	quiet := &structs.QuietHours{
+		From: now.Add(-time.Hour).Format("15:04"),
+		To:   now.Add(time.Hour).Format("15:04"),
+	}
+	feed := structs.RssFeed{Id: "feed"}
+	items := []structs.RssFeedItem{{Id: "one"}, {Id: "two"}}
+
+	notify := func(action structs.QuietHoursAction) {
+		q := *quiet
+		q.Action = action
+		var wg sync.WaitGroup
+		wg.Add(1)
+		s.notifyFeed(ctx, &wg, feed, structs.RssFeedNotification{Type: "test", QuietHours: &q}, items...)
+	}
+
+	notify(structs.QuietHoursActionDrop)
+	require.Empty(t, rec.requests)
+	require.Empty(t, s.queue)
+
+	notify(structs.QuietHoursActionSilent)
+	require.Len(t, rec.requests, 2)
+	require.True(t, rec.requests[0].Silent)
+	rec.requests = nil
+
+	notify(structs.QuietHoursActionQueue)
+	require.Empty(t, rec.requests)
+	require.Len(t, s.queue, 2)
+
+	s.releaseQueued(ctx, now)
+	require.Empty(t, rec.requests, "Quiet hours aren't over yet")
+	require.Len(t, s.queue, 2)
+
+	s.releaseQueued(ctx, s.queue[0].Release)
+	require.Len(t, rec.requests, 2)
+	require.Empty(t, s.queue)
+}
+
+func Test_Service_enqueue_limit(t *testing.T) {
+	s := &Service{logger: tservice.logger, mu: &sync.RWMutex{}, dedup: dedup.New(time.Hour, 0)}
+	nfn := structs.RssFeedNotification{Type: "test", To: []string{"#news"}}
+
+	for i := 0; i < maxQueuedNotifications+2; i++ {
+		item := structs.RssFeedItem{Id: strconv.Itoa(i), Link: "https://example.com/" + strconv.Itoa(i)}
+		_, isDuplicate := s.dedupDestinations(nfn, item)
+		require.False(t, isDuplicate)
+		s.enqueue(queuedNotification{Notification: nfn, Item: item})
+	}
+	require.Len(t, s.queue, maxQueuedNotifications)
+	require.Equal(t, "2", s.queue[0].Item.Id, "The oldest notifications are dropped")
+	require.Equal(t, strconv.Itoa(maxQueuedNotifications+1), s.queue[len(s.queue)-1].Item.Id)
+
+	_, isDuplicate := s.dedupDestinations(nfn, structs.RssFeedItem{Link: "https://example.com/0"})
+	require.False(t, isDuplicate, "Dropped stories are released")
+	_, isDuplicate = s.dedupDestinations(nfn, structs.RssFeedItem{Link: "https://example.com/2"})
+	require.True(t, isDuplicate)
+
+	require.Equal(t, maxQueuedNotifications, s.DropQueued())
+	require.Empty(t, s.queue)
+	_, isDuplicate = s.dedupDestinations(nfn, structs.RssFeedItem{Link: "https://example.com/2"})
+	require.False(t, isDuplicate)
+}
+
+// Records titles of notified items.
+type titlesNotifier struct {
+	recordingNotifier
+	titles []string
+}
+
+func (n *titlesNotifier) NewRequest(fn structs.RssFeedNotification, item *structs.RssFeedItem) notifier.NotificationRequest {
+	n.titles = append(n.titles, item.Title)
+	return n.recordingNotifier.NewRequest(fn, item)
+}
+
+func Test_Service_releaseQueued_translated(t *testing.T) {
+	ctx := context.Background()
+
+	nfr := &titlesNotifier{}
+	s := &Service{
+		cfg:          &Config{},
+		logger:       tservice.logger,
+		notifiers:    map[string]notifierInstance{"test": {Type: "test", Notifier: nfr}},
+		mu:           &sync.RWMutex{},
+		translations: map[string]map[string]structs.RssFeedItem{"one": {"en": {Id: "one", Title: "en: Yksi", Language: "en"}}},
+	}
+
+	now := time.Now().UTC()
+	quiet := &structs.QuietHours{From: now.Add(-time.Hour).Format("15:04"), To: now.Add(time.Hour).Format("15:04")}
+	nfn := structs.RssFeedNotification{Type: "test", Translate: structs.RssFeedTranslation{To: "en"}, QuietHours: quiet}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	s.notifyFeed(ctx, &wg, structs.RssFeed{Id: "feed"}, nfn, structs.RssFeedItem{Id: "one", Title: "Yksi", Language: "fi"})
+	require.Len(t, s.queue, 1)
+
+	// Cache is cleared by next runs
+	s.translations = make(map[string]map[string]structs.RssFeedItem)
+
+	s.releaseQueued(ctx, s.queue[0].Release)
+	require.Equal(t, []string{"en: Yksi"}, nfr.titles)
+}
+
+func Test_parseFeedsQuietHours(t *testing.T) {
+	quiet := &structs.QuietHours{From: "22:00", To: "07:00", Weekdays: []string{"sat", "sun"}}
+	feeds := []structs.RssFeed{
+		{Id: "one", Notifications: []structs.RssFeedNotification{{Type: "test", QuietHours: quiet}}},
+		{Id: "two", Notifications: []structs.RssFeedNotification{{Type: "test"}}},
+	}
+
+	parsed, err := parseFeedsQuietHours(feeds)
+	require.NoError(t, err)
+	require.Len(t, parsed, 1)
+
+	s := &Service{mu: &sync.RWMutex{}, quietHours: parsed}
+	q, err := s.notificationQuietHours(&structs.QuietHours{From: "22:00", To: "07:00", Weekdays: []string{"sat", "sun"}})
+	require.NoError(t, err)
+	require.Same(t, parsed[quietHoursKey(quiet)], q, "Quiet hours parsed on load are reused")
+
+	feeds[1].Notifications[0].QuietHours = &structs.QuietHours{From: "25:00", To: "07:00"}
+	_, err = parseFeedsQuietHours(feeds)
+	require.ErrorContains(t, err, "Feed 'two' notification 'test' quiet hours")
+}
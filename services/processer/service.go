@@ -1,13 +1,17 @@
 package processer
 
 import (
+	"broadcaster/services/processer/dedup"
+	"broadcaster/services/processer/extractor"
 	"broadcaster/services/processer/notifier"
 	"broadcaster/services/processer/translator"
+	"broadcaster/services/processer/websub"
 	"broadcaster/storages"
 	"broadcaster/structs"
 	"broadcaster/utils/info"
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,12 +30,37 @@ type Service struct {
 	logger     *zap.SugaredLogger
 	storage    Storage
 	translator translator.Translator
-	notifiers  map[string]notifier.Notifier
+	notifiers  map[string]notifierInstance
+	dedup      *dedup.Deduplicator
+	extractor  *extractor.Extractor
+	websub     *websub.Subscriber
 	mu         *sync.RWMutex
 	// In-memory cache for translated items. item_uid -> language -> item
 	translations map[string]map[string]structs.RssFeedItem
 	// Timestamp of the last run in UTC
 	lastRun *time.Time
+	// Named notifiers instances configs
+	notifiersConfig []storages.NotifierConfig
+	// Notifications postponed until quiet hours end
+	queue []queuedNotification
+	// Parsed notifications quiet hours by their settings
+	quietHours map[string]*quietHours
+	// Locks of feeds items processing by feed id, shared by polls and pushes
+	feedLocks map[string]*sync.Mutex
+	// Fetches of feeds triggered by unsigned pushes by feed id
+	pushRefetches map[string]pushRefetch
+	// Fetch failures of feeds by feed id
+	failures map[string]*feedFailures
+	// Feeds sources by type replacing the built-in ones
+	sources map[structs.FeedType]FeedSource
+	// Prints notifications instead of sending them if set
+	dryRun *dryRunPrinter
+}
+
+// Notifier instance with its type.
+type notifierInstance struct {
+	Type string
+	notifier.Notifier
 }
 
 type Option func(*Service)
@@ -42,23 +71,24 @@ func WithLogger(logger *zap.SugaredLogger) Option {
 	}
 }
 
+// Sets named notifiers instances, referenced by feeds notifications.
+func WithNotifiers(configs []storages.NotifierConfig) Option {
+	return func(s *Service) {
+		s.notifiersConfig = configs
+	}
+}
+
+// Replaces the whole configuration loaded from env, including zero and false values.
 func WithConfig(c *Config) Option {
 	return func(s *Service) {
-		if c.TranslatorType != "" {
-			s.cfg.TranslatorType = c.TranslatorType
-		}
-		if c.GoogleCloudProjectId != "" {
-			s.cfg.GoogleCloudProjectId = c.GoogleCloudProjectId
-		}
-		if c.TelegramBotToken != "" {
-			s.cfg.TelegramBotToken = c.TelegramBotToken
-		}
-		if c.BackfillHours > 0 {
-			s.cfg.BackfillHours = c.BackfillHours
-		}
-		if c.MuteNotifications {
-			s.cfg.MuteNotifications = c.MuteNotifications
-		}
+		*s.cfg = *c
+	}
+}
+
+// Overrides hours before the first run to notify about published items.
+func WithBackfillHours(hours int) Option {
+	return func(s *Service) {
+		s.cfg.BackfillHours = hours
 	}
 }
 
@@ -72,16 +102,17 @@ func NewService(storage Storage, opts ...Option) (*Service, error) {
 		cfg:          &cfg,
 		logger:       zap.NewNop().Sugar(),
 		storage:      storage,
-		notifiers:    make(map[string]notifier.Notifier),
+		notifiers:    make(map[string]notifierInstance),
 		mu:           &sync.RWMutex{},
 		translations: make(map[string]map[string]structs.RssFeedItem),
+		failures:     make(map[string]*feedFailures),
 	}
 
 	for _, opt := range opts {
 		opt(svc)
 	}
 
-	if err := cfg.Validate(); err != nil {
+	if err := svc.cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("Invalid configuration: %w", err)
 	}
 
@@ -103,33 +134,160 @@ func NewService(storage Storage, opts ...Option) (*Service, error) {
 		return nil, fmt.Errorf("Unsupported translator type '%s'", svc.cfg.TranslatorType)
 	}
 
-	if cfg.TelegramBotToken != "" && !cfg.MuteNotifications {
-		svc.logger.Debug("Loading Telegram notifier")
-		tn, err := notifier.NewTelegramNotifier(
-			cfg.TelegramBotToken,
-			svc.logger.Named("notifier").Named("telegram"),
+	svc.extractor = extractor.New(&extractor.Config{
+		Timeout:   time.Duration(svc.cfg.ContentTimeout) * time.Second,
+		MaxSize:   svc.cfg.ContentMaxSize,
+		UserAgent: info.AppName + "/" + info.Release,
+	})
+
+	if svc.cfg.WebSub {
+		svc.logger.Debug("Enabling WebSub subscriptions")
+		svc.websub = websub.New(&websub.Config{
+			CallbackURL: strings.TrimRight(svc.cfg.PublicURL, "/") + websubPath,
+			Lease:       time.Duration(svc.cfg.WebSubLease) * time.Second,
+			UserAgent:   info.AppName + "/" + info.Release,
+		})
+	}
+
+	if svc.cfg.Dedup {
+		svc.logger.Debug("Enabling cross-feed deduplication")
+		svc.dedup = dedup.New(
+			time.Duration(svc.cfg.DedupWindowHours)*time.Hour,
+			svc.cfg.DedupTitleDistance,
 		)
+	}
+
+	if !svc.cfg.MuteNotifications {
+		notifiers, err := svc.loadNotifiers(svc.notifiersConfig)
 		if err != nil {
-			return nil, fmt.Errorf("Failed to init a telegram notifier: %w", err)
+			return nil, err
 		}
-		svc.notifiers["telegram"] = tn
-	}
 
-	if cfg.SlackApiToken != "" {
-		svc.logger.Debug("Loading Slack notifier")
-		svc.notifiers["slack"] = notifier.NewSlackNotifier(
-			cfg.SlackApiToken,
-			svc.logger.Named("notifier").Named("slack"),
-		)
+		feeds, err := svc.storage.Feeds().List(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load feeds: %w", err)
+		}
+		if err := validateNotifications(notifiers, feeds); err != nil {
+			return nil, fmt.Errorf("Invalid notifications: %w", err)
+		}
+		quiet, err := parseFeedsQuietHours(feeds)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid notifications: %w", err)
+		}
+		svc.notifiers = notifiers
+		svc.quietHours = quiet
 	}
 
 	return svc, nil
 }
 
-/*
-For each feed
-*/
+// Replaces notifiers instances with the ones from the reloaded config.
+// Keeps the current instances if the new ones can't be created or the feeds reference unknown instances.
+func (s *Service) Reload(configs []storages.NotifierConfig, feeds []structs.RssFeed) error {
+	if s.cfg.MuteNotifications {
+		return nil
+	}
+
+	notifiers, err := s.loadNotifiers(configs)
+	if err != nil {
+		return err
+	}
+	if err := validateNotifications(notifiers, feeds); err != nil {
+		return fmt.Errorf("Invalid notifications: %w", err)
+	}
+	quiet, err := parseFeedsQuietHours(feeds)
+	if err != nil {
+		return fmt.Errorf("Invalid notifications: %w", err)
+	}
+
+	s.mu.Lock()
+	s.notifiers = notifiers
+	s.quietHours = quiet
+	s.notifiersConfig = configs
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Returns the notifier instance by name.
+func (s *Service) notifier(name string) (notifierInstance, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n, exists := s.notifiers[name]
+	return n, exists
+}
+
+// Creates notifiers instances: default instances of each type from env and named instances from the config.
+// Named instances override default ones with the same name.
+func (s *Service) loadNotifiers(configs []storages.NotifierConfig) (map[string]notifierInstance, error) {
+	result := make(map[string]notifierInstance)
+
+	for _, typ := range notifier.Types() {
+		n, err := notifier.NewFromEnv(typ, s.logger.Named("notifier").Named(typ))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to init a %s notifier: %w", typ, err)
+		}
+		if n == nil {
+			continue
+		}
+		s.logger.Debugf("Loaded %s notifier from env", typ)
+		result[typ] = notifierInstance{Type: typ, Notifier: n}
+	}
+
+	for _, nc := range configs {
+		n, err := notifier.New(nc.Type, nc.Config, s.logger.Named("notifier").Named(nc.Name))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to init notifier '%s': %w", nc.Name, err)
+		}
+		s.logger.Debugf("Loaded notifier '%s' of type %s", nc.Name, nc.Type)
+		result[nc.Name] = notifierInstance{Type: nc.Type, Notifier: n}
+	}
+
+	return result, nil
+}
+
+// Checks every feed notification references an existing notifier instance of the notification type.
+func validateNotifications(notifiers map[string]notifierInstance, feeds []structs.RssFeed) error {
+	for _, feed := range feeds {
+		for _, nfn := range feed.Notifications {
+			if nfn.Muted {
+				continue
+			}
+			name := notifierName(nfn)
+			if name == "" {
+				return fmt.Errorf("Feed '%s' notification type or notifier is required", feed.Id)
+			}
+			instance, exists := notifiers[name]
+			if !exists {
+				return fmt.Errorf("Feed '%s' references unknown notifier '%s'", feed.Id, name)
+			}
+			if nfn.Type != "" && nfn.Type != instance.Type {
+				return fmt.Errorf("Feed '%s' notifier '%s' type is %s, not %s", feed.Id, name, instance.Type, nfn.Type)
+			}
+			if schema, _ := notifier.SchemaOf(instance.Type); schema.NoDestinations && len(nfn.To) > 0 {
+				return fmt.Errorf("Feed '%s' notification destinations aren't supported by %s notifiers", feed.Id, instance.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the notifier instance name of the notification.
+func notifierName(nfn structs.RssFeedNotification) string {
+	if nfn.Notifier != "" {
+		return nfn.Notifier
+	}
+	return nfn.Type
+}
+
+// Processes all feeds.
 func (s *Service) Process(ctx context.Context) error {
+	return s.ProcessFeeds(ctx)
+}
+
+// Processes the feeds by ids, all feeds if ids are empty. Unknown feeds ids are errors.
+func (s *Service) ProcessFeeds(ctx context.Context, ids ...string) error {
 	s.logger.Debug("Starting feeds processing")
 	defer s.logger.Debug("Feeds processing is done")
 
@@ -139,39 +297,70 @@ func (s *Service) Process(ctx context.Context) error {
 		now = now.Add(-time.Duration(s.cfg.BackfillHours) * time.Hour)
 	}
 
+	// Pushed feeds items are filtered concurrently with runs
+	s.mu.Lock()
 	if s.lastRun == nil {
 		s.lastRun = &now
 	}
+	s.logger.Debug("Last run: ", s.lastRun)
+	s.mu.Unlock()
 	defer func() {
+		s.mu.Lock()
 		s.lastRun = &now
+		s.mu.Unlock()
 	}()
 
-	s.logger.Debug("Last run: ", s.lastRun)
-
 	if s.cfg.MuteNotifications {
 		s.logger.Warn("Notifications are muted")
 	}
 
-	s.logger.Debug("Clearing translations cache; Current size: ", len(s.translations))
 	s.mu.Lock()
+	s.logger.Debug("Clearing translations cache; Current size: ", len(s.translations))
 	s.translations = make(map[string]map[string]structs.RssFeedItem)
 	s.mu.Unlock()
 
+	if s.dedup != nil {
+		s.dedup.Cleanup(time.Now().UTC())
+	}
+
+	s.releaseQueued(ctx, time.Now())
+
 	feeds, err := s.storage.Feeds().List(ctx)
 	if err != nil {
 		return fmt.Errorf("Failed to load feeds: %w", err)
 	}
 	s.logger.Debugf("Loaded '%d' feeds from storage", len(feeds))
 
+	if len(ids) > 0 {
+		if feeds, err = selectFeeds(feeds, ids); err != nil {
+			return err
+		}
+	}
+
+	var active []structs.RssFeed
+	for _, feed := range feeds {
+		if !s.shouldProcess(feed, time.Now()) {
+			continue
+		}
+		if s.websub != nil && s.websub.Active(feed.Id) {
+			s.logger.With("feed_id", feed.Id).Debug("Feed updates are pushed by the WebSub hub, skipping polling")
+			continue
+		}
+		active = append(active, feed)
+	}
+
 	var wg sync.WaitGroup
-	wg.Add(len(feeds))
+	wg.Add(len(active))
 
-	for _, f := range feeds {
+	for _, f := range active {
 		go func(feed structs.RssFeed) {
 			defer wg.Done()
-			if err := s.processFeed(ctx, feed); err != nil {
+			err := s.processFeed(ctx, feed)
+			if err != nil {
+				metricFeedErrors.Add(feed.Id, 1)
 				s.logger.With("feed_id", feed.Id).Errorw("Failed to process feed", "err", err.Error())
 			}
+			s.recordFeedResult(ctx, feed, err)
 		}(f)
 	}
 
@@ -180,6 +369,29 @@ func (s *Service) Process(ctx context.Context) error {
 	return nil
 }
 
+// Returns the feeds with the ids.
+func selectFeeds(feeds []structs.RssFeed, ids []string) ([]structs.RssFeed, error) {
+	byId := make(map[string]structs.RssFeed, len(feeds))
+	for _, feed := range feeds {
+		byId[feed.Id] = feed
+	}
+
+	result := make([]structs.RssFeed, 0, len(ids))
+	var unknown []string
+	for _, id := range ids {
+		feed, exists := byId[id]
+		if !exists {
+			unknown = append(unknown, id)
+			continue
+		}
+		result = append(result, feed)
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("Unknown feeds: %s", strings.Join(unknown, ", "))
+	}
+	return result, nil
+}
+
 func (s *Service) processFeed(ctx context.Context, feed structs.RssFeed) error {
 	logger := s.logger.With("feed_id", feed.Id)
 
@@ -188,15 +400,32 @@ func (s *Service) processFeed(ctx context.Context, feed structs.RssFeed) error {
 		return nil
 	}
 
-	items, err := s.parseRssFeed(ctx, feed, 120*time.Second)
+	items, err := s.parseFeed(ctx, feed, 120*time.Second)
 	if err != nil {
 		return fmt.Errorf("Failed to parse feed: %w", err)
 	}
 	logger.Debug("Parsed feed items: ", len(items))
 
+	s.processItems(ctx, feed, items)
+
+	return nil
+}
+
+// Filters new feed items, translates them, sends notifications and stores the items.
+// Items of the same feed are processed one call at a time.
+func (s *Service) processItems(ctx context.Context, feed structs.RssFeed, items []structs.RssFeedItem) {
+	logger := s.logger.With("feed_id", feed.Id)
+
+	unlock := s.lockFeed(feed.Id)
+	defer unlock()
+
 	items = s.filterItems(ctx, feed, items...)
 	logger.Debug("Feed items after filtering: ", len(items))
 
+	if feed.FetchContent {
+		s.fetchContent(ctx, feed, items)
+	}
+
 	s.translateItems(ctx, feed, items...)
 
 	var wg sync.WaitGroup
@@ -206,29 +435,16 @@ func (s *Service) processFeed(ctx context.Context, feed structs.RssFeed) error {
 	}
 	wg.Wait()
 
-	s.storeItems(ctx, feed, items...)
-
-	return nil
+	// Dry runs don't mark items as processed
+	if s.dryRun == nil {
+		s.storeItems(ctx, feed, items...)
+	}
 }
 
-// Parses the RSS feed and returns a list of converted items.
-func (s *Service) parseRssFeed(ctx context.Context, feed structs.RssFeed, timeout time.Duration) ([]structs.RssFeedItem, error) {
+// Converts the parsed feed items within the feed items limit.
+func (s *Service) feedItems(feed structs.RssFeed, parsedFeed *gofeed.Feed) []structs.RssFeedItem {
 	logger := s.logger.With("feed_id", feed.Id)
 
-	var items []structs.RssFeedItem
-
-	logger.Debug("Parsing feed")
-
-	feedParser := gofeed.NewParser()
-
-	pCtx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
-	parsedFeed, err := feedParser.ParseURLWithContext(feed.URL, pCtx)
-	if err != nil {
-		return nil, err
-	}
-
 	limit := feed.ItemsLimit
 	if limit == 0 {
 		limit = 10
@@ -239,21 +455,40 @@ func (s *Service) parseRssFeed(ctx context.Context, feed structs.RssFeed, timeou
 
 	logger.Debugf("Parsed %d items; Limit: %d", len(parsedFeed.Items), limit)
 
-	for _, item := range parsedFeed.Items[:limit] {
-		items = append(items, structs.RssFeedItem{
-			Id:          item.GUID,
-			FeedId:      feed.Id,
-			Source:      feed.Source,
-			Categories:  item.Categories,
-			Title:       item.Title,
-			Description: item.Description,
-			Link:        item.Link,
-			Language:    feed.Language,
-			PubDate:     *item.PublishedParsed,
-		})
+	items, warnings := convertItems(feed, parsedFeed.Items[:limit], time.Now().UTC())
+	for w, count := range warnings {
+		metricParseWarnings.Add(feed.Id+"."+string(w), int64(count))
+		logger.With("warning", w, "items", count).Warn("Feed items parsed with warnings")
 	}
 
-	return items, nil
+	return items
+}
+
+// Downloads full articles content and lead images of the feed items.
+func (s *Service) fetchContent(ctx context.Context, feed structs.RssFeed, items []structs.RssFeedItem) {
+	logger := s.logger.With("feed_id", feed.Id)
+
+	for i := range items {
+		ilogger := logger.With("item_id", items[i].Id)
+
+		if items[i].Link == "" {
+			ilogger.Debug("Item has no link to fetch content")
+			continue
+		}
+
+		ilogger.Debug("Fetching item content")
+
+		article, err := s.extractor.Extract(ctx, items[i].Link)
+		if err != nil {
+			ilogger.Warnw("Failed to fetch item content", "err", err.Error())
+			continue
+		}
+
+		items[i].Content = article.Content
+		if items[i].Image == "" {
+			items[i].Image = article.Image
+		}
+	}
 }
 
 // Translates feed items and stores the translations in the cache.
@@ -295,6 +530,9 @@ func (s *Service) translateItem(ctx context.Context, item *structs.RssFeedItem,
 		To:   to,
 		Text: []string{item.Title, item.Description},
 	}
+	if item.Content != "" {
+		req.Text = append(req.Text, item.Content)
+	}
 	resp, err := s.translator.Translate(ctx, req)
 	if err != nil {
 		return err
@@ -302,15 +540,41 @@ func (s *Service) translateItem(ctx context.Context, item *structs.RssFeedItem,
 
 	item.Title = resp.Title
 	item.Description = resp.Description
+	if resp.Content != "" {
+		item.Content = resp.Content
+	}
 	item.Link = resp.Link
+	item.Language = to
 
 	return nil
 }
 
+// Locks items processing of the feed, so items polled and pushed at the same time aren't notified twice.
+// Returns the unlock function.
+func (s *Service) lockFeed(feedId string) func() {
+	s.mu.Lock()
+	if s.feedLocks == nil {
+		s.feedLocks = make(map[string]*sync.Mutex)
+	}
+	l, exists := s.feedLocks[feedId]
+	if !exists {
+		l = &sync.Mutex{}
+		s.feedLocks[feedId] = l
+	}
+	s.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
 // Checks if the items are not processed yet and whether they pub data is newer than the last run.
 func (s *Service) filterItems(ctx context.Context, feed structs.RssFeed, items ...structs.RssFeedItem) []structs.RssFeedItem {
 	logger := s.logger.With("feed_id", feed.Id)
 
+	s.mu.RLock()
+	lastRun := s.lastRun
+	s.mu.RUnlock()
+
 	var filtered []structs.RssFeedItem
 
 	for _, item := range items {
@@ -328,7 +592,7 @@ func (s *Service) filterItems(ctx context.Context, feed structs.RssFeed, items .
 			continue
 		}
 
-		if s.lastRun != nil && item.PubDate.Before(*s.lastRun) {
+		if lastRun != nil && item.PubDate.Before(*lastRun) {
 			ilogger.Debug("Skipping item published before the last run")
 			continue
 		}
@@ -342,49 +606,161 @@ func (s *Service) filterItems(ctx context.Context, feed structs.RssFeed, items .
 func (s *Service) notifyFeed(ctx context.Context, wg *sync.WaitGroup, feed structs.RssFeed, nfn structs.RssFeedNotification, items ...structs.RssFeedItem) {
 	defer wg.Done()
 
-	logger := s.logger.With("feed_id", feed.Id, "notify_type", nfn.Type)
+	name := notifierName(nfn)
+	logger := s.logger.With("feed_id", feed.Id, "notify_type", nfn.Type, "notifier", name)
 
-	if nfn.Muted {
-		logger.Debugf("Notification is muted for: '%s'", nfn.Type)
+	if nfn.Muted || s.cfg.MuteNotifications {
+		logger.Debugf("Notification is muted for: '%s'", name)
 		return
 	}
 
-	nfr, exists := s.notifiers[nfn.Type]
+	nfr, exists := s.notifier(name)
 	if !exists {
 		logger.Warnf(
 			"Notifier '%s' isn't configured. You may not have specified a notification token env.",
-			nfn.Type,
+			name,
 		)
 		return
 	}
 
+	var quiet *quietHours
+	if nfn.QuietHours != nil {
+		q, err := s.notificationQuietHours(nfn.QuietHours)
+		if err != nil {
+			logger.With("err", err.Error()).Error("Invalid quiet hours, ignoring them")
+		}
+		quiet = q
+	}
+
 	for _, item := range items {
 		ilogger := logger.With("item_id", item.Id)
 
-		if nfn.Translate.To != "" && nfn.Translate.To != item.Language {
-			tItem := s.getTranslation(item.Id, nfn.Translate.To)
-			if tItem != nil {
-				ilogger.Debug("Item translation found in cache")
-				item = *tItem
-			} else {
-				logger.Warn("Item translation not found in cache. Translating on the fly...")
-
-				if err := s.translateItem(ctx, &item, item.Language, nfn.Translate.To); err != nil {
-					ilogger.With("err", err.Error()).Errorf("Failed to translate item on the fly")
+		n := nfn
+		to, isDuplicate := s.dedupDestinations(nfn, item)
+		if isDuplicate {
+			ilogger.Debug("Item is a duplicate for all destinations, skipping")
+			continue
+		}
+		n.To = to
+
+		if quiet != nil {
+			if active, end := quiet.active(time.Now()); active {
+				switch quiet.action {
+				case structs.QuietHoursActionDrop:
+					ilogger.Debug("Quiet hours, dropping notification")
+					s.releaseDestinations(n, item)
+					continue
+				case structs.QuietHoursActionSilent:
+					ilogger.Debug("Quiet hours, sending notification silently")
+					n.Silent = true
+				default:
+					ilogger.Debugf("Quiet hours, queueing notification until %s", end.Format(time.RFC3339))
+					// Translated now, as the translations cache is cleared before the release
+					s.enqueue(queuedNotification{
+						Feed:         feed,
+						Notification: n,
+						Item:         item,
+						Translated:   s.translatedItem(ctx, ilogger, n, item),
+						Release:      end,
+					})
+					continue
 				}
 			}
 		}
 
-		ilogger.Info("Sending notification")
+		s.notifyItem(ctx, ilogger, nfr, n, item)
+	}
+}
+
+// Translates the item if required and sends the notification.
+func (s *Service) notifyItem(ctx context.Context, logger *zap.SugaredLogger, nfr notifierInstance, nfn structs.RssFeedNotification, item structs.RssFeedItem) {
+	s.sendItem(ctx, logger, nfr, nfn, item, s.translatedItem(ctx, logger, nfn, item))
+}
+
+// Returns the item translated into the notification language, from the cache if possible.
+func (s *Service) translatedItem(ctx context.Context, logger *zap.SugaredLogger, nfn structs.RssFeedNotification, item structs.RssFeedItem) structs.RssFeedItem {
+	if nfn.Translate.To == "" || nfn.Translate.To == item.Language {
+		return item
+	}
+
+	if tItem := s.getTranslation(item.Id, nfn.Translate.To); tItem != nil {
+		logger.Debug("Item translation found in cache")
+		return *tItem
+	}
+	logger.Warn("Item translation not found in cache. Translating on the fly...")
+
+	if err := s.translateItem(ctx, &item, item.Language, nfn.Translate.To); err != nil {
+		logger.With("err", err.Error()).Errorf("Failed to translate item on the fly")
+	}
+	return item
+}
+
+// Sends the notification of the translated item. Destinations claimed for the original item
+// are released if the notification fails.
+func (s *Service) sendItem(ctx context.Context, logger *zap.SugaredLogger, nfr notifierInstance, nfn structs.RssFeedNotification, claimed, item structs.RssFeedItem) {
+	req := nfr.NewRequest(nfn, &item)
+	if s.dryRun != nil {
+		s.dryRun.print(nfr.Type, nfn, item, req)
+		return
+	}
 
-		req := nfr.NewRequest(nfn, &item)
-		if err := nfr.Notify(ctx, req); err != nil {
-			logger.With("item_id", item.Id, "err", err.Error()).
-				Errorf("Failed to notify with '%s'", nfn.Type)
+	logger.Info("Sending notification")
+
+	if err := nfr.Notify(ctx, req); err != nil {
+		logger.With("item_id", item.Id, "err", err.Error()).
+			Errorf("Failed to notify with '%s'", notifierName(nfn))
+
+		// Story is delivered to other destinations, keeping their claims
+		if failed, ok := notifier.FailedDestinations(err); ok && len(nfn.To) > 0 {
+			nfn.To = failed
+		}
+		s.releaseDestinations(nfn, claimed)
+	}
+}
+
+// Returns notification destinations which haven't received the same story yet
+// and whether the item is a duplicate for all of them.
+// Should be called with an original (not translated) item.
+func (s *Service) dedupDestinations(nfn structs.RssFeedNotification, item structs.RssFeedItem) ([]string, bool) {
+	if s.dedup == nil {
+		return nfn.To, false
+	}
+
+	now := time.Now().UTC()
+
+	// Notifiers without explicit destinations (eg posting to a single account)
+	if len(nfn.To) == 0 {
+		return nil, !s.dedup.Claim(notifierName(nfn), item.Link, item.Title, now)
+	}
+
+	var result []string
+	for _, to := range nfn.To {
+		if !s.dedup.Claim(notifierName(nfn)+":"+to, item.Link, item.Title, now) {
+			s.logger.With("feed_id", item.FeedId, "item_id", item.Id, "to", to).
+				Debug("Skipping duplicate story for destination")
+			continue
 		}
+		result = append(result, to)
 	}
+	return result, len(result) == 0
 }
 
+// Releases the destinations claimed for the item when it isn't delivered,
+// so the story can still be delivered there by other feeds.
+func (s *Service) releaseDestinations(nfn structs.RssFeedNotification, item structs.RssFeedItem) {
+	if s.dedup == nil {
+		return
+	}
+	if len(nfn.To) == 0 {
+		s.dedup.Release(notifierName(nfn), item.Link, item.Title)
+		return
+	}
+	for _, to := range nfn.To {
+		s.dedup.Release(notifierName(nfn)+":"+to, item.Link, item.Title)
+	}
+}
+
+// Stores the items with their translations, so published feeds have them after the cache is cleared.
 func (s *Service) storeItems(ctx context.Context, feed structs.RssFeed, items ...structs.RssFeedItem) {
 	logger := s.logger.With("feed_id", feed.Id)
 	for _, item := range items {
@@ -399,11 +775,27 @@ func (s *Service) storeItems(ctx context.Context, feed structs.RssFeed, items ..
 			Categories:  item.Categories,
 			Title:       item.Title,
 			Description: item.Description,
+			Content:     item.Content,
+			Image:       item.Image,
+			Enclosures:  item.Enclosures,
 			PubDate:     item.PubDate,
 			Processed:   time.Now().UTC(),
 			Link:        item.Link,
 			Language:    item.Language,
 		}
+		for _, lang := range feed.GetTranslatonsLang() {
+			if ti := s.getTranslation(item.Id, lang); ti != nil {
+				if req.Translations == nil {
+					req.Translations = make(map[string]structs.RssFeedItemTranslation)
+				}
+				req.Translations[lang] = structs.RssFeedItemTranslation{
+					Title:       ti.Title,
+					Description: ti.Description,
+					Content:     ti.Content,
+					Link:        ti.Link,
+				}
+			}
+		}
 		if _, err := s.storage.FeedItems().Create(ctx, req); err != nil {
 			ilogger.With("err", err.Error()).Error("Failed to save item to storage")
 		}
@@ -1,13 +1,20 @@
 package processer
 
 import (
+	"broadcaster/services/processer/dedup"
+	"broadcaster/services/processer/notifier"
 	"broadcaster/services/processer/translator"
+	"broadcaster/storages"
 	"broadcaster/storages/memory"
 	"broadcaster/structs"
 	"broadcaster/utils/logging"
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -39,7 +46,32 @@ func testMainWrapper(m *testing.M) int {
 	return m.Run()
 }
 
-func Test_Service_parseRssFeed(t *testing.T) {
+func Test_NewService_config(t *testing.T) {
+	t.Setenv("BCTR_DEDUP", "true")
+	t.Setenv("BCTR_DEDUP_TITLE_DISTANCE", "5")
+
+	s, err := NewService(memory.NewStorage(), WithBackfillHours(3))
+	require.NoError(t, err)
+	require.NotNil(t, s.dedup)
+	require.Equal(t, 3, s.cfg.BackfillHours)
+	require.Equal(t, 5, s.cfg.DedupTitleDistance, "Env config is kept")
+
+	s, err = NewService(memory.NewStorage(), WithConfig(&Config{
+		TranslatorType:    TranslationTypeMock,
+		MuteNotifications: true,
+		FeedMaxFailures:   5,
+		FeedBackoff:       600,
+		FeedMaxBackoff:    600,
+	}))
+	require.NoError(t, err)
+	require.Nil(t, s.dedup, "Zero values override env config")
+	require.Zero(t, s.cfg.DedupTitleDistance)
+
+	_, err = NewService(memory.NewStorage(), WithConfig(&Config{TranslatorType: TranslationTypeMock}))
+	require.Error(t, err, "Replaced config is validated")
+}
+
+func Test_Service_parseFeed(t *testing.T) {
 	ctx := context.TODO()
 
 	t.Run("nonExistsRss", func(t *testing.T) {
@@ -47,7 +79,7 @@ func Test_Service_parseRssFeed(t *testing.T) {
 			Id:  "nonExistsRss",
 			URL: "https://fakeurl.local/rss.xml",
 		}
-		items, err := tservice.parseRssFeed(ctx, feed, 2*time.Second)
+		items, err := tservice.parseFeed(ctx, feed, 2*time.Second)
 		require.Error(t, err)
 		require.Nil(t, items)
 	})
@@ -59,7 +91,7 @@ func Test_Service_parseRssFeed(t *testing.T) {
 			URL:      "https://www.hs.fi/rss/helsinki.xml",
 			Language: "fi",
 		}
-		items, err := tservice.parseRssFeed(ctx, feed, 10*time.Second)
+		items, err := tservice.parseFeed(ctx, feed, 10*time.Second)
 		require.NoError(t, err)
 		require.NotNil(t, items)
 
@@ -80,7 +112,7 @@ func Test_Service_parseRssFeed(t *testing.T) {
 			URL:        "https://www.hs.fi/rss/helsinki.xml",
 			ItemsLimit: 1,
 		}
-		items, err := tservice.parseRssFeed(ctx, feed, 10*time.Second)
+		items, err := tservice.parseFeed(ctx, feed, 10*time.Second)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(items), "Items limit should be respected")
 		require.NotEmpty(t, items[0])
@@ -170,3 +202,316 @@ func Test_Service_translateItem(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func Test_Service_storeItems_translations(t *testing.T) {
+	ctx := context.Background()
+
+	st := memory.NewStorage()
+	s := &Service{
+		logger:       tservice.logger,
+		storage:      st,
+		mu:           &sync.RWMutex{},
+		translations: make(map[string]map[string]structs.RssFeedItem),
+	}
+
+	feed := structs.RssFeed{Id: "news", Notifications: []structs.RssFeedNotification{{Type: "test", Translate: structs.RssFeedTranslation{To: "en"}}}}
+	item := structs.RssFeedItem{Id: "news/1", Title: "Hei", Link: "https://example.com/1", Language: "fi"}
+	untranslated := structs.RssFeedItem{Id: "news/2", Title: "Moi", Language: "fi"}
+
+	translated := item
+	translated.Title = "Hi"
+	translated.Language = "en"
+	s.saveTranslation(translated, "en")
+
+	s.storeItems(ctx, feed, item, untranslated)
+
+	// Translations are kept after the cache is cleared
+	s.translations = make(map[string]map[string]structs.RssFeedItem)
+
+	stored, err := st.FeedItems().Find(ctx, storages.FeedItemsStorageFindRequest{Id: "news/1"})
+	require.NoError(t, err)
+	result, ok := stored.Translated("en")
+	require.True(t, ok)
+	require.Equal(t, "Hi", result.Title)
+	require.Equal(t, "en", result.Language)
+
+	stored, err = st.FeedItems().Find(ctx, storages.FeedItemsStorageFindRequest{Id: "news/2"})
+	require.NoError(t, err)
+	_, ok = stored.Translated("en")
+	require.False(t, ok)
+}
+
+func Test_Service_dedupDestinations(t *testing.T) {
+	s := &Service{
+		logger: tservice.logger,
+		dedup:  dedup.New(time.Hour, 10),
+	}
+
+	nfn := structs.RssFeedNotification{
+		Type: "slack",
+		To:   []string{"#news", "#world"},
+	}
+	first := structs.RssFeedItem{
+		Id:    "first",
+		Title: "Finland joins NATO as 31st member of the alliance",
+		Link:  "https://first.example.com/nato?utm_source=rss",
+	}
+	second := structs.RssFeedItem{
+		Id:    "second",
+		Title: "Finland officially joins NATO as 31st member of the alliance",
+		Link:  "https://second.example.com/world/nato",
+	}
+
+	to, isDuplicate := s.dedupDestinations(nfn, first)
+	require.False(t, isDuplicate)
+	require.Equal(t, nfn.To, to)
+
+	to, isDuplicate = s.dedupDestinations(structs.RssFeedNotification{Type: "slack", To: []string{"#world", "#other"}}, second)
+	require.False(t, isDuplicate)
+	require.Equal(t, []string{"#other"}, to, "Only destinations without the story should remain")
+
+	_, isDuplicate = s.dedupDestinations(nfn, second)
+	require.True(t, isDuplicate)
+
+	to, isDuplicate = tservice.dedupDestinations(nfn, first)
+	require.False(t, isDuplicate, "Deduplication is disabled by default")
+	require.Equal(t, nfn.To, to)
+}
+
+// Fails to send notifications.
+type failingNotifier struct {
+	messageNotifier
+}
+
+func (n *failingNotifier) Notify(ctx context.Context, r notifier.NotificationRequest) error {
+	n.sent.Add(1)
+	return errors.New("Service unavailable")
+}
+
+func Test_Service_notifyFeed_dedupFailure(t *testing.T) {
+	ctx := context.Background()
+
+	failing, working := &failingNotifier{}, &messageNotifier{}
+	s := &Service{
+		cfg:    &Config{},
+		logger: tservice.logger,
+		dedup:  dedup.New(time.Hour, 10),
+		notifiers: map[string]notifierInstance{
+			"failing": {Type: "slack", Notifier: failing},
+			"working": {Type: "slack", Notifier: working},
+		},
+		mu: &sync.RWMutex{},
+	}
+	item := structs.RssFeedItem{Id: "first/1", Title: "Story", Link: "https://example.com/story"}
+
+	notify := func(name string, item structs.RssFeedItem) {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		s.notifyFeed(ctx, &wg, structs.RssFeed{Id: "feed"}, structs.RssFeedNotification{Notifier: name, To: []string{"#news"}}, item)
+		wg.Wait()
+	}
+
+	notify("failing", item)
+	require.Equal(t, int32(1), failing.sent.Load())
+
+	item.Id = "second/1"
+	notify("failing", item)
+	require.Equal(t, int32(2), failing.sent.Load(), "Failed deliveries don't claim the story")
+
+	notify("working", item)
+	notify("working", item)
+	require.Equal(t, int32(1), working.sent.Load(), "Delivered stories are claimed")
+}
+
+// Fails to send notifications to the destination.
+type destinationFailingNotifier struct {
+	messageNotifier
+	failing string
+}
+
+func (n *destinationFailingNotifier) Notify(ctx context.Context, r notifier.NotificationRequest) error {
+	var errs []error
+	for _, to := range r.To {
+		if to == n.failing {
+			errs = append(errs, &notifier.DestinationError{To: to, Err: errors.New("Channel not found")})
+			continue
+		}
+		n.sent.Add(1)
+	}
+	return errors.Join(errs...)
+}
+
+func Test_Service_notifyFeed_dedupDestinationFailure(t *testing.T) {
+	ctx := context.Background()
+
+	nfr := &destinationFailingNotifier{failing: "#missing"}
+	s := &Service{
+		cfg:       &Config{},
+		logger:    tservice.logger,
+		dedup:     dedup.New(time.Hour, 10),
+		notifiers: map[string]notifierInstance{"slack": {Type: "slack", Notifier: nfr}},
+		mu:        &sync.RWMutex{},
+	}
+	nfn := structs.RssFeedNotification{Type: "slack", To: []string{"#news", "#missing"}}
+	item := structs.RssFeedItem{Id: "first/1", Title: "Story", Link: "https://example.com/story"}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	s.notifyFeed(ctx, &wg, structs.RssFeed{Id: "feed"}, nfn, item)
+	wg.Wait()
+	require.Equal(t, int32(1), nfr.sent.Load())
+
+	item.Id = "second/1"
+	to, isDuplicate := s.dedupDestinations(nfn, item)
+	require.False(t, isDuplicate)
+	require.Equal(t, []string{"#missing"}, to, "Only failed destinations are released")
+}
+
+func Test_Service_loadNotifiers(t *testing.T) {
+	t.Setenv("BCTR_NTFY_URL", "https://ntfy.example.com")
+	t.Setenv("BCTR_NTFY_TOPIC", "news")
+	t.Setenv("NTFY_ALERTS_TOKEN", "tk_alerts")
+
+	s := &Service{logger: tservice.logger}
+	notifiers, err := s.loadNotifiers([]storages.NotifierConfig{
+		{Name: "alerts", Type: "ntfy", Config: map[string]interface{}{"token": "env:NTFY_ALERTS_TOKEN"}},
+	})
+	require.NoError(t, err)
+
+	require.Contains(t, notifiers, "ntfy", "Default instance is created from env")
+	require.Contains(t, notifiers, "alerts")
+	require.Equal(t, "ntfy", notifiers["alerts"].Type)
+	require.NotContains(t, notifiers, "telegram", "Telegram isn't configured in env")
+
+	feed := func(nfns ...structs.RssFeedNotification) []structs.RssFeed {
+		return []structs.RssFeed{{Id: "feed", Notifications: nfns}}
+	}
+
+	require.NoError(t, validateNotifications(notifiers, feed(
+		structs.RssFeedNotification{Type: "ntfy", To: []string{"news"}},
+		structs.RssFeedNotification{Notifier: "alerts", To: []string{"alerts"}},
+		structs.RssFeedNotification{Type: "ntfy", Notifier: "alerts"},
+		structs.RssFeedNotification{Type: "telegram", Muted: true},
+	)))
+
+	err = validateNotifications(notifiers, feed(structs.RssFeedNotification{Type: "telegram", To: []string{"-1"}}))
+	require.ErrorContains(t, err, "unknown notifier 'telegram'")
+
+	err = validateNotifications(notifiers, feed(structs.RssFeedNotification{Notifier: "missing"}))
+	require.ErrorContains(t, err, "unknown notifier 'missing'")
+
+	err = validateNotifications(notifiers, feed(structs.RssFeedNotification{Type: "slack", Notifier: "alerts"}))
+	require.ErrorContains(t, err, "type is ntfy, not slack")
+
+	err = validateNotifications(notifiers, feed(structs.RssFeedNotification{}))
+	require.ErrorContains(t, err, "type or notifier is required")
+
+	_, err = s.loadNotifiers([]storages.NotifierConfig{{Name: "broken", Type: "ntfy", Config: map[string]interface{}{"tokn": "x"}}})
+	require.ErrorContains(t, err, "Failed to init notifier 'broken'")
+}
+
+func Test_Service_Reload(t *testing.T) {
+	s := &Service{
+		cfg:       &Config{},
+		logger:    tservice.logger,
+		notifiers: map[string]notifierInstance{},
+		mu:        &sync.RWMutex{},
+	}
+
+	configs := []storages.NotifierConfig{{Name: "alerts", Type: "ntfy"}}
+	feeds := []structs.RssFeed{{Id: "feed", Notifications: []structs.RssFeedNotification{{Notifier: "alerts"}}}}
+	require.NoError(t, s.Reload(configs, feeds))
+	_, exists := s.notifier("alerts")
+	require.True(t, exists)
+
+	// Invalid configs don't replace the current instances
+	err := s.Reload(nil, feeds)
+	require.ErrorContains(t, err, "unknown notifier 'alerts'")
+	_, exists = s.notifier("alerts")
+	require.True(t, exists)
+
+	err = s.Reload([]storages.NotifierConfig{{Name: "alerts", Type: "unknown"}}, feeds)
+	require.Error(t, err)
+	_, exists = s.notifier("alerts")
+	require.True(t, exists)
+
+	gotify := []storages.NotifierConfig{{Name: "phone", Type: "gotify", Config: map[string]interface{}{"url": "https://gotify.example.com", "app_token": "secret"}}}
+	err = s.Reload(gotify, []structs.RssFeed{{Id: "feed", Notifications: []structs.RssFeedNotification{{Notifier: "phone", To: []string{"token"}}}}})
+	require.ErrorContains(t, err, "destinations aren't supported by gotify notifiers")
+}
+
+func Test_Service_fetchContent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/article" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><meta property="og:image" content="/lead.jpg"></head>`+
+			`<body><article><p>Full article text which is long enough to be extracted.</p></article></body></html>`)
+	}))
+	defer srv.Close()
+
+	items := []structs.RssFeedItem{
+		{Id: "withContent", Link: srv.URL + "/article"},
+		{Id: "withImage", Link: srv.URL + "/article", Image: "https://example.com/feed.jpg"},
+		{Id: "notFound", Link: srv.URL + "/missing", Description: "Teaser"},
+		{Id: "noLink"},
+	}
+	tservice.fetchContent(context.TODO(), structs.RssFeed{Id: "fetchContent"}, items)
+
+	require.Equal(t, "Full article text which is long enough to be extracted.", items[0].Content)
+	require.Equal(t, srv.URL+"/lead.jpg", items[0].Image)
+	require.Equal(t, "https://example.com/feed.jpg", items[1].Image, "Item image should be kept")
+	require.Empty(t, items[2].Content)
+	require.Equal(t, "Teaser", items[2].Description)
+	require.Empty(t, items[3].Content)
+}
+
+func Test_Service_parseFeed_html(t *testing.T) {
+	page, err := os.ReadFile("extractor/testdata/news.html")
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/city" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write(page)
+	}))
+	defer srv.Close()
+
+	feed := structs.RssFeed{
+		Id:         "htmlFeed",
+		Source:     "City",
+		URL:        srv.URL + "/city",
+		Type:       structs.FeedTypeHTML,
+		Language:   "en",
+		ItemsLimit: 2,
+		HTML: &structs.HTMLSelectors{
+			Item:        "article.news",
+			Title:       "h2",
+			Date:        "time",
+			Description: ".summary",
+		},
+	}
+	items, err := tservice.parseFeed(context.TODO(), feed, 5*time.Second)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	require.Equal(t, "htmlFeed/"+srv.URL+"/news/1-tram-line", items[0].Id, "Items are identified by links")
+	require.Equal(t, feed.Id, items[0].FeedId)
+	require.Equal(t, "New tram line opens in the city centre", items[0].Title)
+	require.Equal(t, "The line connects the railway station with the harbour.", items[0].Description)
+	require.Equal(t, "en", items[0].Language)
+	require.Equal(t, time.Date(2024, 5, 1, 6, 30, 0, 0, time.UTC), items[0].PubDate)
+
+	feed.URL = srv.URL + "/missing"
+	_, err = tservice.parseFeed(context.TODO(), feed, 5*time.Second)
+	require.Error(t, err)
+
+	feed.HTML = nil
+	_, err = tservice.parseFeed(context.TODO(), feed, 5*time.Second)
+	require.Error(t, err)
+}
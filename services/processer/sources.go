@@ -0,0 +1,157 @@
+package processer
+
+import (
+	"broadcaster/services/processer/extractor"
+	"broadcaster/storages"
+	"broadcaster/structs"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// Source of feeds items of some type: RSS/Atom feeds, HTML pages, JSON APIs, sitemaps.
+type FeedSource interface {
+	// Fetches the feed and returns its items.
+	Fetch(ctx context.Context, feed structs.RssFeed) (*gofeed.Feed, error)
+}
+
+// Adapter to use ordinary functions as feeds sources.
+type FeedSourceFunc func(ctx context.Context, feed structs.RssFeed) (*gofeed.Feed, error)
+
+func (f FeedSourceFunc) Fetch(ctx context.Context, feed structs.RssFeed) (*gofeed.Feed, error) {
+	return f(ctx, feed)
+}
+
+// Sets the source of the feeds type, replacing the built-in one.
+func WithFeedSource(typ structs.FeedType, src FeedSource) Option {
+	return func(s *Service) {
+		if s.sources == nil {
+			s.sources = make(map[structs.FeedType]FeedSource)
+		}
+		s.sources[typ] = src
+	}
+}
+
+// Returns the source of the feed type. Feeds without type are RSS/Atom feeds.
+func (s *Service) feedSource(typ structs.FeedType) (FeedSource, error) {
+	if typ == "" {
+		typ = structs.FeedTypeRSS
+	}
+	if src, exists := s.sources[typ]; exists {
+		return src, nil
+	}
+	switch typ {
+	case structs.FeedTypeRSS:
+		return FeedSourceFunc(s.fetchRssFeed), nil
+	case structs.FeedTypeHTML:
+		return FeedSourceFunc(s.fetchHtmlFeed), nil
+	case structs.FeedTypeJSON:
+		return FeedSourceFunc(s.fetchJSONFeed), nil
+	case structs.FeedTypeSitemap:
+		return FeedSourceFunc(s.fetchSitemapFeed), nil
+	default:
+		return nil, fmt.Errorf("Unsupported feed type '%s'", typ)
+	}
+}
+
+// Fetches the feed from its source and returns a list of converted items.
+func (s *Service) parseFeed(ctx context.Context, feed structs.RssFeed, timeout time.Duration) ([]structs.RssFeedItem, error) {
+	src, err := s.feedSource(feed.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.With("feed_id", feed.Id, "type", feed.Type).Debug("Parsing feed")
+
+	pCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	parsedFeed, err := src.Fetch(pCtx, feed)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.websub != nil {
+		s.subscribeHub(ctx, feed, parsedFeed)
+	}
+
+	return s.feedItems(feed, parsedFeed), nil
+}
+
+// Parses the RSS/Atom feed. Convenience URLs ('youtube:channel/<id>') are expanded for feeds created by the API.
+func (s *Service) fetchRssFeed(ctx context.Context, feed structs.RssFeed) (*gofeed.Feed, error) {
+	feedURL, err := storages.ExpandFeedURL(feed.URL)
+	if err != nil {
+		return nil, err
+	}
+	return newFeedParser().ParseURLWithContext(feedURL, ctx)
+}
+
+// Scrapes the feed HTML page.
+func (s *Service) fetchHtmlFeed(ctx context.Context, feed structs.RssFeed) (*gofeed.Feed, error) {
+	if feed.HTML == nil {
+		return nil, errors.New("HTML selectors aren't set")
+	}
+
+	scraped, err := s.extractor.ExtractItems(ctx, feed.URL, htmlSelectors(feed.HTML))
+	if err != nil {
+		return nil, err
+	}
+	// Pages items have no GUIDs, links identify them
+	for _, item := range scraped {
+		item.GUID = item.Link
+	}
+
+	return &gofeed.Feed{Items: scraped}, nil
+}
+
+// Extracts the feed items from the JSON API response.
+func (s *Service) fetchJSONFeed(ctx context.Context, feed structs.RssFeed) (*gofeed.Feed, error) {
+	if feed.JSON == nil {
+		return nil, errors.New("JSON mapping isn't set")
+	}
+
+	items, err := s.extractor.ExtractJSONItems(ctx, feed.URL, jsonMapping(feed.JSON))
+	if err != nil {
+		return nil, err
+	}
+
+	return &gofeed.Feed{Items: items}, nil
+}
+
+// Extracts the feed items from the news sitemap.
+func (s *Service) fetchSitemapFeed(ctx context.Context, feed structs.RssFeed) (*gofeed.Feed, error) {
+	items, err := s.extractor.ExtractSitemapItems(ctx, feed.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gofeed.Feed{Items: items}, nil
+}
+
+func htmlSelectors(h *structs.HTMLSelectors) extractor.Selectors {
+	return extractor.Selectors{
+		Item:        h.Item,
+		Title:       h.Title,
+		Link:        h.Link,
+		Date:        h.Date,
+		DateFormat:  h.DateFormat,
+		Description: h.Description,
+	}
+}
+
+func jsonMapping(m *structs.JSONMapping) extractor.JSONMapping {
+	return extractor.JSONMapping{
+		Items:       m.Items,
+		Id:          m.Id,
+		Title:       m.Title,
+		Link:        m.Link,
+		Date:        m.Date,
+		DateFormat:  m.DateFormat,
+		Description: m.Description,
+		Image:       m.Image,
+	}
+}
@@ -0,0 +1,96 @@
+package processer
+
+import (
+	"broadcaster/storages/memory"
+	"broadcaster/structs"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Service_parseFeed_sources(t *testing.T) {
+	ctx := context.TODO()
+
+	mux := http.NewServeMux()
+	for path, name := range map[string]string{"/api/news": "news.json", "/news-sitemap.xml": "news-sitemap.xml"} {
+		data, err := os.ReadFile("extractor/testdata/" + name)
+		require.NoError(t, err)
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Write(data)
+		})
+	}
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Run("JSON", func(t *testing.T) {
+		feed := structs.RssFeed{
+			Id:   "jsonFeed",
+			URL:  srv.URL + "/api/news",
+			Type: structs.FeedTypeJSON,
+			JSON: &structs.JSONMapping{
+				Items: "$.data.articles[*]",
+				Id:    "id",
+				Title: "headline",
+				Link:  "url",
+				Date:  "published_at",
+			},
+		}
+		items, err := tservice.parseFeed(ctx, feed, 5*time.Second)
+		require.NoError(t, err)
+		require.Len(t, items, 3)
+		require.Equal(t, "jsonFeed/101", items[0].Id)
+		require.Equal(t, srv.URL+"/news/101-tram-line", items[0].Link)
+		require.Equal(t, time.Date(2024, 5, 1, 6, 30, 0, 0, time.UTC), items[0].PubDate)
+
+		feed.JSON = nil
+		_, err = tservice.parseFeed(ctx, feed, 5*time.Second)
+		require.Error(t, err)
+	})
+
+	t.Run("Sitemap", func(t *testing.T) {
+		feed := structs.RssFeed{
+			Id:         "sitemapFeed",
+			URL:        srv.URL + "/news-sitemap.xml",
+			Type:       structs.FeedTypeSitemap,
+			ItemsLimit: 2,
+		}
+		items, err := tservice.parseFeed(ctx, feed, 5*time.Second)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.Equal(t, "sitemapFeed/https://example.com/news/1-tram-line", items[0].Id)
+		require.Equal(t, "New tram line opens in the city centre", items[0].Title)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := tservice.parseFeed(ctx, structs.RssFeed{Id: "gopher", Type: "gopher"}, time.Second)
+		require.ErrorContains(t, err, "Unsupported feed type")
+	})
+
+	t.Run("InvalidAlias", func(t *testing.T) {
+		_, err := tservice.parseFeed(ctx, structs.RssFeed{Id: "yt", URL: "youtube:user/name"}, time.Second)
+		require.ErrorContains(t, err, "Invalid YouTube feed")
+	})
+}
+
+func Test_WithFeedSource(t *testing.T) {
+	var fetched []string
+	src := FeedSourceFunc(func(ctx context.Context, feed structs.RssFeed) (*gofeed.Feed, error) {
+		fetched = append(fetched, feed.Id)
+		return &gofeed.Feed{Items: []*gofeed.Item{{GUID: "1", Title: "Custom"}}}, nil
+	})
+
+	s, err := NewService(memory.NewStorage(), WithLogger(tservice.logger), WithFeedSource(structs.FeedTypeRSS, src))
+	require.NoError(t, err)
+
+	items, err := s.parseFeed(context.TODO(), structs.RssFeed{Id: "custom", URL: "https://example.com/rss"}, time.Second)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "Custom", items[0].Title)
+	require.Equal(t, []string{"custom"}, fetched, "Feeds without type use the RSS source")
+}
@@ -0,0 +1,106 @@
+package processer
+
+import (
+	"broadcaster/storages"
+	"broadcaster/structs"
+	"context"
+	"time"
+)
+
+// Consecutive fetch failures of the feed.
+type feedFailures struct {
+	Count   int
+	RetryAt time.Time // Next fetch of the erroring feed
+}
+
+// Checks whether the feed should be processed by its status.
+func (s *Service) shouldProcess(feed structs.RssFeed, now time.Time) bool {
+	logger := s.logger.With("feed_id", feed.Id)
+
+	switch feed.Status {
+	case structs.FeedStatusDisabled, structs.FeedStatusPaused:
+		logger.Debugf("Feed is %s, skipping", feed.Status)
+		return false
+	case structs.FeedStatusErroring:
+		s.mu.RLock()
+		f := s.failures[feed.Id]
+		s.mu.RUnlock()
+
+		if f != nil && now.Before(f.RetryAt) {
+			logger.Debugf("Feed is erroring, skipping until %s", f.RetryAt.Format(time.RFC3339))
+			return false
+		}
+	default:
+		// Erroring feed is resumed with the API, failures are counted from scratch
+		s.mu.Lock()
+		if f := s.failures[feed.Id]; f != nil && f.Count >= s.cfg.FeedMaxFailures {
+			delete(s.failures, feed.Id)
+		}
+		s.mu.Unlock()
+	}
+	return true
+}
+
+// Records the feed fetch result. Marks the feed as erroring after the max consecutive failures
+// and doubles the retry delay with each next failure. Successful fetch makes the feed active again.
+func (s *Service) recordFeedResult(ctx context.Context, feed structs.RssFeed, err error) {
+	logger := s.logger.With("feed_id", feed.Id)
+
+	if err == nil {
+		s.mu.Lock()
+		delete(s.failures, feed.Id)
+		s.mu.Unlock()
+
+		if feed.Status == structs.FeedStatusErroring {
+			logger.Info("Feed is recovered")
+			s.updateFeedStatus(ctx, feed, structs.FeedStatusActive, "", structs.FeedStatusErroring)
+		}
+		return
+	}
+
+	s.mu.Lock()
+	f, exists := s.failures[feed.Id]
+	if !exists {
+		f = &feedFailures{}
+		s.failures[feed.Id] = f
+	}
+	f.Count++
+	count := f.Count
+	if count >= s.cfg.FeedMaxFailures {
+		f.RetryAt = time.Now().Add(s.feedBackoff(count))
+	}
+	retryAt := f.RetryAt
+	s.mu.Unlock()
+
+	if count < s.cfg.FeedMaxFailures {
+		return
+	}
+
+	logger.With("failures", count).Warnf("Feed is erroring, retrying at %s", retryAt.Format(time.RFC3339))
+	s.updateFeedStatus(ctx, feed, structs.FeedStatusErroring, err.Error(), structs.FeedStatusActive, structs.FeedStatusErroring)
+}
+
+// Returns the retry delay after the failures count: the initial backoff doubled with each failure after the max ones.
+func (s *Service) feedBackoff(failures int) time.Duration {
+	backoff := time.Duration(s.cfg.FeedBackoff) * time.Second
+	maxBackoff := time.Duration(s.cfg.FeedMaxBackoff) * time.Second
+
+	for i := s.cfg.FeedMaxFailures; i < failures && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff)
+}
+
+// Updates the feed status if the current one is any of the statuses.
+// Feed is fetched without the storage lock, so the status paused or disabled meanwhile is kept.
+func (s *Service) updateFeedStatus(ctx context.Context, feed structs.RssFeed, status structs.FeedStatus, message string, from ...structs.FeedStatus) {
+	req := storages.FeedsStorageUpdateRequest{
+		Id:            feed.Id,
+		Status:        status,
+		StatusMessage: message,
+		IfStatus:      from,
+	}
+	if _, err := s.storage.Feeds().Update(ctx, req); err != nil {
+		s.logger.With("feed_id", feed.Id, "err", err.Error()).Error("Failed to update feed status")
+	}
+}
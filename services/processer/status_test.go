@@ -0,0 +1,106 @@
+package processer
+
+import (
+	"broadcaster/storages"
+	"broadcaster/storages/memory"
+	"broadcaster/structs"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Service_feedStatuses(t *testing.T) {
+	ctx := context.Background()
+
+	st := memory.NewStorage()
+	require.NoError(t, st.BootstrapFromConfig(ctx, &storages.BootstrapConfig{Feeds: []storages.FeedConfig{{Source: "One"}}}))
+
+	s := &Service{
+		cfg:      &Config{FeedMaxFailures: 2, FeedBackoff: 60, FeedMaxBackoff: 300},
+		logger:   tservice.logger,
+		storage:  st,
+		mu:       &sync.RWMutex{},
+		failures: make(map[string]*feedFailures),
+	}
+	feed := func() structs.RssFeed {
+		f, err := st.Feeds().Find(ctx, storages.FeedsStorageFindRequest{Id: "One"})
+		require.NoError(t, err)
+		return *f
+	}
+	fetchErr := errors.New("connection refused")
+
+	require.True(t, s.shouldProcess(feed(), time.Now()))
+
+	s.recordFeedResult(ctx, feed(), fetchErr)
+	require.Equal(t, structs.FeedStatusActive, feed().Status, "Single failure doesn't change the status")
+
+	s.recordFeedResult(ctx, feed(), fetchErr)
+	require.Equal(t, structs.FeedStatusErroring, feed().Status)
+	require.Equal(t, "connection refused", feed().StatusMessage)
+
+	require.False(t, s.shouldProcess(feed(), time.Now()), "Erroring feed is skipped until the retry")
+	require.True(t, s.shouldProcess(feed(), time.Now().Add(61*time.Second)))
+
+	s.recordFeedResult(ctx, feed(), fetchErr)
+	require.False(t, s.shouldProcess(feed(), time.Now().Add(61*time.Second)), "Backoff is doubled")
+	require.True(t, s.shouldProcess(feed(), time.Now().Add(121*time.Second)))
+
+	s.recordFeedResult(ctx, feed(), nil)
+	require.Equal(t, structs.FeedStatusActive, feed().Status)
+	require.Empty(t, feed().StatusMessage)
+	require.Empty(t, s.failures)
+
+	for _, status := range []structs.FeedStatus{structs.FeedStatusPaused, structs.FeedStatusDisabled} {
+		require.False(t, s.shouldProcess(structs.RssFeed{Id: "One", Status: status}, time.Now()))
+	}
+}
+
+func Test_Service_recordFeedResult_paused(t *testing.T) {
+	ctx := context.Background()
+
+	st := memory.NewStorage()
+	require.NoError(t, st.BootstrapFromConfig(ctx, &storages.BootstrapConfig{Feeds: []storages.FeedConfig{{Source: "One"}}}))
+
+	s := &Service{
+		cfg:      &Config{FeedMaxFailures: 1, FeedBackoff: 60, FeedMaxBackoff: 300},
+		logger:   tservice.logger,
+		storage:  st,
+		mu:       &sync.RWMutex{},
+		failures: make(map[string]*feedFailures),
+	}
+	status := func() structs.FeedStatus {
+		f, err := st.Feeds().Find(ctx, storages.FeedsStorageFindRequest{Id: "One"})
+		require.NoError(t, err)
+		return f.Status
+	}
+	setStatus := func(status structs.FeedStatus) {
+		_, err := st.Feeds().Update(ctx, storages.FeedsStorageUpdateRequest{Id: "One", Status: status})
+		require.NoError(t, err)
+	}
+
+	// Feed is paused with the API while it's fetched
+	setStatus(structs.FeedStatusPaused)
+	s.recordFeedResult(ctx, structs.RssFeed{Id: "One", Status: structs.FeedStatusActive}, errors.New("connection refused"))
+	require.Equal(t, structs.FeedStatusPaused, status())
+
+	s.recordFeedResult(ctx, structs.RssFeed{Id: "One", Status: structs.FeedStatusErroring}, nil)
+	require.Equal(t, structs.FeedStatusPaused, status())
+
+	setStatus(structs.FeedStatusDisabled)
+	s.recordFeedResult(ctx, structs.RssFeed{Id: "One", Status: structs.FeedStatusErroring}, nil)
+	require.Equal(t, structs.FeedStatusDisabled, status())
+}
+
+func Test_Service_feedBackoff(t *testing.T) {
+	s := &Service{cfg: &Config{FeedMaxFailures: 3, FeedBackoff: 60, FeedMaxBackoff: 300}}
+
+	require.Equal(t, time.Minute, s.feedBackoff(3))
+	require.Equal(t, 2*time.Minute, s.feedBackoff(4))
+	require.Equal(t, 4*time.Minute, s.feedBackoff(5))
+	require.Equal(t, 5*time.Minute, s.feedBackoff(6))
+	require.Equal(t, 5*time.Minute, s.feedBackoff(100))
+}
@@ -8,6 +8,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -37,14 +38,18 @@ func NewGoogleApiTranslator() *GoogleApiTranslator {
 func (t *GoogleApiTranslator) Translate(ctx context.Context, r TranlsationRequest) (*TranlsationResponce, error) {
 	var translates []string
 	for _, text := range r.Text {
-		ts, err := t.translate(ctx, r.From, r.To, text)
-		if err != nil {
-			return nil, fmt.Errorf("Failed to translate text: %w", err)
+		var parts []string
+		for _, chunk := range splitText(text, googleApiMaxTextLen) {
+			ts, err := t.translate(ctx, r.From, r.To, chunk)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to translate text: %w", err)
+			}
+			parts = append(parts, ts)
 		}
-		translates = append(translates, ts)
+		translates = append(translates, strings.Join(parts, "\n\n"))
 	}
 
-	if len(translates) != 2 {
+	if len(translates) < 2 || len(translates) > 3 {
 		return nil, fmt.Errorf("Unexpected number of translations: %d", len(translates))
 	}
 
@@ -57,9 +62,44 @@ func (t *GoogleApiTranslator) Translate(ctx context.Context, r TranlsationReques
 		),
 	}
 
+	if len(translates) > 2 {
+		result.Content = translates[2]
+	}
+
 	return result, nil
 }
 
+// Maximum text length translated with a single request, longer texts (eg articles content)
+// are translated by paragraphs to keep request URL short.
+const googleApiMaxTextLen = 1500
+
+// Splits text into chunks not longer than the limit by paragraphs.
+// Paragraphs longer than the limit are kept as is.
+func splitText(text string, limit int) []string {
+	if len(text) <= limit {
+		return []string{text}
+	}
+
+	var (
+		result  []string
+		current string
+	)
+	for _, p := range strings.Split(text, "\n\n") {
+		if current != "" && len(current)+len(p)+2 > limit {
+			result = append(result, current)
+			current = ""
+		}
+		if current != "" {
+			current += "\n\n"
+		}
+		current += p
+	}
+	if current != "" {
+		result = append(result, current)
+	}
+	return result
+}
+
 func (t *GoogleApiTranslator) translate(ctx context.Context, from, to, text string) (string, error) {
 	uri := fmt.Sprintf(
 		"https://translate.googleapis.com/translate_a/single?client=gtx&sl=%s&tl=%s&dt=t&q=%s",
@@ -52,3 +52,13 @@ func Test_GoogleApiTranslator(t *testing.T) {
 		require.Equal(t, "Lundi", resp.Description)
 	})
 }
+
+func Test_splitText(t *testing.T) {
+	require.Equal(t, []string{"short"}, splitText("short", 10))
+	require.Equal(t, []string{""}, splitText("", 10))
+	require.Equal(
+		t,
+		[]string{"one\n\ntwo", "three", "longparagraph"},
+		splitText("one\n\ntwo\n\nthree\n\nlongparagraph", 10),
+	)
+}
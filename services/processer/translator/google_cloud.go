@@ -58,6 +58,10 @@ func (t *GoogleCloudTranslator) Translate(ctx context.Context, r TranlsationRequ
 		result = append(result, t.GetTranslatedText())
 	}
 
+	if len(result) < 2 {
+		return nil, fmt.Errorf("Unexpected number of translations: %d", len(result))
+	}
+
 	item := &TranlsationResponce{
 		Title:       result[0],
 		Description: result[1],
@@ -66,5 +70,8 @@ func (t *GoogleCloudTranslator) Translate(ctx context.Context, r TranlsationRequ
 			r.From, r.To, r.Link,
 		),
 	}
+	if len(result) > 2 {
+		item.Content = result[2]
+	}
 	return item, nil
 }
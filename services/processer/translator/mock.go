@@ -22,9 +22,13 @@ func (t *MockTranslator) Translate(ctx context.Context, r TranlsationRequest) (*
 	if r.Link == MockURLForError {
 		return nil, errors.New("mock error")
 	}
-	return &TranlsationResponce{
+	resp := &TranlsationResponce{
 		Title:       r.Text[0],
 		Description: r.Text[1],
 		Link:        r.Link,
-	}, nil
+	}
+	if len(r.Text) > 2 {
+		resp.Content = r.Text[2]
+	}
+	return resp, nil
 }
@@ -12,11 +12,12 @@ type TranlsationRequest struct {
 	Link string   // Link to the original article
 	From string   // Language code of the original article
 	To   string   // Language code to translate to
-	Text []string // Text to translate
+	Text []string // Text to translate: title, description and optional content
 }
 
 type TranlsationResponce struct {
 	Title       string
 	Description string
+	Content     string
 	Link        string
 }
@@ -0,0 +1,89 @@
+package processer
+
+import (
+	"broadcaster/services/processer/notifier"
+	"broadcaster/storages"
+	"sort"
+)
+
+func init() {
+	storages.RegisterConfigValidator(validateBootstrapConfig)
+}
+
+// Checks notifiers instances settings and feeds notifications against registered notifiers types.
+// Default instances from env aren't required, so notifications can reference any registered type.
+func validateBootstrapConfig(c *storages.BootstrapConfig) storages.ConfigProblems {
+	var problems storages.ConfigProblems
+
+	instances := make(map[string]string, len(c.Notifiers)) // Name -> type
+	for _, typ := range notifier.Types() {
+		instances[typ] = typ
+	}
+
+	for _, nc := range c.Notifiers {
+		if nc.Type == "" {
+			continue
+		}
+		instances[nc.Name] = nc.Type
+
+		schema, ok := notifier.SchemaOf(nc.Type)
+		if !ok {
+			problems.Add(nc.Pos, "Notifier '%s' type '%s' is unknown", nc.Name, nc.Type)
+			continue
+		}
+		keys := make([]string, 0, len(nc.Config))
+		for key := range nc.Config {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if _, ok := schema.Field(key); !ok {
+				problems.Add(nc.Pos, "Notifier '%s' setting '%s' is unknown for %s notifiers", nc.Name, key, nc.Type)
+			}
+		}
+	}
+
+	for _, fc := range c.Feeds {
+		feed := fc.ToRssFeed()
+		if h := feed.HTML; h != nil && h.Item != "" && h.Title != "" {
+			if err := htmlSelectors(h).Validate(); err != nil {
+				problems.Add(fc.Pos, "Feed '%s' html selectors: %s", feed.Id, err.Error())
+			}
+		}
+		if m := feed.JSON; m != nil && m.Items != "" && m.Title != "" {
+			if err := jsonMapping(m).Validate(); err != nil {
+				problems.Add(fc.Pos, "Feed '%s' json mapping: %s", feed.Id, err.Error())
+			}
+		}
+		for i, nfn := range feed.Notifications {
+			pos := fc.Notifications[i].Pos
+
+			if nfn.Type != "" {
+				if _, ok := notifier.SchemaOf(nfn.Type); !ok {
+					problems.Add(pos, "Feed '%s' notification type '%s' is unknown", feed.Id, nfn.Type)
+					continue
+				}
+			}
+			if name := notifierName(nfn); name != "" {
+				typ, exists := instances[name]
+				switch {
+				case !exists:
+					problems.Add(pos, "Feed '%s' references unknown notifier '%s'", feed.Id, name)
+				case nfn.Type != "" && nfn.Type != typ:
+					problems.Add(pos, "Feed '%s' notifier '%s' type is %s, not %s", feed.Id, name, typ, nfn.Type)
+				default:
+					if schema, _ := notifier.SchemaOf(typ); schema.NoDestinations && len(nfn.To) > 0 {
+						problems.Add(pos, "Feed '%s' notification destinations aren't supported by %s notifiers", feed.Id, typ)
+					}
+				}
+			}
+			if nfn.QuietHours != nil {
+				if _, err := parseQuietHours(nfn.QuietHours); err != nil {
+					problems.Add(pos, "Feed '%s' notification quiet hours: %s", feed.Id, err.Error())
+				}
+			}
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,72 @@
+package processer
+
+import (
+	"broadcaster/services/processer/notifier"
+	"broadcaster/storages"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_validateBootstrapConfig(t *testing.T) {
+	cfg := &storages.BootstrapConfig{
+		Notifiers: []storages.NotifierConfig{
+			{Name: "news-bot", Type: "telegram", Config: map[string]interface{}{"token": "secret"}},
+			{Name: "typo", Type: "telegran"},
+			{Name: "extra", Type: "slack", Config: map[string]interface{}{"tokn": "secret"}},
+		},
+		Feeds: []storages.FeedConfig{
+			{
+				Source: "Feed",
+				Notifications: []storages.FeedNotificationsConfig{
+					{Type: "telegram"},
+					{Notifier: "news-bot"},
+					{Type: "email"},
+					{Notifier: "missing"},
+					{Type: "slack", Notifier: "news-bot"},
+					{Type: "ntfy", QuietHours: &storages.FeedQuietHoursConfig{From: "22:00", To: "7"}},
+					{Type: "gotify", To: []string{"AppToken"}},
+				},
+			},
+			{
+				Source: "Page",
+				Type:   "html",
+				HTML:   &storages.HTMLSelectorsConfig{Item: "article", Title: "h2 >"},
+			},
+			{
+				Source: "Api",
+				Type:   "json",
+				JSON:   &storages.JSONMappingConfig{Items: "$.items[*]", Title: "title", Date: "$..date"},
+			},
+		},
+	}
+
+	var messages []string
+	for _, p := range validateBootstrapConfig(cfg) {
+		messages = append(messages, p.Message)
+	}
+	require.Equal(t, []string{
+		"Notifier 'typo' type 'telegran' is unknown",
+		"Notifier 'extra' setting 'tokn' is unknown for slack notifiers",
+		"Feed 'Feed' notification type 'email' is unknown",
+		"Feed 'Feed' references unknown notifier 'missing'",
+		"Feed 'Feed' notifier 'news-bot' type is telegram, not slack",
+		"Feed 'Feed' notification quiet hours: Invalid 'to' time: Expected 'HH:MM' format, got '7'",
+		"Feed 'Feed' notification destinations aren't supported by gotify notifiers",
+		"Feed 'Page' html selectors: Invalid selector 'h2 >': expected selector, found EOF instead",
+		"Feed 'Api' json mapping: Invalid JSONPath '$..date': empty key",
+	}, messages)
+}
+
+// Checks the bootstrap config schema lists all registered notifiers types.
+func Test_BootstrapSchema_notifierTypes(t *testing.T) {
+	var schema struct {
+		Defs map[string]struct {
+			Enum []string `json:"enum"`
+		} `json:"$defs"`
+	}
+	require.NoError(t, json.Unmarshal(storages.BootstrapSchema, &schema))
+	require.Equal(t, notifier.Types(), schema.Defs["notifierType"].Enum, strings.Join(notifier.Types(), ", "))
+}
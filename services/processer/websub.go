@@ -0,0 +1,225 @@
+package processer
+
+import (
+	"broadcaster/services/processer/websub"
+	"broadcaster/storages"
+	"broadcaster/structs"
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/mmcdole/gofeed/atom"
+)
+
+// Path of WebSub callbacks on the REST API server. Feeds ids are appended to it.
+const websubPath = "/websub"
+
+// Minimal interval between fetches of feeds triggered by unsigned pushes.
+// Callbacks URLs aren't secret, so anyone can push to them.
+const pushRefetchInterval = time.Minute
+
+// Custom field of parsed feeds with the WebSub hub URL.
+const hubField = "websub_hub"
+
+// Returns the feed parser which keeps WebSub hubs links of Atom feeds.
+func newFeedParser() *gofeed.Parser {
+	p := gofeed.NewParser()
+	p.AtomTranslator = &atomHubTranslator{}
+	return p
+}
+
+// Atom translator keeping the 'rel="hub"' link, which is dropped by the default translator.
+type atomHubTranslator struct {
+	gofeed.DefaultAtomTranslator
+}
+
+func (t *atomHubTranslator) Translate(feed interface{}) (*gofeed.Feed, error) {
+	result, err := t.DefaultAtomTranslator.Translate(feed)
+	if err != nil {
+		return nil, err
+	}
+	if af, ok := feed.(*atom.Feed); ok {
+		for _, link := range af.Links {
+			if link.Rel == "hub" && link.Href != "" {
+				if result.Custom == nil {
+					result.Custom = make(map[string]string)
+				}
+				result.Custom[hubField] = link.Href
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// Returns the WebSub hub URL and the topic (the feed self link) of the parsed feed.
+// The hub is empty if the feed doesn't support WebSub.
+func discoverHub(parsedFeed *gofeed.Feed) (hub, topic string) {
+	hub = parsedFeed.Custom[hubField]
+	if hub == "" {
+		// RSS feeds have hubs links as 'atom:link' elements
+		for _, elements := range parsedFeed.Extensions {
+			for _, link := range elements["link"] {
+				if hub == "" && link.Attrs["rel"] == "hub" {
+					hub = link.Attrs["href"]
+				}
+			}
+		}
+	}
+	return hub, parsedFeed.FeedLink
+}
+
+// Disables WebSub subscriptions for runs without the server serving callbacks.
+func WithoutWebSub() Option {
+	return func(s *Service) {
+		s.cfg.WebSub = false
+	}
+}
+
+// Subscribes the feed to the hub discovered in the parsed feed if it isn't subscribed yet
+// or the subscription lease expired.
+func (s *Service) subscribeHub(ctx context.Context, feed structs.RssFeed, parsedFeed *gofeed.Feed) {
+	logger := s.logger.With("feed_id", feed.Id)
+
+	hub, topic := discoverHub(parsedFeed)
+	if hub == "" {
+		return
+	}
+	if topic == "" {
+		topic = feed.URL
+	}
+	if u, err := url.Parse(hub); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		logger.With("hub", hub).Warn("Invalid WebSub hub URL")
+		return
+	}
+	if !s.websub.NeedsSubscription(feed.Id, hub, topic) {
+		return
+	}
+
+	logger = logger.With("hub", hub, "topic", topic)
+	logger.Info("Subscribing to WebSub hub")
+
+	if err := s.websub.Subscribe(ctx, feed.Id, hub, topic); err != nil {
+		logger.With("err", err.Error()).Warn("Failed to subscribe to WebSub hub, polling the feed")
+	}
+}
+
+// Verifies the WebSub hub intent for the feed and returns the challenge.
+func (s *Service) VerifySubscription(feedId string, query url.Values) (string, error) {
+	if s.websub == nil {
+		return "", websub.UnknownSubscriptionError
+	}
+
+	challenge, err := s.websub.Verify(feedId, query)
+	if err != nil {
+		return "", err
+	}
+
+	logger := s.logger.With("feed_id", feedId, "topic", query.Get("hub.topic"))
+	switch query.Get("hub.mode") {
+	case "subscribe":
+		logger.With("lease", query.Get("hub.lease_seconds")).Info("WebSub subscription is verified")
+	case "denied":
+		logger.With("reason", query.Get("hub.reason")).Warn("WebSub subscription is denied by the hub, polling the feed")
+	}
+	return challenge, nil
+}
+
+// Checks the signature of the content pushed by the WebSub hub and processes the feed items in background.
+// Content of unsigned subscriptions isn't trusted, the feed is fetched from its source instead.
+func (s *Service) ReceivePush(ctx context.Context, feedId string, body []byte, signature string) error {
+	if s.websub == nil {
+		return websub.UnknownSubscriptionError
+	}
+	if err := s.websub.CheckSignature(feedId, body, signature); err != nil {
+		return err
+	}
+
+	feed, err := s.storage.Feeds().Find(ctx, storages.FeedsStorageFindRequest{Id: feedId})
+	if err != nil {
+		// The feed is removed from the config
+		s.websub.Remove(feedId)
+		return websub.UnknownSubscriptionError
+	}
+
+	var parsedFeed *gofeed.Feed
+	if sub, _ := s.websub.Subscription(feedId); sub.Signed {
+		if parsedFeed, err = newFeedParser().Parse(bytes.NewReader(body)); err != nil {
+			return fmt.Errorf("Failed to parse pushed feed: %w", err)
+		}
+	} else if !s.startPushRefetch(feedId, time.Now()) {
+		s.logger.With("feed_id", feedId).Debug("Feed is fetched by a recent push, skipping")
+		return nil
+	}
+
+	go s.processPush(context.WithoutCancel(ctx), *feed, parsedFeed)
+
+	return nil
+}
+
+// Fetch of the feed triggered by an unsigned push.
+type pushRefetch struct {
+	running bool
+	started time.Time
+}
+
+// Marks the fetch of the feed triggered by an unsigned push as started. Returns false if the feed
+// is being fetched or was fetched less than the refetch interval ago.
+func (s *Service) startPushRefetch(feedId string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, exists := s.pushRefetches[feedId]; exists && (r.running || now.Sub(r.started) < pushRefetchInterval) {
+		return false
+	}
+	if s.pushRefetches == nil {
+		s.pushRefetches = make(map[string]pushRefetch)
+	}
+	s.pushRefetches[feedId] = pushRefetch{running: true, started: now}
+	return true
+}
+
+func (s *Service) finishPushRefetch(feedId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := s.pushRefetches[feedId]
+	r.running = false
+	s.pushRefetches[feedId] = r
+}
+
+// Processes items of the pushed feed. Feeds without trusted pushed content are fetched.
+func (s *Service) processPush(ctx context.Context, feed structs.RssFeed, parsedFeed *gofeed.Feed) {
+	logger := s.logger.With("feed_id", feed.Id)
+	if parsedFeed == nil {
+		defer s.finishPushRefetch(feed.Id)
+	}
+
+	switch feed.Status {
+	case structs.FeedStatusDisabled, structs.FeedStatusPaused:
+		logger.Debugf("Feed is %s, skipping pushed content", feed.Status)
+		return
+	}
+	if len(feed.Notifications) == 0 {
+		logger.Debug("Feed has no notifications configured, skipping")
+		return
+	}
+
+	var items []structs.RssFeedItem
+	if parsedFeed != nil {
+		items = s.feedItems(feed, parsedFeed)
+	} else {
+		logger.Debug("Pushed content isn't signed, fetching feed")
+		var err error
+		if items, err = s.parseFeed(ctx, feed, 120*time.Second); err != nil {
+			logger.With("err", err.Error()).Warn("Failed to fetch pushed feed")
+			return
+		}
+	}
+	logger.Debug("Pushed feed items: ", len(items))
+
+	s.processItems(ctx, feed, items)
+}
@@ -0,0 +1,311 @@
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	UnknownSubscriptionError = errors.New("Unknown subscription")
+	InvalidSignatureError    = errors.New("Invalid content signature")
+)
+
+// Subscription is resent if the hub doesn't verify it within the timeout.
+const verifyTimeout = 10 * time.Minute
+
+// Failed or denied subscriptions are retried after the delay.
+const retryDelay = time.Hour
+
+type Config struct {
+	CallbackURL string        // Base callback URL, the feed id is appended to it
+	Lease       time.Duration // Requested subscription lease, hubs may use their own
+	UserAgent   string
+}
+
+type State string
+
+const (
+	StatePending State = "pending" // Waiting for the hub intent verification
+	StateActive  State = "active"
+	StateFailed  State = "failed" // Subscription request failed or was denied by the hub
+)
+
+// Subscription of the feed to the hub topic.
+type Subscription struct {
+	FeedId    string
+	Hub       string
+	Topic     string
+	State     State
+	Requested time.Time
+	Expires   time.Time // Lease expiration of the active subscription
+	Signed    bool      // Pushed content is signed, secrets are sent to https hubs only
+	secret    string    // Content signature key, identifies the request for unsigned subscriptions
+}
+
+// Subscriber subscribes feeds to WebSub hubs, verifies hubs intents and pushed content signatures.
+// Subscriptions are kept in memory.
+type Subscriber struct {
+	cfg    *Config
+	httpCl *http.Client
+	mu     *sync.Mutex
+	// feed id -> subscription
+	subs map[string]*Subscription
+	now  func() time.Time
+}
+
+func New(cfg *Config) *Subscriber {
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "broadcaster"
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = 24 * time.Hour
+	}
+	return &Subscriber{
+		cfg:    cfg,
+		httpCl: &http.Client{Timeout: 30 * time.Second},
+		mu:     &sync.Mutex{},
+		subs:   make(map[string]*Subscription),
+		now:    time.Now,
+	}
+}
+
+// Returns the callback URL of the feed.
+func (s *Subscriber) CallbackURL(feedId string) string {
+	return strings.TrimRight(s.cfg.CallbackURL, "/") + "/" + url.PathEscape(feedId)
+}
+
+// Checks whether the feed should be (re)subscribed to the hub topic: there is no subscription,
+// the hub or topic changed, the lease expired, the hub didn't verify the request in time
+// or the retry delay of the failed subscription passed.
+func (s *Subscriber) NeedsSubscription(feedId, hub, topic string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, exists := s.subs[feedId]
+	if !exists || sub.Hub != hub || sub.Topic != topic {
+		return true
+	}
+
+	now := s.now()
+	switch sub.State {
+	case StateActive:
+		return !now.Before(sub.Expires)
+	case StatePending:
+		return now.Sub(sub.Requested) > verifyTimeout
+	default:
+		return now.Sub(sub.Requested) > retryDelay
+	}
+}
+
+// Checks whether the feed has an active subscription, so its updates are pushed by the hub.
+func (s *Subscriber) Active(feedId string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, exists := s.subs[feedId]
+	return exists && sub.State == StateActive && s.now().Before(sub.Expires)
+}
+
+// Returns a copy of the feed subscription.
+func (s *Subscriber) Subscription(feedId string) (Subscription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, exists := s.subs[feedId]
+	if !exists {
+		return Subscription{}, false
+	}
+	return *sub, true
+}
+
+// Removes the feed subscription. Content pushed by the hub is rejected after that.
+func (s *Subscriber) Remove(feedId string) {
+	s.mu.Lock()
+	delete(s.subs, feedId)
+	s.mu.Unlock()
+}
+
+// Sends the subscription request to the hub. The subscription is pending until the hub verifies the intent.
+// The content signature secret is sent to https hubs only, content pushed by other hubs isn't signed.
+func (s *Subscriber) Subscribe(ctx context.Context, feedId, hub, topic string) error {
+	secret, err := newSecret()
+	if err != nil {
+		return err
+	}
+	hubURL, err := url.Parse(hub)
+	if err != nil {
+		return fmt.Errorf("Failed to parse hub URL: %w", err)
+	}
+	signed := hubURL.Scheme == "https"
+
+	// Hubs may verify the intent before responding, so the subscription is stored first
+	s.mu.Lock()
+	s.subs[feedId] = &Subscription{
+		FeedId:    feedId,
+		Hub:       hub,
+		Topic:     topic,
+		State:     StatePending,
+		Requested: s.now(),
+		Signed:    signed,
+		secret:    secret,
+	}
+	s.mu.Unlock()
+
+	form := url.Values{
+		"hub.mode":          {"subscribe"},
+		"hub.topic":         {topic},
+		"hub.callback":      {s.CallbackURL(feedId)},
+		"hub.lease_seconds": {strconv.Itoa(int(s.cfg.Lease.Seconds()))},
+	}
+	if signed {
+		form.Set("hub.secret", secret)
+	}
+	if err := s.send(ctx, hub, form); err != nil {
+		s.fail(feedId, secret)
+		return err
+	}
+	return nil
+}
+
+func (s *Subscriber) send(ctx context.Context, hub string, form url.Values) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("Failed to create hub request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", s.cfg.UserAgent)
+
+	resp, err := s.httpCl.Do(req)
+	if err != nil {
+		return fmt.Errorf("Failed to send hub request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Bad hub response code (%d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Marks the subscription as failed if it wasn't replaced by another request.
+func (s *Subscriber) fail(feedId, secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sub, exists := s.subs[feedId]; exists && sub.secret == secret {
+		sub.State = StateFailed
+	}
+}
+
+// Verifies the hub intent by the callback request query and returns the challenge to respond with.
+// Subscription requests are confirmed for the feed topic only. Unsubscriptions are confirmed
+// unless the feed has a subscription to the topic. Denials mark the subscription as failed.
+func (s *Subscriber) Verify(feedId string, query url.Values) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, exists := s.subs[feedId]
+	topic := query.Get("hub.topic")
+	matches := exists && sub.Topic == topic
+
+	switch mode := query.Get("hub.mode"); mode {
+	case "subscribe":
+		if !matches || sub.State == StateFailed {
+			return "", UnknownSubscriptionError
+		}
+		lease := s.cfg.Lease
+		if seconds, err := strconv.Atoi(query.Get("hub.lease_seconds")); err == nil && seconds > 0 {
+			lease = time.Duration(seconds) * time.Second
+		}
+		sub.State = StateActive
+		sub.Expires = s.now().Add(lease)
+		return query.Get("hub.challenge"), nil
+	case "unsubscribe":
+		if matches && sub.State != StateFailed {
+			return "", fmt.Errorf("Subscription to '%s' is wanted", topic)
+		}
+		return query.Get("hub.challenge"), nil
+	case "denied":
+		if matches {
+			sub.State = StateFailed
+			sub.Requested = s.now()
+		}
+		return "", nil
+	default:
+		return "", fmt.Errorf("Unknown hub mode '%s'", mode)
+	}
+}
+
+// Checks the pushed content signature 'X-Hub-Signature: <method>=<hex digest>'
+// with the secret of the feed subscription. Content of unsigned subscriptions isn't checked,
+// it can't be trusted and the feed should be fetched from the topic instead.
+func (s *Subscriber) CheckSignature(feedId string, body []byte, signature string) error {
+	s.mu.Lock()
+	sub, exists := s.subs[feedId]
+	var secret string
+	var signed bool
+	if exists {
+		secret, signed = sub.secret, sub.Signed
+	}
+	s.mu.Unlock()
+
+	if !exists {
+		return UnknownSubscriptionError
+	}
+	if !signed {
+		return nil
+	}
+
+	method, digest, found := strings.Cut(signature, "=")
+	if !found {
+		return InvalidSignatureError
+	}
+	var h func() hash.Hash
+	switch method {
+	case "sha1":
+		h = sha1.New
+	case "sha256":
+		h = sha256.New
+	case "sha384":
+		h = sha512.New384
+	case "sha512":
+		h = sha512.New
+	default:
+		return InvalidSignatureError
+	}
+	expected, err := hex.DecodeString(digest)
+	if err != nil {
+		return InvalidSignatureError
+	}
+
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return InvalidSignatureError
+	}
+	return nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("Failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,168 @@
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Hub recording subscription requests.
+func testHub(t *testing.T, status int) (*httptest.Server, *url.Values) {
+	var form url.Values
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		form = r.PostForm
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &form
+}
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func Test_Subscriber(t *testing.T) {
+	ctx := context.Background()
+
+	hub, form := testHub(t, http.StatusAccepted)
+	topic := "https://example.com/feed.xml"
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	newSubscriber := func() *Subscriber {
+		s := New(&Config{CallbackURL: "https://bctr.example.com/websub/", Lease: time.Hour})
+		s.now = func() time.Time { return now }
+		s.httpCl = hub.Client()
+		return s
+	}
+
+	t.Run("Subscribe", func(t *testing.T) {
+		s := newSubscriber()
+		require.True(t, s.NeedsSubscription("World News", hub.URL, topic))
+
+		require.NoError(t, s.Subscribe(ctx, "World News", hub.URL, topic))
+		require.Equal(t, "subscribe", form.Get("hub.mode"))
+		require.Equal(t, topic, form.Get("hub.topic"))
+		require.Equal(t, "https://bctr.example.com/websub/World%20News", form.Get("hub.callback"))
+		require.Equal(t, "3600", form.Get("hub.lease_seconds"))
+		require.NotEmpty(t, form.Get("hub.secret"))
+
+		require.False(t, s.Active("World News"), "Subscription isn't verified yet")
+		require.False(t, s.NeedsSubscription("World News", hub.URL, topic))
+
+		// Intent verification
+		_, err := s.Verify("World News", url.Values{"hub.mode": {"subscribe"}, "hub.topic": {"https://other.com/feed.xml"}})
+		require.ErrorIs(t, err, UnknownSubscriptionError)
+
+		challenge, err := s.Verify("World News", url.Values{
+			"hub.mode":          {"subscribe"},
+			"hub.topic":         {topic},
+			"hub.challenge":     {"abc"},
+			"hub.lease_seconds": {"600"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "abc", challenge)
+		require.True(t, s.Active("World News"))
+
+		sub, _ := s.Subscription("World News")
+		require.Equal(t, now.Add(10*time.Minute), sub.Expires)
+
+		// Content signature
+		body := []byte("<feed/>")
+		require.NoError(t, s.CheckSignature("World News", body, sign(form.Get("hub.secret"), body)))
+		require.ErrorIs(t, s.CheckSignature("World News", body, sign("wrong", body)), InvalidSignatureError)
+		require.ErrorIs(t, s.CheckSignature("World News", body, "md5=abc"), InvalidSignatureError)
+		require.ErrorIs(t, s.CheckSignature("World News", body, ""), InvalidSignatureError)
+		require.ErrorIs(t, s.CheckSignature("Other", body, sign(form.Get("hub.secret"), body)), UnknownSubscriptionError)
+
+		// Unsubscription of the wanted topic isn't confirmed
+		_, err = s.Verify("World News", url.Values{"hub.mode": {"unsubscribe"}, "hub.topic": {topic}})
+		require.Error(t, err)
+		challenge, err = s.Verify("Other", url.Values{"hub.mode": {"unsubscribe"}, "hub.topic": {topic}, "hub.challenge": {"x"}})
+		require.NoError(t, err)
+		require.Equal(t, "x", challenge)
+
+		// Lease expiration
+		now = now.Add(11 * time.Minute)
+		require.False(t, s.Active("World News"))
+		require.True(t, s.NeedsSubscription("World News", hub.URL, topic))
+	})
+
+	t.Run("HubChanged", func(t *testing.T) {
+		s := newSubscriber()
+		require.NoError(t, s.Subscribe(ctx, "feed", hub.URL, topic))
+		require.True(t, s.NeedsSubscription("feed", "https://hub.example.com", topic))
+		require.True(t, s.NeedsSubscription("feed", hub.URL, "https://example.com/other.xml"))
+	})
+
+	t.Run("VerifyTimeout", func(t *testing.T) {
+		s := newSubscriber()
+		require.NoError(t, s.Subscribe(ctx, "feed", hub.URL, topic))
+		now = now.Add(verifyTimeout + time.Second)
+		require.True(t, s.NeedsSubscription("feed", hub.URL, topic))
+	})
+
+	t.Run("Denied", func(t *testing.T) {
+		s := newSubscriber()
+		require.NoError(t, s.Subscribe(ctx, "feed", hub.URL, topic))
+
+		_, err := s.Verify("feed", url.Values{"hub.mode": {"denied"}, "hub.topic": {topic}, "hub.reason": {"Nope"}})
+		require.NoError(t, err)
+		require.False(t, s.Active("feed"))
+		require.False(t, s.NeedsSubscription("feed", hub.URL, topic))
+
+		_, err = s.Verify("feed", url.Values{"hub.mode": {"subscribe"}, "hub.topic": {topic}})
+		require.ErrorIs(t, err, UnknownSubscriptionError)
+
+		now = now.Add(retryDelay + time.Second)
+		require.True(t, s.NeedsSubscription("feed", hub.URL, topic))
+	})
+
+	t.Run("HubError", func(t *testing.T) {
+		failing, _ := testHub(t, http.StatusBadRequest)
+
+		s := newSubscriber()
+		require.Error(t, s.Subscribe(ctx, "feed", failing.URL, topic))
+
+		sub, exists := s.Subscription("feed")
+		require.True(t, exists)
+		require.Equal(t, StateFailed, sub.State)
+		require.False(t, s.NeedsSubscription("feed", failing.URL, topic), "Failed subscription is retried after the delay")
+	})
+
+	t.Run("PlainHub", func(t *testing.T) {
+		var plainForm url.Values
+		plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, r.ParseForm())
+			plainForm = r.PostForm
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer plain.Close()
+
+		s := newSubscriber()
+		require.NoError(t, s.Subscribe(ctx, "feed", plain.URL, topic))
+		require.Equal(t, "subscribe", plainForm.Get("hub.mode"))
+		require.False(t, plainForm.Has("hub.secret"), "Secrets aren't sent to plain HTTP hubs")
+
+		sub, _ := s.Subscription("feed")
+		require.False(t, sub.Signed)
+		require.NoError(t, s.CheckSignature("feed", []byte("content"), ""), "Unsigned content isn't checked")
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		s := newSubscriber()
+		require.NoError(t, s.Subscribe(ctx, "feed", hub.URL, topic))
+		s.Remove("feed")
+		require.ErrorIs(t, s.CheckSignature("feed", nil, "sha256=00"), UnknownSubscriptionError)
+	})
+}
@@ -0,0 +1,299 @@
+package processer
+
+import (
+	"broadcaster/services/processer/notifier"
+	"broadcaster/services/processer/translator"
+	"broadcaster/services/processer/websub"
+	"broadcaster/storages"
+	"broadcaster/storages/memory"
+	"broadcaster/structs"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_discoverHub(t *testing.T) {
+	tests := []struct {
+		name  string
+		feed  string
+		hub   string
+		topic string
+	}{
+		{
+			name: "RSS",
+			feed: `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel>
+				<title>News</title>
+				<atom:link rel="hub" href="https://hub.example.com/"/>
+				<atom:link rel="self" href="https://example.com/rss.xml"/>
+			</channel></rss>`,
+			hub:   "https://hub.example.com/",
+			topic: "https://example.com/rss.xml",
+		},
+		{
+			name: "Atom",
+			feed: `<feed xmlns="http://www.w3.org/2005/Atom">
+				<title>News</title>
+				<link rel="self" href="https://example.com/atom.xml"/>
+				<link rel="hub" href="https://hub.example.com/"/>
+			</feed>`,
+			hub:   "https://hub.example.com/",
+			topic: "https://example.com/atom.xml",
+		},
+		{
+			name: "NoHub",
+			feed: `<rss version="2.0"><channel><title>News</title></channel></rss>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := newFeedParser().Parse(strings.NewReader(tt.feed))
+			require.NoError(t, err)
+
+			hub, topic := discoverHub(parsed)
+			require.Equal(t, tt.hub, hub)
+			require.Equal(t, tt.topic, topic)
+		})
+	}
+}
+
+func Test_Service_websub(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		s       *Service
+		fetches atomic.Int32
+		latest  atomic.Value
+	)
+
+	// Hub verifies the intent before responding. Plain HTTP hubs don't get secrets, so pushes aren't signed
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.False(t, r.PostForm.Has("hub.secret"))
+
+		callback, err := url.Parse(r.PostForm.Get("hub.callback"))
+		require.NoError(t, err)
+		feedId, _ := url.PathUnescape(strings.TrimPrefix(callback.EscapedPath(), websubPath+"/"))
+
+		challenge, err := s.VerifySubscription(feedId, url.Values{
+			"hub.mode":          {"subscribe"},
+			"hub.topic":         {r.PostForm.Get("hub.topic")},
+			"hub.challenge":     {"challenge"},
+			"hub.lease_seconds": {"3600"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "challenge", challenge)
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer hub.Close()
+
+	rss := func(title string, pubDate time.Time) string {
+		return fmt.Sprintf(`<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel>
+			<title>News</title>
+			<atom:link rel="hub" href="%s"/>
+			<item><guid>%s</guid><title>%s</title><pubDate>%s</pubDate></item>
+		</channel></rss>`, hub.URL, title, title, pubDate.Format(time.RFC1123Z))
+	}
+
+	latest.Store(rss("old", time.Now().Add(-time.Hour)))
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		fmt.Fprint(w, latest.Load().(string))
+	}))
+	defer source.Close()
+
+	st := memory.NewStorage()
+	_, err := st.Feeds().Create(ctx, storages.FeedsStorageCreateRequest{Id: "news", Source: "News", URL: source.URL})
+	require.NoError(t, err)
+	_, err = st.Feeds().Update(ctx, storages.FeedsStorageUpdateRequest{
+		Id:     "news",
+		Notify: []structs.RssFeedNotification{{Type: "test"}},
+	})
+	require.NoError(t, err)
+
+	rec := &recordingNotifier{}
+	s = &Service{
+		cfg:          &Config{FeedMaxFailures: 5},
+		logger:       tservice.logger,
+		storage:      st,
+		translator:   translator.NewMockTranslator(),
+		notifiers:    map[string]notifierInstance{"test": {Type: "test", Notifier: rec}},
+		mu:           &sync.RWMutex{},
+		translations: make(map[string]map[string]structs.RssFeedItem),
+		failures:     make(map[string]*feedFailures),
+		websub:       websub.New(&websub.Config{CallbackURL: "https://bctr.example.com" + websubPath}),
+	}
+
+	// Hub is discovered on polling
+	require.NoError(t, s.Process(ctx))
+	require.Equal(t, int32(1), fetches.Load())
+	require.True(t, s.websub.Active("news"))
+
+	// Pushed feeds aren't polled
+	require.NoError(t, s.Process(ctx))
+	require.Equal(t, int32(1), fetches.Load())
+
+	latest.Store(rss("new", time.Now().Add(time.Hour)))
+	forged := []byte(rss("forged", time.Now().Add(time.Hour)))
+
+	require.ErrorIs(t, s.ReceivePush(ctx, "other", forged, ""), websub.UnknownSubscriptionError)
+
+	// Unsigned content isn't trusted, the feed is fetched instead
+	require.NoError(t, s.ReceivePush(ctx, "news", forged, ""))
+	require.Eventually(t, func() bool {
+		item, _ := st.FeedItems().Find(ctx, storages.FeedItemsStorageFindRequest{Id: "news/new"})
+		return item != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, int32(2), fetches.Load())
+
+	_, err = st.FeedItems().Find(ctx, storages.FeedItemsStorageFindRequest{Id: "news/forged"})
+	require.ErrorIs(t, err, storages.ItemNotFoundError)
+
+	// Unsigned pushes can't force fetches
+	for i := 0; i < 5; i++ {
+		require.NoError(t, s.ReceivePush(ctx, "news", forged, ""))
+	}
+	require.Never(t, func() bool { return fetches.Load() > 2 }, 100*time.Millisecond, 10*time.Millisecond)
+
+	rec.mu.Lock()
+	require.Len(t, rec.requests, 1, "Pushed item is notified")
+	rec.mu.Unlock()
+}
+
+// Pushes are processed concurrently with polling runs, run with -race.
+func Test_Service_ReceivePush_concurrent(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		s       *Service
+		fetches atomic.Int32
+	)
+
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		_, err := s.VerifySubscription("news", url.Values{
+			"hub.mode":      {"subscribe"},
+			"hub.topic":     {r.PostForm.Get("hub.topic")},
+			"hub.challenge": {"challenge"},
+		})
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer hub.Close()
+
+	// Every fetch has a new item
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := fetches.Add(1)
+		hubLink := ""
+		if r.URL.Path == "/news" {
+			hubLink = fmt.Sprintf(`<atom:link rel="hub" href="%s"/>`, hub.URL)
+		}
+		fmt.Fprintf(w, `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel><title>Feed</title>%s
+			<item><guid>%d</guid><title>Item %d</title><pubDate>%s</pubDate></item>
+		</channel></rss>`, hubLink, n, n, time.Now().Add(time.Hour).Format(time.RFC1123Z))
+	}))
+	defer source.Close()
+
+	st := memory.NewStorage()
+	for _, id := range []string{"news", "blog"} {
+		_, err := st.Feeds().Create(ctx, storages.FeedsStorageCreateRequest{Id: id, Source: id, URL: source.URL + "/" + id})
+		require.NoError(t, err)
+		_, err = st.Feeds().Update(ctx, storages.FeedsStorageUpdateRequest{Id: id, Notify: []structs.RssFeedNotification{{Type: "test"}}})
+		require.NoError(t, err)
+	}
+
+	s = &Service{
+		cfg:          &Config{FeedMaxFailures: 5},
+		logger:       tservice.logger,
+		storage:      st,
+		translator:   translator.NewMockTranslator(),
+		notifiers:    map[string]notifierInstance{"test": {Type: "test", Notifier: &recordingNotifier{}}},
+		mu:           &sync.RWMutex{},
+		translations: make(map[string]map[string]structs.RssFeedItem),
+		failures:     make(map[string]*feedFailures),
+		websub:       websub.New(&websub.Config{CallbackURL: "https://bctr.example.com" + websubPath}),
+	}
+
+	require.NoError(t, s.Process(ctx))
+	require.True(t, s.websub.Active("news"))
+	polled := fetches.Load()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 3; i++ {
+			require.NoError(t, s.Process(ctx))
+		}
+	}()
+	require.NoError(t, s.ReceivePush(ctx, "news", []byte("<rss/>"), ""))
+	wg.Wait()
+
+	require.Eventually(t, func() bool {
+		return fetches.Load() >= polled+4
+	}, 5*time.Second, 10*time.Millisecond, "Pushed feed is fetched along with polled ones")
+}
+
+// Notifier taking time to send messages.
+type slowNotifier struct {
+	messageNotifier
+}
+
+func (n *slowNotifier) Notify(ctx context.Context, r notifier.NotificationRequest) error {
+	time.Sleep(50 * time.Millisecond)
+	return n.messageNotifier.Notify(ctx, r)
+}
+
+func Test_Service_processItems_concurrent(t *testing.T) {
+	ctx := context.Background()
+
+	st := memory.NewStorage()
+	nfr := &slowNotifier{}
+	s := &Service{
+		cfg:          &Config{},
+		logger:       tservice.logger,
+		storage:      st,
+		translator:   translator.NewMockTranslator(),
+		notifiers:    map[string]notifierInstance{"test": {Type: "test", Notifier: nfr}},
+		mu:           &sync.RWMutex{},
+		translations: make(map[string]map[string]structs.RssFeedItem),
+	}
+
+	feed := structs.RssFeed{Id: "news", Notifications: []structs.RssFeedNotification{{Type: "test"}}}
+	items := []structs.RssFeedItem{{Id: "news/1", FeedId: "news", Title: "One", PubDate: time.Now()}}
+
+	// Polled and pushed at the same time
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.processItems(ctx, feed, items)
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, int32(1), nfr.sent.Load(), "Item is notified once")
+}
+
+func Test_Service_startPushRefetch(t *testing.T) {
+	s := &Service{mu: &sync.RWMutex{}}
+	now := time.Now()
+
+	require.True(t, s.startPushRefetch("news", now))
+	require.False(t, s.startPushRefetch("news", now.Add(2*pushRefetchInterval)), "Fetch is running")
+	require.True(t, s.startPushRefetch("other", now))
+
+	s.finishPushRefetch("news")
+	require.False(t, s.startPushRefetch("news", now.Add(pushRefetchInterval/2)), "Fetched recently")
+	require.True(t, s.startPushRefetch("news", now.Add(pushRefetchInterval)))
+}
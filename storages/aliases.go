@@ -0,0 +1,79 @@
+package storages
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Convenience feeds URLs by scheme. Returns the feed URL by the alias value (without the scheme).
+var feedAliases = map[string]func(value string) (string, error){
+	"youtube":  youtubeFeedURL,
+	"mastodon": mastodonFeedURL,
+	"reddit":   redditFeedURL,
+}
+
+var (
+	aliasIdRe   = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	aliasHostRe = regexp.MustCompile(`^[A-Za-z0-9.-]+(:[0-9]+)?$`)
+)
+
+// Expands convenience feeds URLs ('youtube:channel/<id>', 'mastodon:@user@host', 'reddit:r/<name>')
+// to the feeds URLs. Other URLs are returned as is.
+func ExpandFeedURL(raw string) (string, error) {
+	scheme, value, found := strings.Cut(raw, ":")
+	if !found {
+		return raw, nil
+	}
+	alias, exists := feedAliases[strings.ToLower(scheme)]
+	if !exists {
+		return raw, nil
+	}
+	return alias(value)
+}
+
+// 'youtube:channel/<id>' or 'youtube:playlist/<id>'.
+func youtubeFeedURL(value string) (string, error) {
+	invalid := fmt.Errorf("Invalid YouTube feed 'youtube:%s', expected 'youtube:channel/<id>' or 'youtube:playlist/<id>'", value)
+
+	kind, id, _ := strings.Cut(value, "/")
+	if !aliasIdRe.MatchString(id) {
+		return "", invalid
+	}
+	switch kind {
+	case "channel":
+		return "https://www.youtube.com/feeds/videos.xml?channel_id=" + url.QueryEscape(id), nil
+	case "playlist":
+		return "https://www.youtube.com/feeds/videos.xml?playlist_id=" + url.QueryEscape(id), nil
+	default:
+		return "", invalid
+	}
+}
+
+// 'mastodon:@user@host'.
+func mastodonFeedURL(value string) (string, error) {
+	user, host, _ := strings.Cut(strings.TrimPrefix(value, "@"), "@")
+	if !aliasIdRe.MatchString(user) || !aliasHostRe.MatchString(host) {
+		return "", fmt.Errorf("Invalid Mastodon feed 'mastodon:%s', expected 'mastodon:@user@host'", value)
+	}
+	return "https://" + strings.ToLower(host) + "/@" + user + ".rss", nil
+}
+
+// 'reddit:r/<subreddit>' or 'reddit:u/<user>'.
+func redditFeedURL(value string) (string, error) {
+	invalid := fmt.Errorf("Invalid Reddit feed 'reddit:%s', expected 'reddit:r/<subreddit>' or 'reddit:u/<user>'", value)
+
+	kind, name, _ := strings.Cut(value, "/")
+	if !aliasIdRe.MatchString(name) {
+		return "", invalid
+	}
+	switch kind {
+	case "r":
+		return "https://www.reddit.com/r/" + name + "/.rss", nil
+	case "u":
+		return "https://www.reddit.com/user/" + name + "/.rss", nil
+	default:
+		return "", invalid
+	}
+}
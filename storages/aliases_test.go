@@ -0,0 +1,40 @@
+package storages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ExpandFeedURL(t *testing.T) {
+	valid := map[string]string{
+		"https://example.com/rss":           "https://example.com/rss",
+		"youtube:channel/UC_x5XG1OV2P6uZ":   "https://www.youtube.com/feeds/videos.xml?channel_id=UC_x5XG1OV2P6uZ",
+		"youtube:playlist/PL590L5WQmH8f":    "https://www.youtube.com/feeds/videos.xml?playlist_id=PL590L5WQmH8f",
+		"mastodon:@Gargron@Mastodon.social": "https://mastodon.social/@Gargron.rss",
+		"mastodon:user@example.com:8443":    "https://example.com:8443/@user.rss",
+		"reddit:r/golang":                   "https://www.reddit.com/r/golang/.rss",
+		"reddit:u/spez":                     "https://www.reddit.com/user/spez/.rss",
+	}
+	for raw, expected := range valid {
+		result, err := ExpandFeedURL(raw)
+		require.NoError(t, err, raw)
+		require.Equal(t, expected, result, raw)
+	}
+
+	for _, raw := range []string{
+		"youtube:UC_x5XG1OV2P6uZ",
+		"youtube:channel/",
+		"youtube:user/name",
+		"mastodon:@user",
+		"mastodon:@user@host/path",
+		"reddit:golang",
+		"reddit:r/go lang",
+	} {
+		_, err := ExpandFeedURL(raw)
+		require.Error(t, err, raw)
+	}
+
+	feed := FeedConfig{Source: "Go", URL: "reddit:r/golang"}.ToRssFeed()
+	require.Equal(t, "https://www.reddit.com/r/golang/.rss", feed.URL, "Feeds URLs are expanded")
+}
@@ -2,38 +2,109 @@ package storages
 
 import (
 	"broadcaster/structs"
+	"broadcaster/utils/strutil"
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"net/url"
-	"os"
+	"reflect"
 	"strings"
 
-	googleStorage "cloud.google.com/go/storage"
-	"github.com/aws/aws-sdk-go-v2/aws"
-	awsConfig "github.com/aws/aws-sdk-go-v2/config"
-	awsCreds "github.com/aws/aws-sdk-go-v2/credentials"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
 )
 
 type FeedConfig struct {
 	Source        string                    `yaml:"source"`
-	Category      string                    `yaml:"category"`
+	Category      string                    `yaml:"category,omitempty"`
 	URL           string                    `yaml:"url"`
+	Type          string                    `yaml:"type,omitempty"`
+	HTML          *HTMLSelectorsConfig      `yaml:"html,omitempty"`
+	JSON          *JSONMappingConfig        `yaml:"json,omitempty"`
+	Language      string                    `yaml:"language,omitempty"`
+	ItemsLimit    int                       `yaml:"items_limit,omitempty"`
+	IdStrategy    string                    `yaml:"id_strategy,omitempty"`
+	FetchContent  bool                      `yaml:"fetch_content,omitempty"`
+	Disabled      bool                      `yaml:"disabled,omitempty"`
+	Profiles      []string                  `yaml:"profiles,omitempty"`
+	Notifications []FeedNotificationsConfig `yaml:"notifications,omitempty"`
+	Pos           ConfigPosition            `yaml:"-"`
+}
+
+// CSS selectors of 'html' feeds items.
+type HTMLSelectorsConfig struct {
+	Item        string `yaml:"item"`
+	Title       string `yaml:"title"`
+	Link        string `yaml:"link,omitempty"`
+	Date        string `yaml:"date,omitempty"`
+	DateFormat  string `yaml:"date_format,omitempty"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// JSONPath expressions of 'json' feeds items and their fields.
+type JSONMappingConfig struct {
+	Items       string `yaml:"items"`
+	Id          string `yaml:"id,omitempty"`
+	Title       string `yaml:"title"`
+	Link        string `yaml:"link,omitempty"`
+	Date        string `yaml:"date,omitempty"`
+	DateFormat  string `yaml:"date_format,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	Image       string `yaml:"image,omitempty"`
+}
+
+// Bootstrap config file content.
+type BootstrapConfig struct {
+	// Other config files URIs, relative to the including file. Paths can be glob patterns for the sources supporting them.
+	Include   []string                      `yaml:"include"`
+	Defaults  *FeedTemplateConfig           `yaml:"defaults"`
+	Profiles  map[string]FeedTemplateConfig `yaml:"profiles"`
+	Notifiers []NotifierConfig              `yaml:"notifiers"`
+	Feeds     []FeedConfig                  `yaml:"feeds"`
+
+	problems ConfigProblems // Found during loading
+}
+
+// Feeds settings applied by defaults and profiles.
+type FeedTemplateConfig struct {
 	Language      string                    `yaml:"language"`
 	ItemsLimit    int                       `yaml:"items_limit"`
-	Disabled      bool                      `yaml:"disabled"`
 	Notifications []FeedNotificationsConfig `yaml:"notifications"`
 }
 
+// Named notifier instance. Feeds notifications reference instances by name.
+type NotifierConfig struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+	// Notifier type specific settings. Secrets can be references to env variables ('env:NAME') or files ('file:/path').
+	Config map[string]interface{} `yaml:"config"`
+	Pos    ConfigPosition         `yaml:"-"`
+}
+
 type FeedNotificationsConfig struct {
-	Type      string                 `yaml:"type"`
-	To        []string               `yaml:"to"`
-	Muted     bool                   `yaml:"muted"`
-	Translate FeedTranslationsConfig `yaml:"translate"`
+	Type           string                 `yaml:"type"`
+	Notifier       string                 `yaml:"notifier"`
+	To             []string               `yaml:"to"`
+	Muted          bool                   `yaml:"muted"`
+	Translate      FeedTranslationsConfig `yaml:"translate"`
+	Bot            string                 `yaml:"bot"`
+	Silent         bool                   `yaml:"silent"`
+	ProtectContent bool                   `yaml:"protect_content"`
+	Preview        *bool                  `yaml:"preview"`
+	Priority       string                 `yaml:"priority"`
+	Tags           []string               `yaml:"tags"`
+	Visibility     string                 `yaml:"visibility"`
+	ContentWarning string                 `yaml:"content_warning"`
+	QuietHours     *FeedQuietHoursConfig  `yaml:"quiet_hours"`
+	Pos            ConfigPosition         `yaml:"-"`
+}
+
+type FeedQuietHoursConfig struct {
+	Timezone string   `yaml:"timezone"`
+	From     string   `yaml:"from"`
+	To       string   `yaml:"to"`
+	Weekdays []string `yaml:"weekdays"`
+	Action   string   `yaml:"action"`
 }
 
 type FeedTranslationsConfig struct {
@@ -47,24 +118,77 @@ func (c FeedConfig) ToRssFeed() structs.RssFeed {
 		feedid += "." + strings.ReplaceAll(c.Category, " ", "")
 	}
 
+	// Invalid convenience URLs are reported by the validation
+	feedURL, err := ExpandFeedURL(c.URL)
+	if err != nil {
+		feedURL = c.URL
+	}
+
 	result := structs.RssFeed{
-		Id:         feedid,
-		Source:     c.Source,
-		Category:   c.Category,
-		URL:        c.URL,
-		Language:   c.Language,
-		ItemsLimit: c.ItemsLimit,
+		Id:           feedid,
+		Source:       c.Source,
+		Category:     c.Category,
+		URL:          feedURL,
+		Type:         structs.FeedType(c.Type),
+		Language:     c.Language,
+		ItemsLimit:   c.ItemsLimit,
+		IdStrategy:   structs.ItemIdStrategy(c.IdStrategy),
+		FetchContent: c.FetchContent,
+		Status:       structs.FeedStatusActive,
+	}
+	if c.Disabled {
+		result.Status = structs.FeedStatusDisabled
+	}
+	if h := c.HTML; h != nil {
+		result.HTML = &structs.HTMLSelectors{
+			Item:        h.Item,
+			Title:       h.Title,
+			Link:        h.Link,
+			Date:        h.Date,
+			DateFormat:  h.DateFormat,
+			Description: h.Description,
+		}
+	}
+	if j := c.JSON; j != nil {
+		result.JSON = &structs.JSONMapping{
+			Items:       j.Items,
+			Id:          j.Id,
+			Title:       j.Title,
+			Link:        j.Link,
+			Date:        j.Date,
+			DateFormat:  j.DateFormat,
+			Description: j.Description,
+			Image:       j.Image,
+		}
 	}
 
 	for _, n := range c.Notifications {
 		rn := structs.RssFeedNotification{
-			Type:  n.Type,
-			To:    n.To,
-			Muted: n.Muted,
+			Type:     n.Type,
+			Notifier: n.Notifier,
+			To:       n.To,
+			Muted:    n.Muted,
 			Translate: structs.RssFeedTranslation{
-				From: coalesce(n.Translate.From, c.Language),
+				From: strutil.Coalesce(n.Translate.From, c.Language),
 				To:   n.Translate.To,
 			},
+			Bot:            n.Bot,
+			Silent:         n.Silent,
+			ProtectContent: n.ProtectContent,
+			Preview:        n.Preview,
+			Priority:       structs.NotificationPriority(n.Priority),
+			Tags:           n.Tags,
+			Visibility:     n.Visibility,
+			ContentWarning: n.ContentWarning,
+		}
+		if q := n.QuietHours; q != nil {
+			rn.QuietHours = &structs.QuietHours{
+				Timezone: q.Timezone,
+				From:     q.From,
+				To:       q.To,
+				Weekdays: q.Weekdays,
+				Action:   structs.QuietHoursAction(q.Action),
+			}
 		}
 		result.Notifications = append(result.Notifications, rn)
 	}
@@ -74,109 +198,125 @@ func (c FeedConfig) ToRssFeed() structs.RssFeed {
 
 // Loads feeds configurations from file.
 func GetFeedsFromConfig(ctx context.Context, uri string, logger *zap.SugaredLogger) ([]FeedConfig, error) {
-	data, err := loadFileByUri(ctx, uri, logger)
+	config, err := GetBootstrapConfig(ctx, uri, logger)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to load by uri: %w", err)
+		return nil, err
 	}
-
-	var config struct {
-		Feeds []FeedConfig `yaml:"feeds"`
-	}
-
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("Failed to parse data: %w", err)
-	}
-
 	return config.Feeds, nil
 }
 
-func loadFileByUri(ctx context.Context, uri string, logger *zap.SugaredLogger) ([]byte, error) {
-	parsed, err := url.Parse(uri)
+// Loads bootstrap config from file.
+func GetBootstrapConfig(ctx context.Context, uri string, logger *zap.SugaredLogger) (*BootstrapConfig, error) {
+	config, err := loadBootstrapFile(ctx, uri, logger)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to parse config uri: %w", err)
+		return nil, err
 	}
 
-	switch parsed.Scheme {
-	case "file":
-		logger.With("path", parsed.Path).Debug("Loading from filesytsem")
-		return os.ReadFile(parsed.Path)
-
-	case "gs":
-		logger.With("bucket", parsed.Host, "object", parsed.Path).Debug("Loading from Google Cloud Storage")
+	if err := config.resolveIncludes(ctx, uri, logger); err != nil {
+		return nil, err
+	}
 
-		cl, err := googleStorage.NewClient(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("Failed to create google storage client: %w", err)
-		}
-		defer cl.Close()
+	config.problems = append(config.problems, config.expandFeeds()...)
 
-		bucket := cl.Bucket(parsed.Host)
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 
-		gsPath := strings.TrimLeft(parsed.Path, "/")
-		obj := bucket.Object(gsPath)
+	return config, nil
+}
 
-		rc, err := obj.NewReader(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("Failed to read bucket object %s: %w", gsPath, err)
-		}
-		defer rc.Close()
+func loadBootstrapFile(ctx context.Context, uri string, logger *zap.SugaredLogger) (*BootstrapConfig, error) {
+	data, err := loadFileByUri(ctx, uri, logger)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load by uri: %w", err)
+	}
 
-		return io.ReadAll(rc)
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("Failed to parse data: %w", err)
+	}
 
-	case "do":
-		parsed.Path = strings.TrimLeft(parsed.Path, "/")
+	var config BootstrapConfig
+	if err := root.Decode(&config); err != nil {
+		// Values of wrong types are reported as problems, the rest of the config is decoded
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("Failed to parse data: %w", err)
+		}
+		config.problems = typeErrorProblems(typeErr)
+	}
+	config.problems = append(config.problems, unknownFields(&root, reflect.TypeOf(config))...)
 
-		logger.With("bucket", parsed.Host, "object", parsed.Path).
-			Debug("Loading config file from Digital Ocean Spaces")
+	config.setPositions(&root, redactedUri(uri))
 
-		key := os.Getenv("DO_SPACES_ACCESS_KEY_ID")
-		secret := os.Getenv("DO_SPACES_SECRET_ACCESS_KEY")
-		creds := awsCreds.NewStaticCredentialsProvider(key, secret, "")
+	return &config, nil
+}
 
-		resolver := aws.EndpointResolverWithOptionsFunc(
-			func(service, region string, options ...interface{}) (aws.Endpoint, error) {
-				doRegion := os.Getenv("DO_SPACES_REGION")
-				return aws.Endpoint{
-					URL: fmt.Sprintf("https://%s.digitaloceanspaces.com", doRegion),
-				}, nil
-			},
-		)
-
-		cfg, err := awsConfig.LoadDefaultConfig(ctx,
-			awsConfig.WithRegion("us-east-1"),
-			awsConfig.WithCredentialsProvider(creds),
-			awsConfig.WithEndpointResolverWithOptions(resolver),
-		)
-		if err != nil {
-			return nil, fmt.Errorf("Failed to load aws config: %w", err)
-		}
+// Returns the URI with the password replaced, so it can be shown in problems positions.
+func redactedUri(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	return u.Redacted()
+}
 
-		cl := s3.NewFromConfig(cfg)
+// Sets positions of the config values and the file URI to problems positions.
+func (c *BootstrapConfig) setPositions(root *yaml.Node, uri string) {
+	for i := range c.problems {
+		c.problems[i].Pos.URI = uri
+	}
 
-		input := &s3.GetObjectInput{
-			Bucket: aws.String(parsed.Host),
-			Key:    aws.String(parsed.Path),
-		}
-		obj, err := cl.GetObject(ctx, input)
-		if err != nil {
-			return nil, fmt.Errorf("Failed to get object: %w", err)
+	position := func(node *yaml.Node) ConfigPosition {
+		pos := nodePosition(node)
+		pos.URI = uri
+		return pos
+	}
+	notifications := func(node *yaml.Node, items []FeedNotificationsConfig) {
+		nodes := sequenceItems(mappingValue(node, "notifications"), len(items))
+		for i, n := range nodes {
+			items[i].Pos = position(n)
 		}
-		defer obj.Body.Close()
+	}
 
-		return io.ReadAll(obj.Body)
+	doc := root
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
 
-	default:
-		return nil, errors.New("Unknown config file URL scheme")
+	for i, n := range sequenceItems(mappingValue(doc, "notifiers"), len(c.Notifiers)) {
+		c.Notifiers[i].Pos = position(n)
+	}
+	for i, n := range sequenceItems(mappingValue(doc, "feeds"), len(c.Feeds)) {
+		c.Feeds[i].Pos = position(n)
+		notifications(n, c.Feeds[i].Notifications)
+	}
+	if c.Defaults != nil {
+		notifications(mappingValue(doc, "defaults"), c.Defaults.Notifications)
+	}
+	profiles := mappingValue(doc, "profiles")
+	for name, profile := range c.Profiles {
+		notifications(mappingValue(profiles, name), profile.Notifications)
 	}
 }
 
-// Returns the first non-empty string from the given list of strings.
-// If all strings are empty, returns an empty string.
-func coalesce(values ...string) string {
-	for _, v := range values {
-		if v != "" {
-			return v
+// Returns the mapping node value by key or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
 		}
 	}
-	return ""
+	return nil
+}
+
+// Returns the sequence node items if their count matches the decoded values count.
+func sequenceItems(node *yaml.Node, count int) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode || len(node.Content) != count {
+		return nil
+	}
+	return node.Content
 }
@@ -1,7 +1,9 @@
 package storages
 
 import (
+	"broadcaster/structs"
 	"broadcaster/utils/logging"
+	"broadcaster/utils/strutil"
 	"context"
 	"fmt"
 	"os"
@@ -44,15 +46,48 @@ func Test_GetFeedsFromConfig(t *testing.T) {
 		require.Equal(t, "https://www.hs.fi/rss/kaupunki.xml", feed.URL)
 		require.Equal(t, "fi", feed.Language)
 
-		require.Len(t, feed.Notifications, 1)
+		require.Len(t, feed.Notifications, 2)
 		notification := feed.Notifications[0]
 		require.NotEmpty(t, "en", notification.Type)
 		require.NotEmpty(t, "en", notification.To)
 		require.False(t, notification.Muted)
 		require.Equal(t, "en", notification.Translate.To)
+		require.Equal(t, "news-bot", feed.Notifications[1].Notifier)
 	})
 }
 
+func Test_GetBootstrapConfig(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewLogger("fatal", "pretty")
+
+	cpath, err := os.Getwd()
+	require.NoError(t, err)
+
+	cfg, err := GetBootstrapConfig(ctx, fmt.Sprintf("file:///%s/testdata/bootstrap_ok.yml", cpath), logger)
+	require.NoError(t, err)
+	require.Len(t, cfg.Feeds, 1)
+	require.Len(t, cfg.Notifiers, 1)
+
+	notifier := cfg.Notifiers[0]
+	require.Equal(t, "news-bot", notifier.Name)
+	require.Equal(t, "telegram", notifier.Type)
+	require.Equal(t, map[string]interface{}{"token": "env:NEWS_BOT_TOKEN", "parse_mode": "markdownv2"}, notifier.Config)
+}
+
+func Test_BootstrapConfig_validateNotifiers(t *testing.T) {
+	cfg := BootstrapConfig{Notifiers: []NotifierConfig{{Name: "one", Type: "slack"}, {Name: "two", Type: "slack"}}}
+	require.Empty(t, cfg.validateNotifiers())
+
+	cfg = BootstrapConfig{Notifiers: []NotifierConfig{{Type: "slack"}}}
+	require.ErrorContains(t, cfg.validateNotifiers(), "name is required")
+
+	cfg = BootstrapConfig{Notifiers: []NotifierConfig{{Name: "one"}}}
+	require.ErrorContains(t, cfg.validateNotifiers(), "type is required")
+
+	cfg = BootstrapConfig{Notifiers: []NotifierConfig{{Name: "one", Type: "slack"}, {Name: "one", Type: "teams"}}}
+	require.ErrorContains(t, cfg.validateNotifiers(), "Duplicate notifier 'one'")
+}
+
 func Test_FeedConfig_ToRssFeed(t *testing.T) {
 	cfg := FeedConfig{
 		Source:     "Dummy Feed",
@@ -60,6 +95,7 @@ func Test_FeedConfig_ToRssFeed(t *testing.T) {
 		URL:        "https://example.com/rss.xml",
 		Language:   "en",
 		ItemsLimit: 10,
+		IdStrategy: "link",
 		Notifications: []FeedNotificationsConfig{
 			{
 				Type:  "email",
@@ -70,13 +106,27 @@ func Test_FeedConfig_ToRssFeed(t *testing.T) {
 				},
 			},
 			{
-				Type:  "email",
-				To:    []string{"fake2@email"},
-				Muted: false,
+				Type:     "email",
+				Notifier: "work",
+				To:       []string{"fake2@email"},
+				Muted:    false,
 				Translate: FeedTranslationsConfig{
 					From: "eng",
 					To:   "fi",
 				},
+				Bot:            "news",
+				Silent:         true,
+				ProtectContent: true,
+				Preview:        new(bool),
+				Priority:       "high",
+				Tags:           []string{"warning"},
+				QuietHours: &FeedQuietHoursConfig{
+					Timezone: "Europe/Helsinki",
+					From:     "22:00",
+					To:       "07:00",
+					Weekdays: []string{"sat", "sun"},
+					Action:   "silent",
+				},
 			},
 		},
 	}
@@ -88,6 +138,10 @@ func Test_FeedConfig_ToRssFeed(t *testing.T) {
 	require.Equal(t, cfg.URL, feed.URL)
 	require.Equal(t, cfg.Language, feed.Language)
 	require.Equal(t, cfg.ItemsLimit, feed.ItemsLimit)
+	require.Equal(t, structs.ItemIdStrategyLink, feed.IdStrategy)
+	require.Equal(t, structs.FeedStatusActive, feed.Status)
+	cfg.Disabled = true
+	require.Equal(t, structs.FeedStatusDisabled, cfg.ToRssFeed().Status)
 
 	require.Equal(t, len(cfg.Notifications), len(feed.Notifications))
 	require.Equal(t, cfg.Notifications[0].Type, feed.Notifications[0].Type)
@@ -96,10 +150,101 @@ func Test_FeedConfig_ToRssFeed(t *testing.T) {
 	require.Equal(t, cfg.Notifications[0].Translate.To, feed.Notifications[0].Translate.To)
 	require.Equal(t, cfg.Language, feed.Notifications[0].Translate.From)
 	require.Equal(t, cfg.Notifications[1].Translate.From, feed.Notifications[1].Translate.From)
+	require.Equal(t, "work", feed.Notifications[1].Notifier)
+	require.Equal(t, "news", feed.Notifications[1].Bot)
+	require.True(t, feed.Notifications[1].Silent)
+	require.True(t, feed.Notifications[1].ProtectContent)
+	require.NotNil(t, feed.Notifications[1].Preview)
+	require.False(t, *feed.Notifications[1].Preview)
+	require.Nil(t, feed.Notifications[0].Preview)
+	require.Equal(t, structs.NotificationPriorityHigh, feed.Notifications[1].Priority)
+	require.Equal(t, []string{"warning"}, feed.Notifications[1].Tags)
+	require.Nil(t, feed.Notifications[0].QuietHours)
+	require.Equal(t, &structs.QuietHours{
+		Timezone: "Europe/Helsinki",
+		From:     "22:00",
+		To:       "07:00",
+		Weekdays: []string{"sat", "sun"},
+		Action:   structs.QuietHoursActionSilent,
+	}, feed.Notifications[1].QuietHours)
 }
 
 func Test_coalesce(t *testing.T) {
-	require.Equal(t, "", coalesce())
-	require.Equal(t, "", coalesce("", ""))
-	require.Equal(t, "two", coalesce("", "two", "three"))
+	require.Equal(t, "", strutil.Coalesce())
+	require.Equal(t, "", strutil.Coalesce("", ""))
+	require.Equal(t, "two", strutil.Coalesce("", "two", "three"))
+}
+
+func Test_GetBootstrapConfig_includes(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewLogger("fatal", "pretty")
+
+	cpath, err := os.Getwd()
+	require.NoError(t, err)
+
+	cfg, err := GetBootstrapConfig(ctx, fmt.Sprintf("file:///%s/testdata/bootstrap_include.yml", cpath), logger)
+	require.NoError(t, err)
+	require.Nil(t, cfg.Include)
+	require.Len(t, cfg.Profiles, 2)
+	require.Len(t, cfg.Feeds, 3)
+
+	// Defaults only
+	yle := cfg.Feeds[0]
+	require.Equal(t, "fi", yle.Language)
+	require.Equal(t, 5, yle.ItemsLimit)
+	require.Len(t, yle.Notifications, 1)
+	require.Equal(t, []string{"-100"}, yle.Notifications[0].To)
+
+	// Profiles notifications go before the feed ones, defaults notifications aren't added
+	hs := cfg.Feeds[1]
+	require.Equal(t, "en", hs.Language)
+	require.Equal(t, 10, hs.ItemsLimit)
+	require.Len(t, hs.Notifications, 3)
+	require.Equal(t, []string{"@news"}, hs.Notifications[0].To)
+	require.Equal(t, []string{"#news"}, hs.Notifications[1].To)
+	require.Equal(t, []string{"#city"}, hs.Notifications[2].To)
+
+	// Included feed
+	bbc := cfg.Feeds[2]
+	require.Equal(t, "BBC.World", bbc.ToRssFeed().Id)
+	require.Equal(t, 3, bbc.ItemsLimit)
+	require.Len(t, bbc.Notifications, 1)
+}
+
+func Test_BootstrapConfig_expandFeeds(t *testing.T) {
+	cfg := BootstrapConfig{
+		Profiles: map[string]FeedTemplateConfig{"one": {Language: "en"}, "two": {Language: "fi", ItemsLimit: 7}},
+		Feeds:    []FeedConfig{{Source: "Feed", Profiles: []string{"one", "two"}}},
+	}
+	require.Empty(t, cfg.expandFeeds())
+	require.Equal(t, "en", cfg.Feeds[0].Language, "First profile wins")
+	require.Equal(t, 7, cfg.Feeds[0].ItemsLimit)
+
+	cfg = BootstrapConfig{Feeds: []FeedConfig{{Source: "Feed", Profiles: []string{"missing"}}}}
+	require.ErrorContains(t, cfg.expandFeeds(), "unknown profile 'missing'")
+}
+
+func Test_BootstrapConfig_ExpandImportedFeeds(t *testing.T) {
+	cfg := BootstrapConfig{
+		Defaults: &FeedTemplateConfig{Notifications: []FeedNotificationsConfig{{Type: "slack", To: []string{"#all"}}}},
+		Profiles: map[string]FeedTemplateConfig{"fi": {Language: "fi", Notifications: []FeedNotificationsConfig{{Type: "slack", To: []string{"#fi"}}}}},
+		Feeds:    []FeedConfig{{Source: "Config"}},
+	}
+	imported := []FeedConfig{{Source: "One"}, {Source: "Two", Language: "en"}}
+
+	feeds, err := cfg.ExpandImportedFeeds(imported, "fi")
+	require.NoError(t, err)
+	require.Len(t, feeds, 2)
+	require.Equal(t, "fi", feeds[0].Language)
+	require.Equal(t, "en", feeds[1].Language)
+	require.Equal(t, []string{"#fi"}, feeds[1].Notifications[0].To)
+	require.Empty(t, imported[0].Profiles, "Imported feeds aren't changed")
+	require.Len(t, cfg.Feeds, 1)
+
+	feeds, err = cfg.ExpandImportedFeeds(imported)
+	require.NoError(t, err)
+	require.Equal(t, []string{"#all"}, feeds[0].Notifications[0].To)
+
+	_, err = cfg.ExpandImportedFeeds(imported, "missing")
+	require.EqualError(t, err, "Unknown profile 'missing'")
 }
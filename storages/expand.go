@@ -0,0 +1,152 @@
+package storages
+
+import (
+	"broadcaster/utils/strutil"
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+
+	"go.uber.org/zap"
+)
+
+// Loads included config files recursively and merges their profiles, notifiers and feeds into the config.
+// Each file is included once, so include cycles are ignored.
+func (c *BootstrapConfig) resolveIncludes(ctx context.Context, uri string, logger *zap.SugaredLogger) error {
+	root, err := url.Parse(uri)
+	if err != nil {
+		return fmt.Errorf("Failed to parse config uri: %w", err)
+	}
+
+	loaded := map[string]struct{}{includeKey(root): {}}
+	ctx = contextWithRootUri(ctx, root)
+
+	var include func(base *url.URL, includes []string) error
+	include = func(base *url.URL, includes []string) error {
+		for _, inc := range includes {
+			ref, err := url.Parse(inc)
+			if err != nil {
+				return fmt.Errorf("Failed to parse include '%s': %w", inc, err)
+			}
+
+			uris, err := globUri(ctx, base.ResolveReference(ref))
+			if err != nil {
+				return fmt.Errorf("Failed to resolve include '%s': %w", inc, err)
+			}
+
+			for _, u := range uris {
+				key := includeKey(u)
+				if _, ok := loaded[key]; ok {
+					continue
+				}
+				loaded[key] = struct{}{}
+
+				logger.With("uri", u.Redacted()).Debug("Including config file")
+
+				cfg, err := loadBootstrapFile(ctx, u.String(), logger)
+				if err != nil {
+					return fmt.Errorf("Failed to include '%s': %w", u.Redacted(), err)
+				}
+				if cfg.Defaults != nil {
+					c.problems.Add(ConfigPosition{URI: u.Redacted()}, "Defaults are allowed in the root config only")
+				}
+
+				for name, profile := range cfg.Profiles {
+					if _, exists := c.Profiles[name]; exists {
+						c.problems.Add(ConfigPosition{URI: u.Redacted()}, "Duplicate profile '%s'", name)
+						continue
+					}
+					if c.Profiles == nil {
+						c.Profiles = make(map[string]FeedTemplateConfig)
+					}
+					c.Profiles[name] = profile
+				}
+				c.problems = append(c.problems, cfg.problems...)
+				c.Notifiers = append(c.Notifiers, cfg.Notifiers...)
+				c.Feeds = append(c.Feeds, cfg.Feeds...)
+
+				if err := include(u, cfg.Include); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := include(root, c.Include); err != nil {
+		return err
+	}
+	c.Include = nil
+
+	return nil
+}
+
+// Returns the URI with the cleaned path, so the same file referenced by different paths is included once.
+func includeKey(uri *url.URL) string {
+	u := *uri
+	u.Path = path.Clean(u.Path)
+	return u.String()
+}
+
+// Applies the config profiles and defaults to the feeds imported at runtime (eg from OPML) the same way
+// as to the config feeds. The profiles are added to every imported feed.
+func (c *BootstrapConfig) ExpandImportedFeeds(feeds []FeedConfig, profiles ...string) ([]FeedConfig, error) {
+	for _, name := range profiles {
+		if _, ok := c.Profiles[name]; !ok {
+			return nil, fmt.Errorf("Unknown profile '%s'", name)
+		}
+	}
+
+	imported := &BootstrapConfig{Defaults: c.Defaults, Profiles: c.Profiles}
+	for _, feed := range feeds {
+		feed.Profiles = append(append([]string{}, feed.Profiles...), profiles...)
+		imported.Feeds = append(imported.Feeds, feed)
+	}
+	if err := imported.expandFeeds().Err(); err != nil {
+		return nil, err
+	}
+	return imported.Feeds, nil
+}
+
+// Applies profiles and defaults to the feeds. Feed settings take precedence over profiles ones,
+// profiles are applied in the listed order and take precedence over defaults.
+// Notifications of the feed profiles are added before the feed ones, default notifications are used
+// only if the feed has no notifications otherwise.
+func (c *BootstrapConfig) expandFeeds() ConfigProblems {
+	var problems ConfigProblems
+	for i, feed := range c.Feeds {
+		templates := make([]FeedTemplateConfig, 0, len(feed.Profiles)+1)
+		for _, name := range feed.Profiles {
+			profile, ok := c.Profiles[name]
+			if !ok {
+				problems.Add(feed.Pos, "Feed '%s' references unknown profile '%s'", feed.ToRssFeed().Id, name)
+				continue
+			}
+			templates = append(templates, profile)
+		}
+
+		var notifications []FeedNotificationsConfig
+		for _, t := range templates {
+			notifications = append(notifications, t.Notifications...)
+		}
+		notifications = append(notifications, feed.Notifications...)
+
+		if c.Defaults != nil {
+			templates = append(templates, *c.Defaults)
+			if len(notifications) == 0 {
+				notifications = append(notifications, c.Defaults.Notifications...)
+			}
+		}
+
+		for _, t := range templates {
+			feed.Language = strutil.Coalesce(feed.Language, t.Language)
+			if feed.ItemsLimit == 0 {
+				feed.ItemsLimit = t.ItemsLimit
+			}
+		}
+		feed.Notifications = notifications
+
+		c.Feeds[i] = feed
+	}
+	return problems
+}
@@ -2,34 +2,68 @@ package memory
 
 import (
 	"broadcaster/storages"
+	"broadcaster/structs"
 	"context"
 )
 
 // Initializes DB from config file.
 func (s *Storage) BootstrapFromConfigFile(ctx context.Context, uri string) error {
-	feeds, err := storages.GetFeedsFromConfig(ctx, uri, s.logger)
+	config, err := storages.GetBootstrapConfig(ctx, uri, s.logger)
 	if err != nil {
 		return err
 	}
+	return s.BootstrapFromConfig(ctx, config)
+}
+
+// Initializes DB from loaded bootstrap config.
+func (s *Storage) BootstrapFromConfig(ctx context.Context, config *storages.BootstrapConfig) error {
+	feeds := config.RssFeeds()
 	s.logger.Debugf("Found '%d' feeds in config file", len(feeds))
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	for _, feed := range feeds {
-		conv := feed.ToRssFeed()
-
-		if _, ok := s.feeds[conv.Id]; ok {
-			s.logger.Debugf("Feed '%s' already in state", conv.Id)
+		if _, ok := s.feeds[feed.Id]; ok {
+			s.logger.Debugf("Feed '%s' already in state", feed.Id)
 			continue
 		}
 
-		s.logger.With("feed_id", conv.Id).Debug("Adding feed to state")
+		s.logger.With("feed_id", feed.Id).Debug("Adding feed to state")
 
-		s.feeds[conv.Id] = conv
+		s.feeds[feed.Id] = feed
 	}
 
 	s.logger.Infof("Loaded '%d' feeds", len(s.feeds))
 
 	return nil
 }
+
+// Applies reloaded bootstrap config: adds new feeds, updates changed ones and removes feeds missing in the config.
+// Returns applied changes.
+func (s *Storage) ReloadFromConfig(ctx context.Context, config *storages.BootstrapConfig) (storages.FeedsDiff, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := make([]structs.RssFeed, 0, len(s.feeds))
+	for _, feed := range s.feeds {
+		current = append(current, feed)
+	}
+
+	diff := storages.DiffFeeds(current, config.RssFeeds())
+
+	for _, feed := range diff.Added {
+		s.logger.With("feed_id", feed.Id).Info("Adding feed")
+		s.feeds[feed.Id] = feed
+	}
+	for _, feed := range diff.Updated {
+		s.logger.With("feed_id", feed.Id).Info("Updating feed")
+		s.feeds[feed.Id] = feed
+	}
+	for _, id := range diff.Removed {
+		s.logger.With("feed_id", id).Info("Removing feed")
+		delete(s.feeds, id)
+	}
+
+	return diff, nil
+}
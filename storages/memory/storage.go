@@ -5,6 +5,8 @@ import (
 	"broadcaster/structs"
 	"context"
 	"errors"
+	"slices"
+	"sort"
 	"sync"
 
 	"go.uber.org/zap"
@@ -54,7 +56,23 @@ func (s *Storage) Feeds() storages.FeedsStorage {
 // Interface conformance assertion
 var _ storages.FeedsStorage = &Feeds{}
 
+func (s *Feeds) Create(ctx context.Context, req storages.FeedsStorageCreateRequest) (*structs.RssFeed, error) {
+	s.st.mu.Lock()
+	defer s.st.mu.Unlock()
+
+	if _, exists := s.st.feeds[req.Id]; exists {
+		return nil, storages.ItemExistsError
+	}
+	feed := req.ToRssFeed()
+	s.st.feeds[req.Id] = feed
+
+	return &feed, nil
+}
+
 func (s *Feeds) List(ctx context.Context) ([]structs.RssFeed, error) {
+	s.st.mu.RLock()
+	defer s.st.mu.RUnlock()
+
 	var result []structs.RssFeed
 
 	for _, feed := range s.st.feeds {
@@ -64,6 +82,9 @@ func (s *Feeds) List(ctx context.Context) ([]structs.RssFeed, error) {
 }
 
 func (s *Feeds) Find(ctx context.Context, req storages.FeedsStorageFindRequest) (*structs.RssFeed, error) {
+	s.st.mu.RLock()
+	defer s.st.mu.RUnlock()
+
 	if feed, exists := s.st.feeds[req.Id]; exists {
 		return &feed, nil
 	}
@@ -83,7 +104,33 @@ func (s *Feeds) Delete(ctx context.Context, req storages.FeedsStorageDeleteReque
 }
 
 func (s *Feeds) Update(ctx context.Context, req storages.FeedsStorageUpdateRequest) (*structs.RssFeed, error) {
-	return nil, nil
+	s.st.mu.Lock()
+	defer s.st.mu.Unlock()
+
+	feed, exists := s.st.feeds[req.Id]
+	if !exists {
+		return nil, errors.New("Feed not found")
+	}
+
+	if req.URL != "" {
+		feed.URL = req.URL
+	}
+	if req.Language != "" {
+		feed.Language = req.Language
+	}
+	if req.ItemsLimit > 0 {
+		feed.ItemsLimit = req.ItemsLimit
+	}
+	if req.Notify != nil {
+		feed.Notifications = req.Notify
+	}
+	if req.Status != "" && (len(req.IfStatus) == 0 || slices.Contains(req.IfStatus, feed.Status)) {
+		feed.Status = req.Status
+		feed.StatusMessage = req.StatusMessage
+	}
+	s.st.feeds[req.Id] = feed
+
+	return &feed, nil
 }
 
 // ------------------------------------------------------------------------------------------------
@@ -100,6 +147,9 @@ func (s *Storage) FeedItems() storages.FeedItemsStorage {
 var _ storages.FeedItemsStorage = &FeedItems{}
 
 func (s *FeedItems) Find(ctx context.Context, req storages.FeedItemsStorageFindRequest) (*structs.RssFeedItem, error) {
+	s.st.mu.RLock()
+	defer s.st.mu.RUnlock()
+
 	if feedItem, exists := s.st.feedsItems[req.Id]; exists {
 		return &feedItem, nil
 	}
@@ -115,10 +165,41 @@ func (s *FeedItems) Create(ctx context.Context, req storages.FeedItemsCreateRequ
 }
 
 func (s *FeedItems) List(ctx context.Context, req storages.FeedItemsListRequest) ([]structs.RssFeedItem, error) {
+	s.st.mu.RLock()
+	defer s.st.mu.RUnlock()
+
+	matches := func(values []string, value string) bool {
+		return len(values) == 0 || slices.Contains(values, value)
+	}
+
 	var result []structs.RssFeedItem
 	for _, feedItem := range s.st.feedsItems {
+		if !matches(req.FeedIds, feedItem.FeedId) ||
+			!matches(req.Sources, feedItem.Source) ||
+			!matches(req.Languages, feedItem.Language) {
+			continue
+		}
+		if len(req.Categories) > 0 && !slices.ContainsFunc(feedItem.Categories, func(c string) bool {
+			return slices.Contains(req.Categories, c)
+		}) {
+			continue
+		}
+		if req.PubDate != nil && !feedItem.PubDate.After(*req.PubDate) {
+			continue
+		}
 		result = append(result, feedItem)
 	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].PubDate.Equal(result[j].PubDate) {
+			return result[i].PubDate.After(result[j].PubDate)
+		}
+		return result[i].Id < result[j].Id
+	})
+	if req.Limit > 0 && len(result) > req.Limit {
+		result = result[:req.Limit]
+	}
+
 	return result, nil
 }
 
@@ -127,11 +208,12 @@ func (s *FeedItems) Update(ctx context.Context, req storages.FeedItemsUpdateRequ
 }
 
 func (s *FeedItems) Delete(ctx context.Context, req storages.FeedItemsStorageDeleteRequest) error {
+	s.st.mu.Lock()
+	defer s.st.mu.Unlock()
+
 	if _, exists := s.st.feedsItems[req.Id]; !exists {
 		return storages.ItemNotFoundError
 	}
-	s.st.mu.Lock()
 	delete(s.st.feedsItems, req.Id)
-	s.st.mu.Unlock()
 	return nil
 }
@@ -0,0 +1,148 @@
+package storages
+
+import (
+	"broadcaster/structs"
+	"broadcaster/utils/strutil"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    opmlHead `xml:"head"`
+	Body    opmlBody `xml:"body"`
+}
+
+type opmlHead struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type opmlBody struct {
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Language string        `xml:"language,attr,omitempty"`
+	Category string        `xml:"category,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// Converts OPML outlines to feeds configs. The nearest folder name is used as the feed category.
+func ParseOPML(r io.Reader) ([]FeedConfig, error) {
+	var doc opmlDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("Failed to parse OPML: %w", err)
+	}
+
+	var result []FeedConfig
+
+	var walk func(outlines []opmlOutline, folder string)
+	walk = func(outlines []opmlOutline, folder string) {
+		for _, o := range outlines {
+			if o.XMLURL == "" {
+				walk(o.Outlines, strutil.Coalesce(o.Title, o.Text))
+				continue
+			}
+
+			category := folder
+			if category == "" {
+				// OPML 2.0 categories are comma-separated slash-delimited paths
+				first, _, _ := strings.Cut(o.Category, ",")
+				first = strings.Trim(first, "/ ")
+				category = first[strings.LastIndex(first, "/")+1:]
+			}
+
+			result = append(result, FeedConfig{
+				Source:   strutil.Coalesce(o.Title, o.Text, hostname(o.XMLURL)),
+				Category: category,
+				URL:      o.XMLURL,
+				Language: o.Language,
+			})
+		}
+	}
+	walk(doc.Body.Outlines, "")
+
+	return result, nil
+}
+
+func hostname(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// Writes feeds as OPML. Feeds are grouped into folders by categories.
+// Only RSS/Atom feeds are written, HTML pages, JSON APIs and sitemaps aren't feeds for readers.
+func WriteOPML(w io.Writer, title string, feeds []structs.RssFeed) error {
+	var sorted []structs.RssFeed
+	for _, feed := range feeds {
+		if feed.Type != "" && feed.Type != structs.FeedTypeRSS {
+			continue
+		}
+		if u, err := ExpandFeedURL(feed.URL); err == nil {
+			feed.URL = u
+		}
+		sorted = append(sorted, feed)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Category != sorted[j].Category {
+			return sorted[i].Category < sorted[j].Category
+		}
+		return sorted[i].Id < sorted[j].Id
+	})
+
+	doc := opmlDocument{
+		Version: "2.0",
+		Head: opmlHead{
+			Title:       title,
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	folders := make(map[string]int) // Category -> folder outline index
+	for _, feed := range sorted {
+		outline := opmlOutline{
+			Text:     feed.Source,
+			Title:    feed.Source,
+			Type:     "rss",
+			XMLURL:   feed.URL,
+			Language: feed.Language,
+		}
+		if feed.Category == "" {
+			doc.Body.Outlines = append(doc.Body.Outlines, outline)
+			continue
+		}
+		i, exists := folders[feed.Category]
+		if !exists {
+			i = len(doc.Body.Outlines)
+			folders[feed.Category] = i
+			doc.Body.Outlines = append(doc.Body.Outlines, opmlOutline{Text: feed.Category, Title: feed.Category})
+		}
+		doc.Body.Outlines[i].Outlines = append(doc.Body.Outlines[i].Outlines, outline)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("Failed to encode OPML: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
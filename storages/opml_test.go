@@ -0,0 +1,55 @@
+package storages
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseOPML(t *testing.T) {
+	f, err := os.Open("testdata/feeds.opml")
+	require.NoError(t, err)
+	defer f.Close()
+
+	feeds, err := ParseOPML(f)
+	require.NoError(t, err)
+	require.Equal(t, []FeedConfig{
+		{Source: "Yle", Category: "News", URL: "https://feeds.yle.fi/uutiset/v1/majorHeadlines/YLE_UUTISET.rss"},
+		{Source: "Helsingin Sanomat", Category: "Finland", URL: "https://www.hs.fi/rss/kaupunki.xml", Language: "fi"},
+		{Source: "No folder", Category: "Go", URL: "https://example.com/rss"},
+	}, feeds)
+
+	_, err = ParseOPML(strings.NewReader("not opml"))
+	require.Error(t, err)
+}
+
+func Test_WriteOPML(t *testing.T) {
+	feeds := []FeedConfig{
+		{Source: "Yle", Category: "News", URL: "https://yle.fi/rss", Language: "fi"},
+		{Source: "BBC", Category: "News", URL: "https://bbc.co.uk/rss", Language: "en"},
+		{Source: "Blog", URL: "https://blog.example.com/rss"},
+	}
+	cfg := &BootstrapConfig{Feeds: append(feeds, FeedConfig{
+		Source: "Page",
+		URL:    "https://page.example.com/news",
+		Type:   "html",
+		HTML:   &HTMLSelectorsConfig{Item: "article", Title: "h2"},
+	}, FeedConfig{
+		Source: "Sitemap",
+		URL:    "https://sitemap.example.com/news-sitemap.xml",
+		Type:   "sitemap",
+	})}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteOPML(&buf, "Test", cfg.RssFeeds()))
+	require.Contains(t, buf.String(), `<outline text="News" title="News">`)
+	require.NotContains(t, buf.String(), "page.example.com", "HTML pages aren't feeds for readers")
+	require.NotContains(t, buf.String(), "sitemap.example.com", "Sitemaps aren't feeds for readers")
+
+	parsed, err := ParseOPML(&buf)
+	require.NoError(t, err)
+	require.ElementsMatch(t, feeds, parsed)
+}
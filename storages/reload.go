@@ -0,0 +1,83 @@
+package storages
+
+import (
+	"broadcaster/structs"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Returns feeds to keep in storage. The first feed wins for duplicate ids.
+func (c *BootstrapConfig) RssFeeds() []structs.RssFeed {
+	var result []structs.RssFeed
+	seen := make(map[string]struct{}, len(c.Feeds))
+	for _, fc := range c.Feeds {
+		feed := fc.ToRssFeed()
+		if _, exists := seen[feed.Id]; exists {
+			continue
+		}
+		seen[feed.Id] = struct{}{}
+		result = append(result, feed)
+	}
+	return result
+}
+
+// Changes required to turn the stored feeds into the configured ones.
+type FeedsDiff struct {
+	Added   []structs.RssFeed
+	Updated []structs.RssFeed
+	Removed []string // Feeds ids
+}
+
+func (d FeedsDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Updated) == 0 && len(d.Removed) == 0
+}
+
+// Returns the changes summary.
+func (d FeedsDiff) String() string {
+	return fmt.Sprintf("%d added, %d updated, %d removed", len(d.Added), len(d.Updated), len(d.Removed))
+}
+
+// Compares the current feeds with the desired ones. Results are sorted by feeds ids.
+// Runtime statuses (paused and erroring) of active feeds are kept. Feeds created by the API aren't removed.
+func DiffFeeds(current, desired []structs.RssFeed) FeedsDiff {
+	var diff FeedsDiff
+
+	existing := make(map[string]structs.RssFeed, len(current))
+	for _, feed := range current {
+		existing[feed.Id] = feed
+	}
+
+	keep := make(map[string]struct{}, len(desired))
+	for _, feed := range desired {
+		keep[feed.Id] = struct{}{}
+
+		old, exists := existing[feed.Id]
+		if exists && old.Status.Runtime() && feed.Status == structs.FeedStatusActive {
+			feed.Status = old.Status
+			feed.StatusMessage = old.StatusMessage
+		}
+
+		switch {
+		case !exists:
+			diff.Added = append(diff.Added, feed)
+		case !reflect.DeepEqual(old, feed):
+			diff.Updated = append(diff.Updated, feed)
+		}
+	}
+
+	for _, feed := range current {
+		if _, exists := keep[feed.Id]; !exists && !feed.Managed {
+			diff.Removed = append(diff.Removed, feed.Id)
+		}
+	}
+
+	byId := func(feeds []structs.RssFeed) func(i, j int) bool {
+		return func(i, j int) bool { return feeds[i].Id < feeds[j].Id }
+	}
+	sort.Slice(diff.Added, byId(diff.Added))
+	sort.Slice(diff.Updated, byId(diff.Updated))
+	sort.Strings(diff.Removed)
+
+	return diff
+}
@@ -0,0 +1,86 @@
+package storages
+
+import (
+	"broadcaster/structs"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BootstrapConfig_RssFeeds(t *testing.T) {
+	cfg := BootstrapConfig{
+		Feeds: []FeedConfig{
+			{Source: "One", URL: "https://one.example.com/rss"},
+			{Source: "Two", URL: "https://two.example.com/rss", Disabled: true},
+			{Source: "One", URL: "https://duplicate.example.com/rss"},
+			{Source: "Three", URL: "https://three.example.com/rss"},
+		},
+	}
+
+	feeds := cfg.RssFeeds()
+	require.Len(t, feeds, 3)
+	require.Equal(t, "One", feeds[0].Id)
+	require.Equal(t, "https://one.example.com/rss", feeds[0].URL, "First feed wins")
+	require.Equal(t, structs.FeedStatusActive, feeds[0].Status)
+	require.Equal(t, "Two", feeds[1].Id)
+	require.Equal(t, structs.FeedStatusDisabled, feeds[1].Status)
+	require.Equal(t, "Three", feeds[2].Id)
+}
+
+func Test_DiffFeeds(t *testing.T) {
+	current := []structs.RssFeed{
+		{Id: "same", URL: "https://same.example.com/rss"},
+		{Id: "changed", URL: "https://changed.example.com/rss"},
+		{Id: "removed", URL: "https://removed.example.com/rss"},
+		{Id: "imported", URL: "https://imported.example.com/rss", Managed: true},
+		{Id: "notifications", Notifications: []structs.RssFeedNotification{{Type: "slack", To: []string{"#news"}}}},
+	}
+	desired := []structs.RssFeed{
+		{Id: "same", URL: "https://same.example.com/rss"},
+		{Id: "changed", URL: "https://changed.example.com/atom"},
+		{Id: "notifications", Notifications: []structs.RssFeedNotification{{Type: "slack", To: []string{"#world"}}}},
+		{Id: "b-added"},
+		{Id: "a-added"},
+	}
+
+	diff := DiffFeeds(current, desired)
+	require.False(t, diff.Empty())
+	require.Equal(t, "2 added, 2 updated, 1 removed", diff.String())
+
+	require.Len(t, diff.Added, 2)
+	require.Equal(t, "a-added", diff.Added[0].Id)
+	require.Equal(t, "b-added", diff.Added[1].Id)
+
+	require.Len(t, diff.Updated, 2)
+	require.Equal(t, "changed", diff.Updated[0].Id)
+	require.Equal(t, "https://changed.example.com/atom", diff.Updated[0].URL)
+	require.Equal(t, "notifications", diff.Updated[1].Id)
+
+	require.Equal(t, []string{"removed"}, diff.Removed)
+
+	require.True(t, DiffFeeds(current, current).Empty())
+}
+
+func Test_DiffFeeds_statuses(t *testing.T) {
+	current := []structs.RssFeed{
+		{Id: "paused", Status: structs.FeedStatusPaused},
+		{Id: "erroring", Status: structs.FeedStatusErroring, StatusMessage: "timeout"},
+		{Id: "disabled", Status: structs.FeedStatusPaused},
+		{Id: "enabled", Status: structs.FeedStatusDisabled},
+	}
+	desired := []structs.RssFeed{
+		{Id: "paused", Status: structs.FeedStatusActive},
+		{Id: "erroring", Status: structs.FeedStatusActive},
+		{Id: "disabled", Status: structs.FeedStatusDisabled},
+		{Id: "enabled", Status: structs.FeedStatusActive},
+	}
+
+	diff := DiffFeeds(current, desired)
+	require.Empty(t, diff.Added)
+	require.Empty(t, diff.Removed)
+	require.Len(t, diff.Updated, 2, "Runtime statuses of active feeds are kept")
+	require.Equal(t, "disabled", diff.Updated[0].Id)
+	require.Equal(t, structs.FeedStatusDisabled, diff.Updated[0].Status)
+	require.Equal(t, "enabled", diff.Updated[1].Id)
+	require.Equal(t, structs.FeedStatusActive, diff.Updated[1].Status)
+}
@@ -0,0 +1,8 @@
+package storages
+
+import _ "embed"
+
+// JSON Schema of the bootstrap config file for editors integration.
+//
+//go:embed bootstrap.schema.json
+var BootstrapSchema []byte
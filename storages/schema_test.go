@@ -0,0 +1,79 @@
+package storages
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type jsonSchema struct {
+	Ref                  string                `json:"$ref"`
+	Properties           map[string]jsonSchema `json:"properties"`
+	Items                *jsonSchema           `json:"items"`
+	AdditionalProperties interface{}           `json:"additionalProperties"`
+	Defs                 map[string]jsonSchema `json:"$defs"`
+}
+
+// Checks the schema describes all config fields.
+func Test_BootstrapSchema(t *testing.T) {
+	var root jsonSchema
+	require.NoError(t, json.Unmarshal(BootstrapSchema, &root))
+
+	resolve := func(s jsonSchema) jsonSchema {
+		if name, ok := strings.CutPrefix(s.Ref, "#/$defs/"); ok {
+			def, exists := root.Defs[name]
+			require.True(t, exists, s.Ref)
+			return def
+		}
+		return s
+	}
+
+	var check func(path string, s jsonSchema, typ reflect.Type)
+	check = func(path string, s jsonSchema, typ reflect.Type) {
+		s = resolve(s)
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		switch typ.Kind() {
+		case reflect.Slice:
+			if typ.Elem().Kind() == reflect.Struct {
+				require.NotNil(t, s.Items, path)
+				check(path+"[]", *s.Items, typ.Elem())
+			}
+		case reflect.Map:
+			if typ.Elem().Kind() == reflect.Struct {
+				raw, err := json.Marshal(s.AdditionalProperties)
+				require.NoError(t, err)
+				var values jsonSchema
+				require.NoError(t, json.Unmarshal(raw, &values))
+				check(path+"{}", values, typ.Elem())
+			}
+		case reflect.Struct:
+			var fields []string
+			for i := 0; i < typ.NumField(); i++ {
+				f := typ.Field(i)
+				name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+				if !f.IsExported() || name == "-" {
+					continue
+				}
+				fields = append(fields, name)
+				prop, ok := s.Properties[name]
+				require.True(t, ok, "%s.%s isn't in schema", path, name)
+				check(path+"."+name, prop, f.Type)
+			}
+			props := make([]string, 0, len(s.Properties))
+			for name := range s.Properties {
+				props = append(props, name)
+			}
+			sort.Strings(fields)
+			sort.Strings(props)
+			require.Equal(t, fields, props, path)
+		}
+	}
+
+	check("", root, reflect.TypeOf(BootstrapConfig{}))
+}
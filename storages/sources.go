@@ -0,0 +1,365 @@
+package storages
+
+import (
+	"broadcaster/utils/info"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	googleStorage "cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsConfig "github.com/aws/aws-sdk-go-v2/config"
+	awsCreds "github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/kelseyhightower/envconfig"
+	"go.uber.org/zap"
+)
+
+// Loads bootstrap config file content.
+type ConfigSource interface {
+	Load(ctx context.Context, uri *url.URL, logger *zap.SugaredLogger) ([]byte, error)
+}
+
+// Config source supporting glob patterns in URIs paths.
+type ConfigSourceGlobber interface {
+	// Returns URIs matching the pattern.
+	Glob(ctx context.Context, pattern *url.URL) ([]*url.URL, error)
+}
+
+// Config source function adapter.
+type ConfigSourceFunc func(ctx context.Context, uri *url.URL, logger *zap.SugaredLogger) ([]byte, error)
+
+func (f ConfigSourceFunc) Load(ctx context.Context, uri *url.URL, logger *zap.SugaredLogger) ([]byte, error) {
+	return f(ctx, uri, logger)
+}
+
+var (
+	sourcesMu sync.RWMutex
+	sources   = make(map[string]ConfigSource)
+)
+
+// Registers the config source for the URI scheme. Replaces the source already registered for the scheme.
+func RegisterConfigSource(scheme string, source ConfigSource) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	sources[scheme] = source
+}
+
+func init() {
+	RegisterConfigSource("file", fileSource{})
+	RegisterConfigSource("gs", ConfigSourceFunc(loadGoogleStorage))
+	RegisterConfigSource("s3", ConfigSourceFunc(loadS3))
+	RegisterConfigSource("do", ConfigSourceFunc(loadDigitalOceanSpaces))
+
+	web := newHttpSource()
+	RegisterConfigSource("http", web)
+	RegisterConfigSource("https", web)
+}
+
+func loadFileByUri(ctx context.Context, uri string, logger *zap.SugaredLogger) ([]byte, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse config uri: %w", err)
+	}
+
+	source, err := configSource(parsed.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	return source.Load(ctx, parsed, logger)
+}
+
+func configSource(scheme string) (ConfigSource, error) {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+
+	source, ok := sources[scheme]
+	if !ok {
+		return nil, errors.New("Unknown config file URL scheme")
+	}
+	return source, nil
+}
+
+// Returns URIs matching the glob pattern. URIs without glob meta characters are returned as is.
+func globUri(ctx context.Context, uri *url.URL) ([]*url.URL, error) {
+	if !strings.ContainsAny(uri.Path, "*?[") {
+		return []*url.URL{uri}, nil
+	}
+
+	source, err := configSource(uri.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	globber, ok := source.(ConfigSourceGlobber)
+	if !ok {
+		return nil, fmt.Errorf("Glob patterns aren't supported by '%s' scheme", uri.Scheme)
+	}
+	return globber.Glob(ctx, uri)
+}
+
+// Local filesystem source.
+type fileSource struct{}
+
+func (fileSource) Load(ctx context.Context, uri *url.URL, logger *zap.SugaredLogger) ([]byte, error) {
+	logger.With("path", uri.Path).Debug("Loading from filesytsem")
+	return os.ReadFile(uri.Path)
+}
+
+// Returns matching files sorted by name.
+func (fileSource) Glob(ctx context.Context, pattern *url.URL) ([]*url.URL, error) {
+	matches, err := filepath.Glob(pattern.Path)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid glob pattern: %w", err)
+	}
+
+	result := make([]*url.URL, 0, len(matches))
+	for _, path := range matches {
+		result = append(result, &url.URL{Scheme: pattern.Scheme, Path: path})
+	}
+	return result, nil
+}
+
+func loadGoogleStorage(ctx context.Context, uri *url.URL, logger *zap.SugaredLogger) ([]byte, error) {
+	logger.With("bucket", uri.Host, "object", uri.Path).Debug("Loading from Google Cloud Storage")
+
+	cl, err := googleStorage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create google storage client: %w", err)
+	}
+	defer cl.Close()
+
+	bucket := cl.Bucket(uri.Host)
+
+	gsPath := strings.TrimLeft(uri.Path, "/")
+	obj := bucket.Object(gsPath)
+
+	rc, err := obj.NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read bucket object %s: %w", gsPath, err)
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+// S3-compatible storage settings.
+type S3Config struct {
+	Endpoint        string `envconfig:"S3_ENDPOINT"` // Custom endpoint URL (eg MinIO), AWS if empty
+	Region          string `envconfig:"S3_REGION" default:"us-east-1"`
+	PathStyle       bool   `envconfig:"S3_PATH_STYLE"` // Path-style addressing (endpoint/bucket/key)
+	AccessKeyId     string `envconfig:"S3_ACCESS_KEY_ID"`
+	SecretAccessKey string `envconfig:"S3_SECRET_ACCESS_KEY"` // Default AWS credentials chain is used if keys are empty
+}
+
+// Loads 's3://bucket/key' object. Settings from env can be overridden by the URI query
+// parameters 'endpoint', 'region' and 'path_style'.
+func loadS3(ctx context.Context, uri *url.URL, logger *zap.SugaredLogger) ([]byte, error) {
+	var cfg S3Config
+	if err := envconfig.Process(info.EnvPrefix, &cfg); err != nil {
+		return nil, fmt.Errorf("Failed to load S3 config: %w", err)
+	}
+
+	query := uri.Query()
+	if v := query.Get("endpoint"); v != "" {
+		cfg.Endpoint = v
+	}
+	if v := query.Get("region"); v != "" {
+		cfg.Region = v
+	}
+	if v := query.Get("path_style"); v != "" {
+		pathStyle, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid 'path_style' value '%s'", v)
+		}
+		cfg.PathStyle = pathStyle
+	}
+
+	logger.With("bucket", uri.Host, "object", uri.Path, "endpoint", cfg.Endpoint).Debug("Loading from S3")
+
+	return getS3Object(ctx, &cfg, uri.Host, strings.TrimLeft(uri.Path, "/"))
+}
+
+// Loads 'do://bucket/key' object from DigitalOcean Spaces. Kept for compatibility, 's3' scheme can be used instead.
+func loadDigitalOceanSpaces(ctx context.Context, uri *url.URL, logger *zap.SugaredLogger) ([]byte, error) {
+	logger.With("bucket", uri.Host, "object", uri.Path).
+		Debug("Loading config file from Digital Ocean Spaces")
+
+	cfg := &S3Config{
+		Endpoint:        fmt.Sprintf("https://%s.digitaloceanspaces.com", os.Getenv("DO_SPACES_REGION")),
+		Region:          "us-east-1",
+		AccessKeyId:     os.Getenv("DO_SPACES_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("DO_SPACES_SECRET_ACCESS_KEY"),
+	}
+	return getS3Object(ctx, cfg, uri.Host, strings.TrimLeft(uri.Path, "/"))
+}
+
+func getS3Object(ctx context.Context, cfg *S3Config, bucket, key string) ([]byte, error) {
+	opts := []func(*awsConfig.LoadOptions) error{
+		awsConfig.WithRegion(cfg.Region),
+	}
+	if cfg.AccessKeyId != "" || cfg.SecretAccessKey != "" {
+		creds := awsCreds.NewStaticCredentialsProvider(cfg.AccessKeyId, cfg.SecretAccessKey, "")
+		opts = append(opts, awsConfig.WithCredentialsProvider(creds))
+	}
+
+	awsCfg, err := awsConfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load aws config: %w", err)
+	}
+
+	cl := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.PathStyle
+	})
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	obj, err := cl.GetObject(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get object: %w", err)
+	}
+	defer obj.Body.Close()
+
+	return io.ReadAll(obj.Body)
+}
+
+// HTTP(S) source authentication settings. Basic auth credentials can be set in the URI as well.
+type HttpSourceConfig struct {
+	Token    string `envconfig:"BOOTSTRAP_HTTP_TOKEN"` // Bearer token
+	Username string `envconfig:"BOOTSTRAP_HTTP_USERNAME"`
+	Password string `envconfig:"BOOTSTRAP_HTTP_PASSWORD"`
+	// Hosts receiving the credentials, the root config host only if empty
+	Hosts []string `envconfig:"BOOTSTRAP_HTTP_HOSTS"`
+}
+
+// Checks whether the env credentials can be sent to the URI. Included configs can be hosted by third parties,
+// so by default the credentials are sent to the host of the root config only.
+func (c HttpSourceConfig) allowed(ctx context.Context, uri *url.URL) bool {
+	if len(c.Hosts) > 0 {
+		for _, host := range c.Hosts {
+			if strings.EqualFold(strings.TrimSpace(host), uri.Host) {
+				return true
+			}
+		}
+		return false
+	}
+
+	root := rootUri(ctx)
+	if root == nil {
+		return true
+	}
+	return strings.EqualFold(root.Scheme, uri.Scheme) && strings.EqualFold(root.Host, uri.Host)
+}
+
+type rootUriKey struct{}
+
+// Returns the context with the root config URI, sources use it to authenticate included configs requests.
+func contextWithRootUri(ctx context.Context, uri *url.URL) context.Context {
+	return context.WithValue(ctx, rootUriKey{}, uri)
+}
+
+// Returns the root config URI or nil if the file isn't loaded as a part of a config.
+func rootUri(ctx context.Context) *url.URL {
+	uri, _ := ctx.Value(rootUriKey{}).(*url.URL)
+	return uri
+}
+
+// Loads config files by HTTP(S). Caches files by ETag, so unchanged files aren't downloaded again.
+type httpSource struct {
+	cl    *http.Client
+	mu    *sync.Mutex
+	cache map[string]httpCacheEntry // By URI
+}
+
+type httpCacheEntry struct {
+	ETag string
+	Data []byte
+}
+
+func newHttpSource() *httpSource {
+	return &httpSource{
+		cl:    &http.Client{Timeout: 30 * time.Second},
+		mu:    &sync.Mutex{},
+		cache: make(map[string]httpCacheEntry),
+	}
+}
+
+func (s *httpSource) Load(ctx context.Context, uri *url.URL, logger *zap.SugaredLogger) ([]byte, error) {
+	var cfg HttpSourceConfig
+	if err := envconfig.Process(info.EnvPrefix, &cfg); err != nil {
+		return nil, fmt.Errorf("Failed to load HTTP source config: %w", err)
+	}
+
+	target := *uri
+	if !cfg.allowed(ctx, &target) {
+		logger.With("host", target.Host).Debug("Host isn't the root config one, not sending env credentials")
+		cfg = HttpSourceConfig{}
+	}
+	if target.User != nil {
+		cfg.Username = target.User.Username()
+		cfg.Password, _ = target.User.Password()
+		target.User = nil
+	}
+	key := target.String()
+
+	logger.With("url", target.Redacted()).Debug("Loading from HTTP")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new request: %w", err)
+	}
+	switch {
+	case cfg.Token != "":
+		req.Header.Set("Authorization", "Bearer "+cfg.Token)
+	case cfg.Username != "":
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+
+	s.mu.Lock()
+	cached, isCached := s.cache[key]
+	s.mu.Unlock()
+	if isCached {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := s.cl.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && isCached:
+		logger.Debug("Config file isn't modified, using cached one")
+		return cached.Data, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("Bad response code (%d)", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read response: %w", err)
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		s.mu.Lock()
+		s.cache[key] = httpCacheEntry{ETag: etag, Data: data}
+		s.mu.Unlock()
+	}
+
+	return data, nil
+}
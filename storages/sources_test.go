@@ -0,0 +1,163 @@
+package storages
+
+import (
+	"broadcaster/utils/logging"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_RegisterConfigSource(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewLogger("fatal", "pretty")
+
+	_, err := loadFileByUri(ctx, "mem://config.yml", logger)
+	require.Error(t, err)
+
+	RegisterConfigSource("mem", ConfigSourceFunc(func(ctx context.Context, uri *url.URL, logger *zap.SugaredLogger) ([]byte, error) {
+		return []byte(uri.Host), nil
+	}))
+	t.Cleanup(func() {
+		sourcesMu.Lock()
+		delete(sources, "mem")
+		sourcesMu.Unlock()
+	})
+
+	data, err := loadFileByUri(ctx, "mem://config.yml", logger)
+	require.NoError(t, err)
+	require.Equal(t, "config.yml", string(data))
+}
+
+func Test_httpSource(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewLogger("fatal", "pretty")
+
+	var downloads int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		bearer := r.Header.Get("Authorization") == "Bearer secret"
+		if !bearer && (!ok || user != "user" || pass != "pass") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("feeds: []"))
+	}))
+	defer srv.Close()
+
+	withCreds := strings.Replace(srv.URL, "http://", "http://user:pass@", 1) + "/config.yml"
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		_, err := newHttpSource().Load(ctx, mustParseUrl(t, srv.URL+"/config.yml"), logger)
+		require.Error(t, err)
+	})
+
+	t.Run("BasicAuth", func(t *testing.T) {
+		t.Setenv("BCTR_BOOTSTRAP_HTTP_USERNAME", "user")
+		t.Setenv("BCTR_BOOTSTRAP_HTTP_PASSWORD", "pass")
+
+		data, err := newHttpSource().Load(ctx, mustParseUrl(t, srv.URL+"/config.yml"), logger)
+		require.NoError(t, err)
+		require.Equal(t, "feeds: []", string(data))
+	})
+
+	t.Run("Bearer", func(t *testing.T) {
+		t.Setenv("BCTR_BOOTSTRAP_HTTP_TOKEN", "secret")
+
+		data, err := newHttpSource().Load(ctx, mustParseUrl(t, srv.URL+"/config.yml"), logger)
+		require.NoError(t, err)
+		require.Equal(t, "feeds: []", string(data))
+	})
+
+	t.Run("ETag", func(t *testing.T) {
+		downloads = 0
+		src := newHttpSource()
+
+		for i := 0; i < 3; i++ {
+			data, err := src.Load(ctx, mustParseUrl(t, withCreds), logger)
+			require.NoError(t, err)
+			require.Equal(t, "feeds: []", string(data))
+		}
+		require.Equal(t, 1, downloads)
+	})
+}
+
+func Test_httpSource_includes(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewLogger("fatal", "pretty")
+
+	var includeAuth []string
+	included := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		includeAuth = append(includeAuth, r.Header.Get("Authorization"))
+		w.Write([]byte("feeds: []"))
+	}))
+	defer included.Close()
+
+	root := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("include:\n  - " + included.URL + "/feeds.yml\nfeeds: []"))
+	}))
+	defer root.Close()
+
+	t.Setenv("BCTR_BOOTSTRAP_HTTP_TOKEN", "secret")
+
+	t.Run("OtherHost", func(t *testing.T) {
+		includeAuth = nil
+		_, err := GetBootstrapConfig(ctx, root.URL+"/config.yml", logger)
+		require.NoError(t, err)
+		require.Equal(t, []string{""}, includeAuth, "Credentials are sent to the root config host only")
+	})
+
+	t.Run("AllowedHosts", func(t *testing.T) {
+		t.Setenv("BCTR_BOOTSTRAP_HTTP_HOSTS", strings.TrimPrefix(root.URL, "http://")+","+strings.TrimPrefix(included.URL, "http://"))
+
+		includeAuth = nil
+		_, err := GetBootstrapConfig(ctx, root.URL+"/config.yml", logger)
+		require.NoError(t, err)
+		require.Equal(t, []string{"Bearer secret"}, includeAuth)
+	})
+}
+
+func Test_loadS3(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewLogger("fatal", "pretty")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bucket/path/config.yml" || !strings.Contains(r.Header.Get("Authorization"), "Credential=key/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("feeds: []"))
+	}))
+	defer srv.Close()
+
+	t.Setenv("BCTR_S3_ACCESS_KEY_ID", "key")
+	t.Setenv("BCTR_S3_SECRET_ACCESS_KEY", "secret")
+
+	data, err := loadFileByUri(ctx, "s3://bucket/path/config.yml?path_style=true&endpoint="+url.QueryEscape(srv.URL), logger)
+	require.NoError(t, err)
+	require.Equal(t, "feeds: []", string(data))
+
+	_, err = loadFileByUri(ctx, "s3://bucket/path/config.yml?path_style=maybe", logger)
+	require.Error(t, err)
+}
+
+func mustParseUrl(t *testing.T, raw string) *url.URL {
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u
+}
@@ -10,15 +10,41 @@ import (
 var (
 	NotImplementedError error = errors.New("Not implemented")
 	ItemNotFoundError   error = errors.New("Item not found")
+	ItemExistsError     error = errors.New("Item already exists")
 )
 
 type FeedsStorage interface {
+	Create(ctx context.Context, req FeedsStorageCreateRequest) (*structs.RssFeed, error)
 	List(ctx context.Context) ([]structs.RssFeed, error)
 	Find(ctx context.Context, req FeedsStorageFindRequest) (*structs.RssFeed, error)
 	Delete(ctx context.Context, req FeedsStorageDeleteRequest) error
 	Update(ctx context.Context, req FeedsStorageUpdateRequest) (*structs.RssFeed, error)
 }
 
+type FeedsStorageCreateRequest struct {
+	Id         string
+	Source     string
+	Category   string
+	URL        string
+	Language   string
+	ItemsLimit int
+	Notify     []structs.RssFeedNotification
+}
+
+func (r FeedsStorageCreateRequest) ToRssFeed() structs.RssFeed {
+	return structs.RssFeed{
+		Id:            r.Id,
+		Source:        r.Source,
+		Category:      r.Category,
+		URL:           r.URL,
+		Language:      r.Language,
+		ItemsLimit:    r.ItemsLimit,
+		Notifications: r.Notify,
+		Status:        structs.FeedStatusActive,
+		Managed:       true,
+	}
+}
+
 type FeedsStorageFindRequest struct {
 	Id string
 }
@@ -34,6 +60,11 @@ type FeedsStorageUpdateRequest struct {
 	ItemsLimit int
 	Notify     []structs.RssFeedNotification
 	Translates []structs.RssFeedTranslation
+	// Status is updated with the message if specified
+	Status        structs.FeedStatus
+	StatusMessage string
+	// Status is updated only if the current one is any of these, if specified
+	IfStatus []structs.FeedStatus
 }
 
 type FeedItemsStorage interface {
@@ -45,30 +76,38 @@ type FeedItemsStorage interface {
 }
 
 type FeedItemsCreateRequest struct {
-	Id          string
-	FeedId      string
-	Source      string
-	Categories  []string
-	Title       string
-	Description string
-	PubDate     time.Time
-	Processed   time.Time
-	Link        string
-	Language    string
+	Id           string
+	FeedId       string
+	Source       string
+	Categories   []string
+	Title        string
+	Description  string
+	Content      string
+	Image        string
+	Enclosures   []structs.RssFeedEnclosure
+	PubDate      time.Time
+	Processed    time.Time
+	Link         string
+	Language     string
+	Translations map[string]structs.RssFeedItemTranslation
 }
 
 func (r FeedItemsCreateRequest) ToRssFeedItem() structs.RssFeedItem {
 	return structs.RssFeedItem{
-		Id:          r.Id,
-		FeedId:      r.FeedId,
-		Source:      r.Source,
-		Categories:  r.Categories,
-		Title:       r.Title,
-		Description: r.Description,
-		PubDate:     r.PubDate,
-		Processed:   r.Processed,
-		Link:        r.Link,
-		Language:    r.Language,
+		Id:           r.Id,
+		FeedId:       r.FeedId,
+		Source:       r.Source,
+		Categories:   r.Categories,
+		Title:        r.Title,
+		Description:  r.Description,
+		Content:      r.Content,
+		Image:        r.Image,
+		Enclosures:   r.Enclosures,
+		PubDate:      r.PubDate,
+		Processed:    r.Processed,
+		Link:         r.Link,
+		Language:     r.Language,
+		Translations: r.Translations,
 	}
 }
 
@@ -80,12 +119,14 @@ type FeedItemsStorageDeleteRequest struct {
 	Id string
 }
 
+// Filters are applied if set. Items are sorted by publication date, newest first.
 type FeedItemsListRequest struct {
 	Limit      int
+	FeedIds    []string
 	Sources    []string
-	Categories []string
+	Categories []string // Items with any of the categories
 	Languages  []string
-	PubDate    *time.Time
+	PubDate    *time.Time // Items published after the date
 }
 
 type FeedItemsUpdateRequest struct {
@@ -0,0 +1,302 @@
+package storages
+
+import (
+	"broadcaster/structs"
+	"broadcaster/utils/strutil"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+// Position of the value in the config file.
+type ConfigPosition struct {
+	URI    string
+	Line   int
+	Column int
+}
+
+func nodePosition(node *yaml.Node) ConfigPosition {
+	return ConfigPosition{Line: node.Line, Column: node.Column}
+}
+
+// Returns position in 'uri:line:column' format.
+func (p ConfigPosition) String() string {
+	result := p.URI
+	if p.Line > 0 {
+		result += ":" + strconv.Itoa(p.Line)
+		if p.Column > 0 {
+			result += ":" + strconv.Itoa(p.Column)
+		}
+	}
+	return result
+}
+
+type ConfigProblem struct {
+	Pos     ConfigPosition
+	Message string
+}
+
+func (p ConfigProblem) String() string {
+	if pos := p.Pos.String(); pos != "" {
+		return pos + ": " + p.Message
+	}
+	return p.Message
+}
+
+// Config problems. Can be used as an error.
+type ConfigProblems []ConfigProblem
+
+func (p *ConfigProblems) Add(pos ConfigPosition, format string, args ...interface{}) {
+	*p = append(*p, ConfigProblem{Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+func (p ConfigProblems) Error() string {
+	lines := make([]string, 0, len(p))
+	for _, problem := range p {
+		lines = append(lines, problem.String())
+	}
+	return fmt.Sprintf("Invalid config, %d problem(s):\n%s", len(p), strings.Join(lines, "\n"))
+}
+
+// Returns nil if there are no problems.
+func (p ConfigProblems) Err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return p
+}
+
+// Sorts problems by positions.
+func (p ConfigProblems) sort() {
+	sort.SliceStable(p, func(i, j int) bool {
+		a, b := p[i].Pos, p[j].Pos
+		if a.URI != b.URI {
+			return a.URI < b.URI
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// Checks the loaded bootstrap config.
+type ConfigValidator func(c *BootstrapConfig) ConfigProblems
+
+var (
+	validatorsMu sync.RWMutex
+	validators   []ConfigValidator
+)
+
+// Registers the validator called by Validate.
+// Allows services to check config parts they are responsible for (eg notifiers types).
+func RegisterConfigValidator(v ConfigValidator) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	validators = append(validators, v)
+}
+
+// Returns all config problems sorted by positions: found during loading, by the built-in checks
+// and registered validators. Returns nil if the config is valid.
+func (c *BootstrapConfig) Validate() error {
+	problems := append(ConfigProblems{}, c.problems...)
+	problems = append(problems, c.validateNotifiers()...)
+	problems = append(problems, c.validateFeeds()...)
+
+	validatorsMu.RLock()
+	for _, v := range validators {
+		problems = append(problems, v(c)...)
+	}
+	validatorsMu.RUnlock()
+
+	problems.sort()
+	return problems.Err()
+}
+
+// Checks notifiers instances have unique names and types.
+func (c *BootstrapConfig) validateNotifiers() ConfigProblems {
+	var problems ConfigProblems
+	names := make(map[string]struct{}, len(c.Notifiers))
+	for i, n := range c.Notifiers {
+		if n.Name == "" {
+			problems.Add(n.Pos, "Notifier #%d name is required", i+1)
+			continue
+		}
+		if n.Type == "" {
+			problems.Add(n.Pos, "Notifier '%s' type is required", n.Name)
+		}
+		if _, exists := names[n.Name]; exists {
+			problems.Add(n.Pos, "Duplicate notifier '%s'", n.Name)
+		}
+		names[n.Name] = struct{}{}
+	}
+	return problems
+}
+
+// Checks feeds settings and their notifications.
+func (c *BootstrapConfig) validateFeeds() ConfigProblems {
+	var problems ConfigProblems
+	ids := make(map[string]ConfigPosition, len(c.Feeds))
+
+	for _, feed := range c.Feeds {
+		if feed.Source == "" {
+			problems.Add(feed.Pos, "Feed source is required")
+			continue
+		}
+
+		id := feed.ToRssFeed().Id
+		if first, exists := ids[id]; exists {
+			problems.Add(feed.Pos, "Duplicate feed id '%s', first defined at %s", id, first)
+		} else {
+			ids[id] = feed.Pos
+		}
+
+		if feed.URL == "" {
+			problems.Add(feed.Pos, "Feed '%s' url is required", id)
+		} else if feedURL, err := ExpandFeedURL(feed.URL); err != nil {
+			problems.Add(feed.Pos, "Feed '%s' url: %s", id, err.Error())
+		} else if u, err := url.Parse(feedURL); err != nil || !u.IsAbs() {
+			problems.Add(feed.Pos, "Feed '%s' url '%s' must be an absolute URL", id, feed.URL)
+		}
+		if feed.Language != "" && !validLanguage(feed.Language) {
+			problems.Add(feed.Pos, "Feed '%s' language '%s' is invalid", id, feed.Language)
+		}
+		if feed.ItemsLimit < 0 {
+			problems.Add(feed.Pos, "Feed '%s' items limit can't be negative", id)
+		}
+		switch structs.ItemIdStrategy(feed.IdStrategy) {
+		case "", structs.ItemIdStrategyGUID, structs.ItemIdStrategyLink, structs.ItemIdStrategyHash:
+		default:
+			problems.Add(feed.Pos, "Feed '%s' id strategy '%s' is invalid", id, feed.IdStrategy)
+		}
+		typ := structs.FeedType(strutil.Coalesce(feed.Type, string(structs.FeedTypeRSS)))
+		switch typ {
+		case structs.FeedTypeRSS, structs.FeedTypeSitemap:
+		case structs.FeedTypeHTML:
+			if feed.HTML == nil || feed.HTML.Item == "" || feed.HTML.Title == "" {
+				problems.Add(feed.Pos, "Feed '%s' html item and title selectors are required", id)
+			}
+		case structs.FeedTypeJSON:
+			if feed.JSON == nil || feed.JSON.Items == "" || feed.JSON.Title == "" {
+				problems.Add(feed.Pos, "Feed '%s' json items and title paths are required", id)
+			}
+		default:
+			problems.Add(feed.Pos, "Feed '%s' type '%s' is invalid", id, feed.Type)
+		}
+		if feed.HTML != nil && typ != structs.FeedTypeHTML {
+			problems.Add(feed.Pos, "Feed '%s' html selectors are set for the %s feed", id, typ)
+		}
+		if feed.JSON != nil && typ != structs.FeedTypeJSON {
+			problems.Add(feed.Pos, "Feed '%s' json mapping is set for the %s feed", id, typ)
+		}
+
+		for _, n := range feed.Notifications {
+			if n.Type == "" && n.Notifier == "" {
+				problems.Add(n.Pos, "Feed '%s' notification type or notifier is required", id)
+			}
+			for _, lang := range []string{n.Translate.From, n.Translate.To} {
+				if lang != "" && !validLanguage(lang) {
+					problems.Add(n.Pos, "Feed '%s' notification translation language '%s' is invalid", id, lang)
+				}
+			}
+			switch structs.NotificationPriority(n.Priority) {
+			case "", structs.NotificationPriorityMin, structs.NotificationPriorityLow, structs.NotificationPriorityDefault,
+				structs.NotificationPriorityHigh, structs.NotificationPriorityMax:
+			default:
+				problems.Add(n.Pos, "Feed '%s' notification priority '%s' is invalid", id, n.Priority)
+			}
+		}
+	}
+
+	return problems
+}
+
+func validLanguage(code string) bool {
+	_, err := language.Parse(code)
+	return err == nil
+}
+
+// Returns problems for mappings keys not matching the type fields.
+func unknownFields(node *yaml.Node, t reflect.Type) ConfigProblems {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var problems ConfigProblems
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			problems = append(problems, unknownFields(n, t)...)
+		}
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice {
+			break
+		}
+		for _, n := range node.Content {
+			problems = append(problems, unknownFields(n, t.Elem())...)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			switch {
+			case key.Value == "<<":
+				problems = append(problems, unknownFields(value, t)...)
+			case t.Kind() == reflect.Map:
+				problems = append(problems, unknownFields(value, t.Elem())...)
+			case t.Kind() == reflect.Struct:
+				field, ok := yamlField(t, key.Value)
+				if !ok {
+					problems.Add(nodePosition(key), "Unknown field '%s'", key.Value)
+					continue
+				}
+				problems = append(problems, unknownFields(value, field.Type)...)
+			}
+		}
+	}
+	return problems
+}
+
+// Returns the struct field by its yaml name.
+func yamlField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if tag == "-" {
+			continue
+		}
+		if tag == "" {
+			tag = strings.ToLower(field.Name)
+		}
+		if tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+var typeErrorLine = regexp.MustCompile(`^line (\d+): (.+)$`)
+
+// Converts yaml decoding error messages to problems.
+func typeErrorProblems(err *yaml.TypeError) ConfigProblems {
+	var problems ConfigProblems
+	for _, msg := range err.Errors {
+		var pos ConfigPosition
+		if m := typeErrorLine.FindStringSubmatch(msg); m != nil {
+			pos.Line, _ = strconv.Atoi(m[1])
+			msg = m[2]
+		}
+		problems.Add(pos, "Invalid value: %s", msg)
+	}
+	return problems
+}
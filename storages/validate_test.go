@@ -0,0 +1,55 @@
+package storages
+
+import (
+	"broadcaster/utils/logging"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetBootstrapConfig_problems(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewLogger("fatal", "pretty")
+
+	cpath, err := os.Getwd()
+	require.NoError(t, err)
+	uri := fmt.Sprintf("file://%s/testdata/bootstrap_invalid.yml", cpath)
+
+	_, err = GetBootstrapConfig(ctx, uri, logger)
+	require.Error(t, err)
+
+	var problems ConfigProblems
+	require.True(t, errors.As(err, &problems))
+
+	var lines []string
+	for _, p := range problems {
+		lines = append(lines, p.String())
+	}
+	require.Equal(t, []string{
+		uri + ":2:5: Notifier #1 name is required",
+		uri + ":4:5: Feed 'HelsinginSanomat.City' language 'finnish' is invalid",
+		uri + ":8: Invalid value: cannot unmarshal !!str `many` into int",
+		uri + ":10:9: Feed 'HelsinginSanomat.City' notification type or notifier is required",
+		uri + ":10:9: Feed 'HelsinginSanomat.City' notification translation language 'english' is invalid",
+		uri + ":13:5: Duplicate feed id 'HelsinginSanomat.City', first defined at " + uri + ":4:5",
+		uri + ":13:5: Feed 'HelsinginSanomat.City' url 'kaupunki.xml' must be an absolute URL",
+		uri + ":13:5: Feed 'HelsinginSanomat.City' id strategy 'random' is invalid",
+		uri + ":14:5: Unknown field 'categry'",
+		uri + ":18:5: Feed 'Example' html item and title selectors are required",
+		uri + ":23:5: Feed 'Example.Blog' type 'gopher' is invalid",
+		uri + ":27:5: Feed 'Example.Api' json items and title paths are required",
+		uri + ":27:5: Feed 'Example.Api' html selectors are set for the json feed",
+		uri + ":34:5: Feed 'Example.Videos' url: Invalid YouTube feed 'youtube:UC_x5XG1OV2P6uZ', expected 'youtube:channel/<id>' or 'youtube:playlist/<id>'",
+	}, lines)
+}
+
+func Test_ConfigPosition_String(t *testing.T) {
+	require.Equal(t, "", ConfigPosition{}.String())
+	require.Equal(t, "file:///config.yml", ConfigPosition{URI: "file:///config.yml"}.String())
+	require.Equal(t, "file:///config.yml:3", ConfigPosition{URI: "file:///config.yml", Line: 3}.String())
+	require.Equal(t, "file:///config.yml:3:5", ConfigPosition{URI: "file:///config.yml", Line: 3, Column: 5}.String())
+}
@@ -10,16 +10,136 @@ type RssFeed struct {
 	Source        string
 	Category      string
 	URL           string
+	Type          FeedType
+	HTML          *HTMLSelectors // Items selectors of 'html' feeds
+	JSON          *JSONMapping   // Items fields mapping of 'json' feeds
 	Language      string
 	ItemsLimit    int
+	IdStrategy    ItemIdStrategy
+	FetchContent  bool // Download full articles content by items links
 	Notifications []RssFeedNotification
+	Status        FeedStatus
+	StatusMessage string // Status reason, eg the last fetch error for erroring feeds
+	Managed       bool   // Created by the API, not by the config. Config reloads keep such feeds
 }
 
+// Feed source type.
+type FeedType string
+
+const (
+	FeedTypeRSS     FeedType = "rss"     // RSS, Atom or JSON Feed (default if empty)
+	FeedTypeHTML    FeedType = "html"    // Items scraped from the HTML page by CSS selectors
+	FeedTypeJSON    FeedType = "json"    // Items of the JSON API response mapped by JSONPath expressions
+	FeedTypeSitemap FeedType = "sitemap" // News sitemap
+)
+
+// CSS selectors of the HTML page items. Selectors ending with '@attr' use the attribute value instead of the text.
+type HTMLSelectors struct {
+	Item        string // Items containers, other selectors are relative to them
+	Title       string
+	Link        string // The item itself or its first link if empty
+	Date        string
+	DateFormat  string // Go time layout of dates, common formats are detected if empty
+	Description string
+}
+
+// JSONPath expressions of the JSON API response items and their fields.
+// Items path is relative to the response root, fields paths are relative to items.
+type JSONMapping struct {
+	Items       string
+	Id          string
+	Title       string
+	Link        string
+	Date        string
+	DateFormat  string // Go time layout of dates, common formats and unix timestamps are detected if empty
+	Description string
+	Image       string
+}
+
+// Feed lifecycle status.
+type FeedStatus string
+
+const (
+	FeedStatusActive   FeedStatus = "active"   // Feed is processed
+	FeedStatusPaused   FeedStatus = "paused"   // Feed processing is paused with the API
+	FeedStatusDisabled FeedStatus = "disabled" // Feed is disabled in the config
+	FeedStatusErroring FeedStatus = "erroring" // Feed fetches fail, fetches are retried with backoff
+)
+
+// Returns whether the status is set at runtime (by the API or the processing) rather than by the config.
+func (s FeedStatus) Runtime() bool {
+	return s == FeedStatusPaused || s == FeedStatusErroring
+}
+
+// Defines how feed items identifiers are built.
+type ItemIdStrategy string
+
+const (
+	ItemIdStrategyGUID ItemIdStrategy = "guid" // Item GUID, falls back to link and hash (default)
+	ItemIdStrategyLink ItemIdStrategy = "link" // Item link, falls back to hash
+	ItemIdStrategyHash ItemIdStrategy = "hash" // Hash of the item link and title
+)
+
 type RssFeedNotification struct {
-	Type      string
-	To        []string
-	Muted     bool
-	Translate RssFeedTranslation
+	Type           string
+	Notifier       string // Named notifier instance, the type default instance is used if empty
+	To             []string
+	Muted          bool
+	Translate      RssFeedTranslation
+	Bot            string // Named bot credentials (Telegram)
+	Silent         bool   // Send without notification sound
+	ProtectContent bool   // Protect messages from forwarding and saving (Telegram)
+	Preview        *bool  // Overrides links previews setting if specified
+	Priority       NotificationPriority
+	Tags           []string // Notification tags (ntfy)
+	Visibility     string   // Posts visibility (Mastodon)
+	ContentWarning string   // Warning shown instead of posts text until expanded (Mastodon)
+	QuietHours     *QuietHours
+}
+
+// Daily time window when notifications aren't sent immediately.
+type QuietHours struct {
+	Timezone string   // IANA time zone name, UTC if empty
+	From     string   // Window start in 'HH:MM' format
+	To       string   // Window end in 'HH:MM' format, windows ending before the start end on the next day
+	Weekdays []string // Days of the window start ('mon', 'tue', ...), every day if empty
+	Action   QuietHoursAction
+}
+
+// What to do with notifications during quiet hours.
+type QuietHoursAction string
+
+const (
+	QuietHoursActionQueue  QuietHoursAction = "queue"  // Send after the window end (default)
+	QuietHoursActionSilent QuietHoursAction = "silent" // Send without notification sound
+	QuietHoursActionDrop   QuietHoursAction = "drop"   // Don't send
+)
+
+// Notification priority, mapped to the notifier specific priority levels.
+type NotificationPriority string
+
+const (
+	NotificationPriorityMin     NotificationPriority = "min"
+	NotificationPriorityLow     NotificationPriority = "low"
+	NotificationPriorityDefault NotificationPriority = "default"
+	NotificationPriorityHigh    NotificationPriority = "high"
+	NotificationPriorityMax     NotificationPriority = "max"
+)
+
+// Returns the priority level from 0 (min) to 4 (max). Empty or unknown priority is the default one.
+func (p NotificationPriority) Level() int {
+	switch p {
+	case NotificationPriorityMin:
+		return 0
+	case NotificationPriorityLow:
+		return 1
+	case NotificationPriorityHigh:
+		return 3
+	case NotificationPriorityMax:
+		return 4
+	default:
+		return 2
+	}
 }
 
 type RssFeedTranslation struct {
@@ -34,10 +154,48 @@ type RssFeedItem struct {
 	Categories  []string
 	Title       string
 	Description string
+	Content     string // Full article text (if the feed content fetching is enabled)
+	Image       string // Lead image URL
+	Enclosures  []RssFeedEnclosure
 	Link        string
 	Language    string
 	PubDate     time.Time // Publication date (from the feed)
 	Processed   time.Time // When the item was processed by the service
+	// Translated fields by language, made by feeds processing
+	Translations map[string]RssFeedItemTranslation
+}
+
+// Translated fields of the feed item.
+type RssFeedItemTranslation struct {
+	Title       string
+	Description string
+	Content     string
+	Link        string
+}
+
+// Returns the item translated into the language if it's in the language or has the translation.
+func (i RssFeedItem) Translated(lang string) (RssFeedItem, bool) {
+	if i.Language == lang {
+		return i, true
+	}
+	t, exists := i.Translations[lang]
+	if !exists {
+		return i, false
+	}
+	i.Title = t.Title
+	i.Description = t.Description
+	i.Content = t.Content
+	i.Link = t.Link
+	i.Language = lang
+	i.Translations = nil
+	return i, true
+}
+
+// Media file attached to the feed item.
+type RssFeedEnclosure struct {
+	URL    string
+	Type   string // MIME type
+	Length int64  // Size in bytes (if known)
 }
 
 // Returns a list of languages to which the feed items should be translated.
@@ -31,6 +31,11 @@ const (
 )
 
 func NewLogger(level string, format Format) *zap.SugaredLogger {
+	return NewLoggerWithOutput(level, format, "stdout")
+}
+
+// Creates new logger writing to the output path ('stdout', 'stderr' or a file path).
+func NewLoggerWithOutput(level string, format Format, output string) *zap.SugaredLogger {
 	var config *zap.Config
 
 	switch format {
@@ -114,6 +119,7 @@ func NewLogger(level string, format Format) *zap.SugaredLogger {
 			ErrorOutputPaths: []string{"stderr"},
 		}
 	}
+	config.OutputPaths = []string{output}
 
 	logger, err := config.Build()
 	if err != nil {
@@ -2,6 +2,8 @@ package logging
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -17,6 +19,19 @@ func Test_NewLogger(t *testing.T) {
 	}
 }
 
+func Test_NewLoggerWithOutput(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	logger := NewLoggerWithOutput("info", FormatJSON, path)
+	logger.Info("Hello")
+	require.NoError(t, logger.Sync())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"msg":"Hello"`)
+}
+
 func Test_Context(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,12 @@
+// Package strutil keeps strings helpers shared by services and storages.
+package strutil
+
+// Returns the first non-empty string.
+func Coalesce(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
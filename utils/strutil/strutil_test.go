@@ -0,0 +1,13 @@
+package strutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Coalesce(t *testing.T) {
+	require.Equal(t, "", Coalesce())
+	require.Equal(t, "", Coalesce("", ""))
+	require.Equal(t, "one", Coalesce("", "one", "two"))
+}